@@ -0,0 +1,4 @@
+// Package resourceclientv1 is the gRPC API served by the rpc package, generated from resourceclient.proto.
+package resourceclientv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative resourceclient/v1/resourceclient.proto
@@ -0,0 +1,298 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: resourceclient/v1/resourceclient.proto
+
+// Package resourceclient.v1 serves the discovery, access map and watches of one client to services that can
+// not import the Go library. Objects are sent as the JSON of the Kubernetes object.
+
+package resourceclientv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ResourceClient_ListResources_FullMethodName = "/resourceclient.v1.ResourceClient/ListResources"
+	ResourceClient_CheckAccess_FullMethodName   = "/resourceclient.v1.ResourceClient/CheckAccess"
+	ResourceClient_ListObjects_FullMethodName   = "/resourceclient.v1.ResourceClient/ListObjects"
+	ResourceClient_GetObject_FullMethodName     = "/resourceclient.v1.ResourceClient/GetObject"
+	ResourceClient_Watch_FullMethodName         = "/resourceclient.v1.ResourceClient/Watch"
+)
+
+// ResourceClientClient is the client API for ResourceClient service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ResourceClientClient interface {
+	// ListResources returns the discovered resources.
+	ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ListResourcesResponse, error)
+	// CheckAccess returns the access status of verbs on a resource in a namespace.
+	CheckAccess(ctx context.Context, in *CheckAccessRequest, opts ...grpc.CallOption) (*CheckAccessResponse, error)
+	// ListObjects returns a page of the cached objects of a resource.
+	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error)
+	// GetObject returns a cached object.
+	GetObject(ctx context.Context, in *GetObjectRequest, opts ...grpc.CallOption) (*Object, error)
+	// Watch streams the current objects of resources followed by their changes.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ResourceClient_WatchClient, error)
+}
+
+type resourceClientClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewResourceClientClient(cc grpc.ClientConnInterface) ResourceClientClient {
+	return &resourceClientClient{cc}
+}
+
+func (c *resourceClientClient) ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ListResourcesResponse, error) {
+	out := new(ListResourcesResponse)
+	err := c.cc.Invoke(ctx, ResourceClient_ListResources_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceClientClient) CheckAccess(ctx context.Context, in *CheckAccessRequest, opts ...grpc.CallOption) (*CheckAccessResponse, error) {
+	out := new(CheckAccessResponse)
+	err := c.cc.Invoke(ctx, ResourceClient_CheckAccess_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceClientClient) ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error) {
+	out := new(ListObjectsResponse)
+	err := c.cc.Invoke(ctx, ResourceClient_ListObjects_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceClientClient) GetObject(ctx context.Context, in *GetObjectRequest, opts ...grpc.CallOption) (*Object, error) {
+	out := new(Object)
+	err := c.cc.Invoke(ctx, ResourceClient_GetObject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceClientClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ResourceClient_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ResourceClient_ServiceDesc.Streams[0], ResourceClient_Watch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &resourceClientWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ResourceClient_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type resourceClientWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *resourceClientWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ResourceClientServer is the server API for ResourceClient service.
+// All implementations must embed UnimplementedResourceClientServer
+// for forward compatibility
+type ResourceClientServer interface {
+	// ListResources returns the discovered resources.
+	ListResources(context.Context, *ListResourcesRequest) (*ListResourcesResponse, error)
+	// CheckAccess returns the access status of verbs on a resource in a namespace.
+	CheckAccess(context.Context, *CheckAccessRequest) (*CheckAccessResponse, error)
+	// ListObjects returns a page of the cached objects of a resource.
+	ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error)
+	// GetObject returns a cached object.
+	GetObject(context.Context, *GetObjectRequest) (*Object, error)
+	// Watch streams the current objects of resources followed by their changes.
+	Watch(*WatchRequest, ResourceClient_WatchServer) error
+	mustEmbedUnimplementedResourceClientServer()
+}
+
+// UnimplementedResourceClientServer must be embedded to have forward compatible implementations.
+type UnimplementedResourceClientServer struct {
+}
+
+func (UnimplementedResourceClientServer) ListResources(context.Context, *ListResourcesRequest) (*ListResourcesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListResources not implemented")
+}
+func (UnimplementedResourceClientServer) CheckAccess(context.Context, *CheckAccessRequest) (*CheckAccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckAccess not implemented")
+}
+func (UnimplementedResourceClientServer) ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListObjects not implemented")
+}
+func (UnimplementedResourceClientServer) GetObject(context.Context, *GetObjectRequest) (*Object, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetObject not implemented")
+}
+func (UnimplementedResourceClientServer) Watch(*WatchRequest, ResourceClient_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedResourceClientServer) mustEmbedUnimplementedResourceClientServer() {}
+
+// UnsafeResourceClientServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ResourceClientServer will
+// result in compilation errors.
+type UnsafeResourceClientServer interface {
+	mustEmbedUnimplementedResourceClientServer()
+}
+
+func RegisterResourceClientServer(s grpc.ServiceRegistrar, srv ResourceClientServer) {
+	s.RegisterService(&ResourceClient_ServiceDesc, srv)
+}
+
+func _ResourceClient_ListResources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListResourcesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceClientServer).ListResources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResourceClient_ListResources_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceClientServer).ListResources(ctx, req.(*ListResourcesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceClient_CheckAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceClientServer).CheckAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResourceClient_CheckAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceClientServer).CheckAccess(ctx, req.(*CheckAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceClient_ListObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListObjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceClientServer).ListObjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResourceClient_ListObjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceClientServer).ListObjects(ctx, req.(*ListObjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceClient_GetObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceClientServer).GetObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResourceClient_GetObject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceClientServer).GetObject(ctx, req.(*GetObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceClient_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ResourceClientServer).Watch(m, &resourceClientWatchServer{stream})
+}
+
+type ResourceClient_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type resourceClientWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *resourceClientWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ResourceClient_ServiceDesc is the grpc.ServiceDesc for ResourceClient service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ResourceClient_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "resourceclient.v1.ResourceClient",
+	HandlerType: (*ResourceClientServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListResources",
+			Handler:    _ResourceClient_ListResources_Handler,
+		},
+		{
+			MethodName: "CheckAccess",
+			Handler:    _ResourceClient_CheckAccess_Handler,
+		},
+		{
+			MethodName: "ListObjects",
+			Handler:    _ResourceClient_ListObjects_Handler,
+		},
+		{
+			MethodName: "GetObject",
+			Handler:    _ResourceClient_GetObject_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _ResourceClient_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "resourceclient/v1/resourceclient.proto",
+}
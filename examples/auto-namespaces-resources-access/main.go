@@ -6,40 +6,28 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
-	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	r6eCache "github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	r6eClient "github.com/wwitzel3/k8s-resource-client/pkg/client"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 )
 
 func main() {
-	var kubeconfig *string
-	if home := homeDir(); home != "" {
-		kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
-	} else {
-		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-	}
+	kubeconfig := flag.String("kubeconfig", "", "(optional) kubeconfig files, defaults to KUBECONFIG or ~/.kube/config")
+	kubeContext := flag.String("context", "", "(optional) kubeconfig context, defaults to the current-context")
 	flag.Parse()
 
-	// use the current context in kubeconfig
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
-	if err != nil {
-		panic(err.Error())
-	}
-
-	config.QPS = 500
-	config.Burst = 1000
-
 	ctx := context.Background()
 
-	client, err := r6eClient.NewClient(ctx, r6eClient.WithRESTConfig(config))
+	client, err := r6eClient.NewClientFromKubeconfig(ctx, *kubeconfig, *kubeContext)
 	if err != nil {
 		panic(err)
 	}
@@ -70,7 +58,12 @@ func main() {
 
 	r6eClient.WatchAllResources(ctx, client, false, []string{""})
 
-	c := make(chan os.Signal)
+	// Wait for the initial List of every watch so the first loop does not report empty caches
+	if err := r6eClient.WaitForWatchesSync(ctx, client, 30*time.Second); err != nil {
+		fmt.Println("not all watches synced:", err)
+	}
+
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
@@ -78,19 +71,21 @@ func main() {
 	}()
 
 	for {
-		fmt.Println("active watcher count:", cache.WatchCount(true))
-		v, ok := cache.ResourceWatches.Load("v1.Pod")
-		if !ok {
+		fmt.Println("active watcher count:", len(cache.WatchStatuses(cache.ActiveWatchStates...)))
+		watcher, err := cache.WatchForResource(podResource)
+		if err != nil {
 			fmt.Println("not found")
-		}
-		watcher, ok := v.(*cache.WatchDetail)
-		if !ok {
-			fmt.Println("bad conversion")
+			time.Sleep(5 * time.Second)
+			continue
 		}
 
 		fmt.Println("pod counts by namespaces")
 		for _, ns := range cache.Namespaces {
-			objs, err := watcher.List(labels.Everything())
+			nsWatcher, err := cache.WatchForResource(podResource, ns)
+			if err != nil {
+				panic(err)
+			}
+			objs, err := nsWatcher.List(labels.Everything())
 			if err != nil {
 				panic(err)
 			}
@@ -106,9 +101,11 @@ func main() {
 	}
 }
 
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
-	}
-	return os.Getenv("USERPROFILE") // windows
+var podResource = resource.Resource{
+	APIResource: metav1.APIResource{
+		Name:         "pods",
+		SingularName: "pod",
+		Verbs:        []string{"list", "watch"},
+	},
+	GroupVersionKind: schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"},
 }
@@ -6,23 +6,21 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/net/websocket"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
-	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/wwitzel3/k8s-resource-client/pkg/aggregate"
 	r6eCache "github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	r6eClient "github.com/wwitzel3/k8s-resource-client/pkg/client"
-	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
 	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	"github.com/wwitzel3/k8s-resource-client/pkg/server"
+	"github.com/wwitzel3/k8s-resource-client/pkg/stream"
 )
 
 type Fields struct {
@@ -35,6 +33,14 @@ type Field struct {
 	Action string `json:"action"`
 }
 
+var logger = zap.NewNop()
+
+var (
+	podCounter        = aggregate.NewCounter(schema.GroupKind{Kind: "Pod"}, aggregate.ByNamespace())
+	deploymentCounter = aggregate.NewCounter(schema.GroupKind{Group: "apps", Kind: "Deployment"}, aggregate.ByNothing())
+	replicaSetCounter = aggregate.NewCounter(schema.GroupKind{Group: "apps", Kind: "ReplicaSet"}, aggregate.ByNothing())
+)
+
 var podRes = resource.Resource{
 	APIResource: metav1.APIResource{
 		Name:         "pods",
@@ -50,19 +56,19 @@ func Echo(ws *websocket.Conn) {
 
 	pods, err := r6eCache.WatchForResource(podRes, "default")
 	if err != nil {
-		logging.Logger.Warn("pod watcher", zap.Error(err))
+		logger.Warn("pod watcher", zap.Error(err))
 	} else {
 		pods.Drain(eventCh, stopCh)
 	}
 	deployments, err := r6eCache.WatchForResource(resource.Resource{GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}})
 	if err != nil {
-		logging.Logger.Warn("deployment watcher", zap.Error(err))
+		logger.Warn("deployment watcher", zap.Error(err))
 	} else {
 		deployments.Drain(eventCh, stopCh)
 	}
 	replicaSets, err := r6eCache.WatchForResource(resource.Resource{GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}})
 	if err != nil {
-		logging.Logger.Warn("replicaset watcher", zap.Error(err))
+		logger.Warn("replicaset watcher", zap.Error(err))
 	} else {
 		replicaSets.Drain(eventCh, stopCh)
 	}
@@ -82,22 +88,20 @@ func sendUpdate(ws *websocket.Conn, pods, deployments, replicasets r6eCache.Reso
 	ts := Field{Key: "timestamp", Value: time.Now().UTC().String(), Action: ""}
 	fields.Fields = append(fields.Fields, ts)
 
-	f := Field{Key: "watcher count", Value: fmt.Sprintf("%d", r6eCache.WatchCount(true)), Action: ""}
+	f := Field{Key: "watcher count", Value: fmt.Sprintf("%d", len(r6eCache.WatchStatuses(r6eCache.ActiveWatchStates...))), Action: ""}
 	fields.Fields = append(fields.Fields, f)
 
+	// the counters are updated from the watch events, reading them does not list the cache
 	if pods != nil {
-		podObjs, _ := pods.List(labels.Everything())
-		fields.Fields = append(fields.Fields, Field{Key: "pod_count", Value: fmt.Sprintf("%d", len(podObjs))})
+		fields.Fields = append(fields.Fields, Field{Key: "pod_count", Value: fmt.Sprintf("%d", podCounter.Count("default"))})
 	}
 
 	if deployments != nil {
-		depObjs, _ := deployments.List(labels.Everything())
-		fields.Fields = append(fields.Fields, Field{Key: "deployment_count", Value: fmt.Sprintf("%d", len(depObjs))})
+		fields.Fields = append(fields.Fields, Field{Key: "deployment_count", Value: fmt.Sprintf("%d", deploymentCounter.Total())})
 	}
 
 	if replicasets != nil {
-		rsOjs, _ := replicasets.List(labels.Everything())
-		fields.Fields = append(fields.Fields, Field{Key: "replicaset_count", Value: fmt.Sprintf("%d", len(rsOjs))})
+		fields.Fields = append(fields.Fields, Field{Key: "replicaset_count", Value: fmt.Sprintf("%d", replicaSetCounter.Total())})
 	}
 
 	s, _ := json.CaseSensitiveJSONIterator().MarshalToString(fields)
@@ -105,29 +109,22 @@ func sendUpdate(ws *websocket.Conn, pods, deployments, replicasets r6eCache.Reso
 }
 
 func main() {
-	var kubeconfig *string
-	if home := homeDir(); home != "" {
-		kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
-	} else {
-		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-	}
+	kubeconfig := flag.String("kubeconfig", "", "(optional) kubeconfig files, defaults to KUBECONFIG or ~/.kube/config")
+	kubeContext := flag.String("context", "", "(optional) kubeconfig context, defaults to the current-context")
 
 	autoDiscoverNamespaces := flag.Bool("discover-namespaces", false, "auto discover namespaces")
 
 	flag.Parse()
 
-	// use the current context in kubeconfig
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
-	if err != nil {
-		panic(err.Error())
+	var err error
+	if logger, err = zap.NewProduction(); err != nil {
+		panic(err)
 	}
-
-	config.QPS = 500
-	config.Burst = 1000
+	defer logger.Sync()
 
 	ctx := context.Background()
 
-	client, err := r6eClient.NewClient(ctx, r6eClient.WithRESTConfig(config))
+	client, err := r6eClient.NewClientFromKubeconfig(ctx, *kubeconfig, *kubeContext, r6eClient.WithLogger(logger))
 	if err != nil {
 		panic(err)
 	}
@@ -167,15 +164,22 @@ func main() {
 		}
 	}
 
+	for _, counter := range []*aggregate.Counter{podCounter, deploymentCounter, replicaSetCounter} {
+		r6eCache.AddEventHandler(counter)
+	}
+
+	// the cached objects are readable at the Kubernetes API paths, e.g. /api/v1/namespaces/default/pods
+	api := server.NewHandler()
+	http.Handle("/api/", api)
+	http.Handle("/apis/", api)
+	http.Handle(server.AccessPath, api)
+	// watch events are streamed with pkg/stream where cache.Access allows watching, e.g. /events?resource=v1/pods&namespace=default
+	hub := stream.NewHub()
+	defer hub.Close()
+	http.Handle("/events", hub.SSEHandler())
+	http.Handle("/watch", hub.WebSocketHandler())
 	http.Handle("/", websocket.Handler(Echo))
 	if err := http.ListenAndServe("127.0.0.1:1234", nil); err != nil {
 		log.Fatal("ListenAndServe:", err)
 	}
 }
-
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
-	}
-	return os.Getenv("USERPROFILE") // windows
-}
@@ -0,0 +1,211 @@
+package aggregate
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+)
+
+// member records the group of a counted object and the watches it was seen in. An object cached by
+// both a namespace watch and a NamespaceAll watch is counted once.
+type member struct {
+	group   string
+	watches map[cache.WatchKey]struct{}
+}
+
+// Counter counts the cached objects of a kind in groups, updating the counts from watch events so
+// reading a count does not list the cache. Register the Counter with cache.AddEventHandler:
+//
+//	pods := aggregate.NewCounter(schema.GroupKind{Kind: "Pod"}, aggregate.ByNamespace())
+//	remove := cache.AddEventHandler(pods)
+//	defer remove()
+//	pods.Count("default")
+type Counter struct {
+	mu      sync.RWMutex
+	members map[types.UID]*member
+	counts  map[string]int
+
+	gk       schema.GroupKind
+	groupBy  GroupBy
+	cluster  string
+	selector labels.Selector
+	query    *query.Query
+	onError  func(obj runtime.Object, err error)
+}
+
+var _ cache.EventHandler = (*Counter)(nil)
+
+// NewCounter creates a Counter for objects of the kind grouped with the GroupBy.
+func NewCounter(gk schema.GroupKind, groupBy GroupBy, options ...Option) *Counter {
+	c := &Counter{
+		members: map[types.UID]*member{},
+		counts:  map[string]int{},
+		gk:      gk,
+		groupBy: groupBy,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	if c.selector == nil {
+		c.selector = labels.Everything()
+	}
+	return c
+}
+
+func (c *Counter) OnAdd(key cache.WatchKey, obj runtime.Object) {
+	c.update(key, obj)
+}
+
+func (c *Counter) OnUpdate(key cache.WatchKey, _, newObj runtime.Object) {
+	c.update(key, newObj)
+}
+
+func (c *Counter) OnDelete(key cache.WatchKey, obj runtime.Object) {
+	if !c.handles(key, obj) {
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(accessor.GetUID(), key)
+}
+
+// update moves the object to its current group, removing it when it no longer matches the filters.
+func (c *Counter) update(key cache.WatchKey, obj runtime.Object) {
+	if !c.handles(key, obj) {
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	uid := accessor.GetUID()
+
+	matches := c.selector.Matches(labels.Set(accessor.GetLabels()))
+	if matches && c.query != nil {
+		matches, err = c.query.Matches(obj)
+		if err != nil {
+			c.handleError(obj, err)
+		}
+	}
+
+	var group string
+	if matches {
+		group, err = c.groupBy.Group(obj)
+		if err != nil {
+			c.handleError(obj, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !matches {
+		c.removeAll(uid)
+		return
+	}
+
+	m, ok := c.members[uid]
+	if !ok {
+		m = &member{group: group, watches: map[cache.WatchKey]struct{}{}}
+		c.members[uid] = m
+		c.counts[group]++
+	} else if m.group != group {
+		c.decrement(m.group)
+		m.group = group
+		c.counts[group]++
+	}
+	m.watches[key] = struct{}{}
+}
+
+func (c *Counter) handles(key cache.WatchKey, obj runtime.Object) bool {
+	return key.Cluster == c.cluster && obj.GetObjectKind().GroupVersionKind().GroupKind() == c.gk
+}
+
+func (c *Counter) handleError(obj runtime.Object, err error) {
+	if c.onError != nil {
+		c.onError(obj, err)
+	}
+}
+
+// remove removes the watch from the object, the object is no longer counted once no watch has it.
+func (c *Counter) remove(uid types.UID, key cache.WatchKey) {
+	m, ok := c.members[uid]
+	if !ok {
+		return
+	}
+	delete(m.watches, key)
+	if len(m.watches) == 0 {
+		c.removeAll(uid)
+	}
+}
+
+func (c *Counter) removeAll(uid types.UID) {
+	if m, ok := c.members[uid]; ok {
+		delete(c.members, uid)
+		c.decrement(m.group)
+	}
+}
+
+func (c *Counter) decrement(group string) {
+	c.counts[group]--
+	if c.counts[group] <= 0 {
+		delete(c.counts, group)
+	}
+}
+
+// Count returns the number of objects in the group.
+func (c *Counter) Count(group string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.counts[group]
+}
+
+// Total returns the number of objects counted.
+func (c *Counter) Total() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.members)
+}
+
+// Counts returns a copy of the number of objects in each group.
+func (c *Counter) Counts() map[string]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	counts := make(map[string]int, len(c.counts))
+	for group, count := range c.counts {
+		counts[group] = count
+	}
+	return counts
+}
+
+// Groups returns the groups with at least one object, sorted.
+func (c *Counter) Groups() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	groups := make([]string, 0, len(c.counts))
+	for group := range c.counts {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// GroupBy returns the GroupBy of the Counter.
+func (c *Counter) GroupBy() GroupBy {
+	return c.groupBy
+}
@@ -0,0 +1,137 @@
+package aggregate_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/aggregate"
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+)
+
+var podGK = schema.GroupKind{Kind: "Pod"}
+
+func newPod(namespace, name, phase string, labels map[string]string) *unstructured.Unstructured {
+	u := wtesting.NewPod(namespace, name)
+	u.SetLabels(labels)
+	if phase != "" {
+		_ = unstructured.SetNestedField(u.Object, phase, "status", "phase")
+	}
+	return u
+}
+
+func TestCounterByNamespace(t *testing.T) {
+	counter := aggregate.NewCounter(podGK, aggregate.ByNamespace())
+	all := cache.WatchKey{}
+	defaultNS := cache.WatchKey{Namespace: "default"}
+
+	counter.OnAdd(all, newPod("default", "a", "", nil))
+	counter.OnAdd(all, newPod("default", "b", "", nil))
+	counter.OnAdd(all, newPod("test", "c", "", nil))
+	// the same object from a second watch is only counted once
+	counter.OnAdd(defaultNS, newPod("default", "a", "", nil))
+	// other kinds and clusters are ignored
+	deployment := newPod("default", "d", "", nil)
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	counter.OnAdd(all, deployment)
+	counter.OnAdd(cache.WatchKey{Cluster: "other"}, newPod("default", "e", "", nil))
+
+	assert.Equal(t, 2, counter.Count("default"))
+	assert.Equal(t, 1, counter.Count("test"))
+	assert.Equal(t, 3, counter.Total())
+	assert.Equal(t, []string{"default", "test"}, counter.Groups())
+
+	// removed from one watch the object is still cached by the other
+	counter.OnDelete(defaultNS, newPod("default", "a", "", nil))
+	assert.Equal(t, 2, counter.Count("default"))
+	counter.OnDelete(all, newPod("default", "a", "", nil))
+	assert.Equal(t, 1, counter.Count("default"))
+
+	counter.OnDelete(all, newPod("test", "c", "", nil))
+	assert.Equal(t, map[string]int{"default": 1}, counter.Counts())
+}
+
+func TestCounterByJSONPath(t *testing.T) {
+	groupBy, err := aggregate.ByJSONPath("status.phase")
+	assert.Nil(t, err)
+	counter := aggregate.NewCounter(podGK, groupBy)
+	key := cache.WatchKey{}
+
+	pending := newPod("default", "a", "Pending", nil)
+	running := newPod("default", "a", "Running", nil)
+	counter.OnAdd(key, pending)
+	counter.OnAdd(key, newPod("default", "b", "Running", nil))
+	counter.OnAdd(key, newPod("default", "c", "", nil))
+	assert.Equal(t, map[string]int{"Pending": 1, "Running": 1, "": 1}, counter.Counts())
+
+	counter.OnUpdate(key, pending, running)
+	assert.Equal(t, map[string]int{"Running": 2, "": 1}, counter.Counts())
+	assert.Equal(t, 3, counter.Total())
+
+	_, err = aggregate.ByJSONPath("{.status[")
+	assert.NotNil(t, err)
+}
+
+func TestCounterFilters(t *testing.T) {
+	counter := aggregate.NewCounter(podGK, aggregate.ByLabel("tier"),
+		aggregate.WithSelector(labels.SelectorFromSet(labels.Set{"app": "shop"})),
+		aggregate.WithQuery(query.MustCompile("status.phase != Failed")),
+	)
+	key := cache.WatchKey{}
+
+	frontend := newPod("default", "a", "Running", map[string]string{"app": "shop", "tier": "frontend"})
+	counter.OnAdd(key, frontend)
+	counter.OnAdd(key, newPod("default", "b", "Running", map[string]string{"app": "shop", "tier": "backend"}))
+	counter.OnAdd(key, newPod("default", "c", "Running", map[string]string{"app": "blog", "tier": "backend"}))
+	counter.OnAdd(key, newPod("default", "d", "Running", map[string]string{"app": "shop"}))
+	assert.Equal(t, map[string]int{"frontend": 1, "backend": 1, "": 1}, counter.Counts())
+
+	// an update that no longer matches removes the object
+	counter.OnUpdate(key, frontend, newPod("default", "a", "Failed", map[string]string{"app": "shop", "tier": "frontend"}))
+	assert.Equal(t, 0, counter.Count("frontend"))
+	assert.Equal(t, 2, counter.Total())
+	assert.Equal(t, "label:tier", counter.GroupBy().String())
+
+	nothing := aggregate.NewCounter(podGK, aggregate.ByNothing())
+	nothing.OnAdd(key, frontend)
+	assert.Equal(t, 1, nothing.Count(""))
+}
+
+func TestCounterConcurrentEvents(t *testing.T) {
+	groupBy, err := aggregate.ByJSONPath("{range .spec.containers[*]}{.image}{end}")
+	assert.Nil(t, err)
+	counter := aggregate.NewCounter(podGK, groupBy,
+		aggregate.WithQuery(query.MustCompile("spec.containers[*].image = 'nginx:1.21'")),
+		aggregate.WithErrorHandler(func(_ runtime.Object, err error) {
+			assert.Nil(t, err)
+		}),
+	)
+
+	// the informer handlers of every watch share the GroupBy and the Query of the Counter
+	var group sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+		go func(namespace string) {
+			defer group.Done()
+			key := cache.WatchKey{Namespace: namespace}
+			for j := 0; j < 50; j++ {
+				pod := newPod(namespace, fmt.Sprint(j), "Running", nil)
+				_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+					map[string]interface{}{"image": "nginx:1.21"},
+				}, "spec", "containers")
+				counter.OnAdd(key, pod)
+			}
+		}(fmt.Sprint("ns-", i))
+	}
+	group.Wait()
+	assert.Equal(t, map[string]int{"nginx:1.21": 400}, counter.Counts())
+}
@@ -0,0 +1,72 @@
+// Package aggregate maintains counts of cached objects updated incrementally from watch events.
+package aggregate
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+)
+
+// GroupBy returns the group an object is counted in. Objects without a value for the GroupBy are
+// counted in the empty group.
+type GroupBy interface {
+	Group(obj runtime.Object) (string, error)
+	String() string
+}
+
+type groupByFunc struct {
+	name string
+	fn   func(obj runtime.Object) (string, error)
+}
+
+func (g groupByFunc) Group(obj runtime.Object) (string, error) { return g.fn(obj) }
+func (g groupByFunc) String() string                           { return g.name }
+
+// ByNothing counts every object in the empty group.
+func ByNothing() GroupBy {
+	return groupByFunc{name: "nothing", fn: func(runtime.Object) (string, error) { return "", nil }}
+}
+
+// ByNamespace groups objects by namespace.
+func ByNamespace() GroupBy {
+	return groupByFunc{name: "namespace", fn: func(obj runtime.Object) (string, error) {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return "", err
+		}
+		return accessor.GetNamespace(), nil
+	}}
+}
+
+// ByLabel groups objects by the value of the label.
+func ByLabel(key string) GroupBy {
+	return groupByFunc{name: "label:" + key, fn: func(obj runtime.Object) (string, error) {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return "", err
+		}
+		return accessor.GetLabels()[key], nil
+	}}
+}
+
+// ByJSONPath groups objects by the first value found at the JSONPath, such as status.phase.
+func ByJSONPath(path string) (GroupBy, error) {
+	jp, err := query.ParseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return groupByFunc{name: "jsonpath:" + path, fn: func(obj runtime.Object) (string, error) {
+		content, err := query.ObjectContent(obj)
+		if err != nil {
+			return "", err
+		}
+		value, ok, err := jp.Value(content)
+		if err != nil || !ok || value == nil {
+			return "", err
+		}
+		return fmt.Sprint(value), nil
+	}}, nil
+}
@@ -0,0 +1,39 @@
+package aggregate
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+)
+
+type Option func(*Counter)
+
+// WithCluster limits the Counter to the events of the watches of the named cluster.
+func WithCluster(cluster string) Option {
+	return func(c *Counter) {
+		c.cluster = cluster
+	}
+}
+
+// WithSelector only counts objects matching the label selector.
+func WithSelector(selector labels.Selector) Option {
+	return func(c *Counter) {
+		c.selector = selector
+	}
+}
+
+// WithQuery only counts objects matching the query.
+func WithQuery(q *query.Query) Option {
+	return func(c *Counter) {
+		c.query = q
+	}
+}
+
+// WithErrorHandler sets a function called when an object cannot be matched or grouped. Objects that
+// cannot be matched are not counted and objects that cannot be grouped are counted in the empty group.
+func WithErrorHandler(fn func(obj runtime.Object, err error)) Option {
+	return func(c *Counter) {
+		c.onError = fn
+	}
+}
@@ -1,8 +1,13 @@
 package cache
 
 import (
+	"fmt"
 	"sync"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
 	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 )
 
@@ -14,6 +19,27 @@ func init() {
 	Resources = NewResourceCache()
 	Namespaces = make([]string, 0)
 	Access = nil
+	metrics.WatchStatsFn = watchStats
+}
+
+// DiscoveredResources returns the namespace and cluster resources of Resources.
+func DiscoveredResources() []resource.Resource {
+	resources := []resource.Resource{}
+	resources = append(resources, Resources.Get("namespace")...)
+	return append(resources, Resources.Get("cluster")...)
+}
+
+// ToUnstructured returns the object as an *unstructured.Unstructured, the cached objects of the dynamic
+// informers are returned as is and other objects are converted.
+func ToUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("convert to unstructured, %w", err)
+	}
+	return &unstructured.Unstructured{Object: content}, nil
 }
 
 func NewResourceCache() *ResourceCache {
@@ -6,6 +6,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -23,6 +26,30 @@ func TestNewResourceCache(t *testing.T) {
 	assert.Len(t, empty, 0)
 }
 
+func TestDiscoveredResources(t *testing.T) {
+	cache.Resources = cache.NewResourceCache()
+	defer func() { cache.Resources = cache.NewResourceCache() }()
+
+	node := resource.Resource{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Node"}}
+	cache.Resources.Add("cluster", node)
+	cache.Resources.Add("namespace", testResource)
+	assert.Equal(t, []resource.Resource{testResource, node}, cache.DiscoveredResources())
+}
+
+func TestToUnstructured(t *testing.T) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetName("nginx")
+	converted, err := cache.ToUnstructured(u)
+	assert.Nil(t, err)
+	assert.Same(t, u, converted)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx"}}
+	converted, err = cache.ToUnstructured(pod)
+	assert.Nil(t, err)
+	assert.Equal(t, "default", converted.GetNamespace())
+	assert.Equal(t, "nginx", converted.GetName())
+}
+
 var testResource = resource.Resource{
 	GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "deployment"},
 }
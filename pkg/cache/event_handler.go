@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	kcache "k8s.io/client-go/tools/cache"
+)
+
+// EventHandler is notified of the events of every WatchDetail in ResourceWatches, including watches
+// created after the EventHandler is registered. Handlers are called synchronously from the Informer
+// loops and must not block.
+type EventHandler interface {
+	OnAdd(key WatchKey, obj runtime.Object)
+	OnUpdate(key WatchKey, oldObj, newObj runtime.Object)
+	OnDelete(key WatchKey, obj runtime.Object)
+}
+
+// EventHandlerFuncs is an EventHandler calling the functions that are set.
+type EventHandlerFuncs struct {
+	AddFunc    func(key WatchKey, obj runtime.Object)
+	UpdateFunc func(key WatchKey, oldObj, newObj runtime.Object)
+	DeleteFunc func(key WatchKey, obj runtime.Object)
+}
+
+func (f EventHandlerFuncs) OnAdd(key WatchKey, obj runtime.Object) {
+	if f.AddFunc != nil {
+		f.AddFunc(key, obj)
+	}
+}
+
+func (f EventHandlerFuncs) OnUpdate(key WatchKey, oldObj, newObj runtime.Object) {
+	if f.UpdateFunc != nil {
+		f.UpdateFunc(key, oldObj, newObj)
+	}
+}
+
+func (f EventHandlerFuncs) OnDelete(key WatchKey, obj runtime.Object) {
+	if f.DeleteFunc != nil {
+		f.DeleteFunc(key, obj)
+	}
+}
+
+type eventHandlerRegistry struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]*eventHandlerRegistration
+}
+
+var eventHandlers = &eventHandlerRegistry{handlers: map[int]*eventHandlerRegistration{}}
+
+// eventHandlerRegistration buffers the events of a handler while the existing objects are replayed to it.
+type eventHandlerRegistration struct {
+	handler EventHandler
+
+	mu        sync.Mutex
+	replaying bool
+	removed   bool
+	buffered  []func(EventHandler)
+}
+
+// AddEventHandler registers the EventHandler and calls OnAdd for every object already in the running
+// watches. Events are buffered until the existing objects have been replayed so the handler sees a
+// consistent sequence, an object added during the replay may be passed to OnAdd twice. The replay runs
+// on the calling goroutine without holding any lock, the handler may add or remove EventHandlers. The
+// returned function removes the EventHandler.
+func AddEventHandler(handler EventHandler) func() {
+	registration := &eventHandlerRegistration{handler: handler, replaying: true}
+
+	eventHandlers.mu.Lock()
+	id := eventHandlers.nextID
+	eventHandlers.nextID++
+	eventHandlers.handlers[id] = registration
+	eventHandlers.mu.Unlock()
+
+	for _, detail := range watchDetails() {
+		if detail.IsRunning() == 0 {
+			continue
+		}
+		objs, err := detail.List(labels.Everything())
+		if err != nil {
+			detail.logger().Warn("unable to replay objects to event handler",
+				zap.String("key", detail.WatchKey().String()),
+				zap.Error(err),
+			)
+			continue
+		}
+		for _, obj := range objs {
+			if registration.isRemoved() {
+				break
+			}
+			handler.OnAdd(detail.WatchKey(), obj)
+		}
+	}
+	registration.flush()
+
+	return func() {
+		eventHandlers.mu.Lock()
+		delete(eventHandlers.handlers, id)
+		eventHandlers.mu.Unlock()
+
+		registration.mu.Lock()
+		defer registration.mu.Unlock()
+		registration.removed = true
+		registration.buffered = nil
+	}
+}
+
+func (r *eventHandlerRegistration) isRemoved() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.removed
+}
+
+// flush delivers the buffered events until none are left and ends the replay.
+func (r *eventHandlerRegistration) flush() {
+	for {
+		r.mu.Lock()
+		buffered := r.buffered
+		r.buffered = nil
+		if len(buffered) == 0 || r.removed {
+			r.replaying = false
+			r.mu.Unlock()
+			return
+		}
+		r.mu.Unlock()
+
+		for _, fn := range buffered {
+			fn(r.handler)
+		}
+	}
+}
+
+// deliver calls fn with the handler, or buffers it while the existing objects are replayed.
+func (r *eventHandlerRegistration) deliver(fn func(EventHandler)) {
+	r.mu.Lock()
+	if r.removed {
+		r.mu.Unlock()
+		return
+	}
+	if r.replaying {
+		r.buffered = append(r.buffered, fn)
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+	fn(r.handler)
+}
+
+// notify delivers the event to the registered handlers. No lock is held while the handlers are called.
+func (r *eventHandlerRegistry) notify(fn func(EventHandler)) {
+	r.mu.RLock()
+	registrations := make([]*eventHandlerRegistration, 0, len(r.handlers))
+	for _, registration := range r.handlers {
+		registrations = append(registrations, registration)
+	}
+	r.mu.RUnlock()
+
+	for _, registration := range registrations {
+		registration.deliver(fn)
+	}
+}
+
+func (r *eventHandlerRegistry) add(key WatchKey, obj interface{}) {
+	if o, ok := obj.(runtime.Object); ok {
+		r.notify(func(h EventHandler) { h.OnAdd(key, o) })
+	}
+}
+
+func (r *eventHandlerRegistry) update(key WatchKey, oldObj, newObj interface{}) {
+	o, _ := oldObj.(runtime.Object)
+	if n, ok := newObj.(runtime.Object); ok {
+		r.notify(func(h EventHandler) { h.OnUpdate(key, o, n) })
+	}
+}
+
+func (r *eventHandlerRegistry) delete(key WatchKey, obj interface{}) {
+	if tombstone, ok := obj.(kcache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if o, ok := obj.(runtime.Object); ok {
+		r.notify(func(h EventHandler) { h.OnDelete(key, o) })
+	}
+}
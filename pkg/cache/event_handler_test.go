@@ -0,0 +1,112 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kcache "k8s.io/client-go/tools/cache"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+)
+
+func TestAddEventHandler(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	dynFake := ctesting.FakeDynamicClient{}
+
+	existing := newUnstructured("v1", "Pod", "default", "existing", "1", "1")
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Objects = []runtime.Object{existing}
+
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(dsifFake),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+
+	wd, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	defer wd.Stop()
+
+	events := []string{}
+	remove := cache.AddEventHandler(cache.EventHandlerFuncs{
+		AddFunc: func(key cache.WatchKey, obj runtime.Object) {
+			assert.Equal(t, wd.Keys()[0], key)
+			events = append(events, "add:"+obj.(*unstructured.Unstructured).GetName())
+		},
+		UpdateFunc: func(key cache.WatchKey, oldObj, newObj runtime.Object) {
+			events = append(events, "update:"+oldObj.(*unstructured.Unstructured).GetResourceVersion()+
+				"->"+newObj.(*unstructured.Unstructured).GetResourceVersion())
+		},
+		DeleteFunc: func(key cache.WatchKey, obj runtime.Object) {
+			events = append(events, "delete:"+obj.(*unstructured.Unstructured).GetName())
+		},
+	})
+
+	handler := dsifFake.GenericInformer.SharedIndexInformer.Handlers[0]
+	pod := newUnstructured("v1", "Pod", "default", "nginx", "2", "1")
+	handler.OnAdd(pod)
+	handler.OnUpdate(pod, newUnstructured("v1", "Pod", "default", "nginx", "2", "2"))
+	handler.OnDelete(kcache.DeletedFinalStateUnknown{Key: "default/nginx", Obj: pod})
+	// events for objects that are not runtime.Objects are ignored
+	handler.OnAdd("")
+
+	remove()
+	handler.OnAdd(pod)
+
+	assert.Equal(t, []string{"add:existing", "add:nginx", "update:1->2", "delete:nginx"}, events)
+}
+
+func TestAddEventHandlerReplay(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	existing := newUnstructured("v1", "Pod", "default", "existing", "1", "1")
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Objects = []runtime.Object{existing}
+
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(ctesting.FakeDynamicClient{}),
+		cache.WithDynamicSharedInformerFactory(dsifFake),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+	wd, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	informerHandler := dsifFake.GenericInformer.SharedIndexInformer.Handlers[0]
+
+	// events during the replay are delivered after it, the handler may register other handlers
+	events := []string{}
+	nested := 0
+	remove := cache.AddEventHandler(cache.EventHandlerFuncs{
+		AddFunc: func(key cache.WatchKey, obj runtime.Object) {
+			name := obj.(*unstructured.Unstructured).GetName()
+			events = append(events, "add:"+name)
+			if name == "existing" {
+				informerHandler.OnAdd(newUnstructured("v1", "Pod", "default", "nginx", "2", "1"))
+				removeNested := cache.AddEventHandler(cache.EventHandlerFuncs{
+					AddFunc: func(cache.WatchKey, runtime.Object) { nested++ },
+				})
+				removeNested()
+			}
+		},
+		DeleteFunc: func(key cache.WatchKey, obj runtime.Object) {
+			events = append(events, "delete:"+obj.(*unstructured.Unstructured).GetName())
+		},
+	})
+	defer remove()
+	assert.Equal(t, []string{"add:existing", "add:nginx"}, events)
+	assert.Equal(t, 1, nested)
+
+	// stopping the watch deletes its objects from the handlers
+	wd.Stop()
+	wd.Stop()
+	assert.Equal(t, []string{"add:existing", "add:nginx", "delete:existing"}, events)
+}
@@ -1,6 +1,9 @@
 package cache
 
 import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -27,6 +30,14 @@ func (w *FilteredWatchDetail) Namespace() string {
 	return w.namespace
 }
 
+func (w *FilteredWatchDetail) Keys() []WatchKey {
+	return w.Detail.Keys()
+}
+
+func (w *FilteredWatchDetail) Namespaces() []string {
+	return []string{w.namespace}
+}
+
 func (w *FilteredWatchDetail) Drain(ch chan<- interface{}, stopCh chan struct{}) {
 	w.Detail.Drain(ch, stopCh)
 }
@@ -35,6 +46,13 @@ func (w *FilteredWatchDetail) Get(name string) (runtime.Object, error) {
 	return w.Detail.informer.Lister().ByNamespace(w.namespace).Get(name)
 }
 
+func (w *FilteredWatchDetail) GetNamespaced(namespace, name string) (runtime.Object, error) {
+	if namespace != w.namespace {
+		return nil, apierrors.NewNotFound(w.Detail.Resource.GroupVersionResource().GroupResource(), name)
+	}
+	return w.Get(name)
+}
+
 func (w *FilteredWatchDetail) Stop() {
 	w.Detail.Stop()
 }
@@ -42,3 +60,15 @@ func (w *FilteredWatchDetail) Stop() {
 func (w *FilteredWatchDetail) IsRunning() int {
 	return w.Detail.IsRunning()
 }
+
+func (w *FilteredWatchDetail) HasSynced() bool {
+	return w.Detail.HasSynced()
+}
+
+func (w *FilteredWatchDetail) WaitForSync(ctx context.Context) error {
+	return w.Detail.WaitForSync(ctx)
+}
+
+func (w *FilteredWatchDetail) Statuses() []WatchStatus {
+	return w.Detail.Statuses()
+}
@@ -10,10 +10,10 @@ import (
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
 	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
-	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	rtesting "k8s.io/apimachinery/pkg/runtime/testing"
@@ -32,17 +32,16 @@ func TestFilteredWatchDetail(t *testing.T) {
 	filteredInfo := false
 	logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.Hooks(func(e zapcore.Entry) error {
 		println(e.Message)
-		if strings.Contains(e.Message, "found NamespaceAll creating filtered watch detail") && e.Level == zap.InfoLevel {
+		if strings.Contains(e.Message, "found NamespaceAll creating filtered watch detail") && e.Level == zap.DebugLevel {
 			filteredInfo = true
 		}
 		return nil
 	})))
-	logging.Logger = logger
 
 	w, err := cache.NewWatcher(context.TODO(),
 		cache.WithDynamicClient(&dynFake),
 		cache.WithDynamicSharedInformerFactory(dsifFake),
-		cache.WithLogger(zap.NewNop()),
+		cache.WithLogger(logger),
 	)
 	assert.Nil(t, err)
 
@@ -73,9 +72,22 @@ func TestFilteredWatchDetail(t *testing.T) {
 	assert.NotNil(t, obj)
 	assert.True(t, filteredInfo)
 
-	assert.Equal(t, lister.IsRunning(), 1)
+	obj, err = lister.GetNamespaced("testing-ns", "test-name")
+	assert.Nil(t, err)
+	assert.NotNil(t, obj)
+
+	_, err = lister.GetNamespaced("other-ns", "test-name")
+	assert.EqualError(t, err, "unable to find object test-name, namespace other-ns is not watched")
+
+	filtered := &cache.FilteredWatchDetail{Detail: podWd.(*cache.WatchDetail)}
+	_, err = filtered.GetNamespaced("other-ns", "test-name")
+	assert.True(t, apierrors.IsNotFound(err))
 
-	logging.Logger, _ = zap.NewProduction()
+	obj, err = podWd.GetNamespaced("other-ns", "test-name")
+	assert.Nil(t, err)
+	assert.NotNil(t, obj)
+
+	assert.Equal(t, lister.IsRunning(), 1)
 }
 
 func TestFilteredWatchDetailDrain(t *testing.T) {
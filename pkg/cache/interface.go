@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"context"
+
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -8,16 +10,28 @@ import (
 type ResourceLister interface {
 	// List will return all objects in this namespace
 	List(selector labels.Selector) (ret []runtime.Object, err error)
-	// Get will attempt to retrieve by namespace and name
+	// Get will attempt to retrieve by name, returning an *errors.AmbiguousObject if the name exists in multiple namespaces
 	Get(name string) (runtime.Object, error)
+	// GetNamespaced will attempt to retrieve by namespace and name
+	GetNamespaced(namespace, name string) (runtime.Object, error)
 	// Drain will get events from the queue and send them to the provided channel
 	Drain(ch chan<- interface{}, stopCh chan struct{})
 	// Stop
 	Stop()
 	// Namespace
 	Namespace() string
+	// Namespaces returns the unique namespaces covered by the ResourceLister
+	Namespaces() []string
 	// Key
 	Key() string
+	// Keys returns the unique WatchKeys of the watches backing the ResourceLister
+	Keys() []WatchKey
 	// IsRunning returns the count of underlying Watchers that are running for the ResourceLister
 	IsRunning() int
+	// Statuses returns the WatchStatus of each underlying WatchDetail
+	Statuses() []WatchStatus
+	// HasSynced returns true once the underlying Informers have completed their initial List
+	HasSynced() bool
+	// WaitForSync blocks until HasSynced is true, the watch is stopped, or the context is done
+	WaitForSync(ctx context.Context) error
 }
@@ -1,9 +1,13 @@
 package cache
 
 import (
+	"github.com/go-logr/logr"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
 )
 
 type WatcherOption func(*Watcher)
@@ -26,8 +30,58 @@ func WithLogger(logger *zap.Logger) WatcherOption {
 	}
 }
 
+// WithLogr logs to the logr.Logger, see logging.FromLogr.
+func WithLogr(logger logr.Logger) WatcherOption {
+	return WithLogger(logging.FromLogr(logger))
+}
+
+// WithCluster sets the cluster name used in the WatchKey of every watch created by the Watcher.
+func WithCluster(cluster string) WatcherOption {
+	return func(w *Watcher) {
+		w.cluster = cluster
+	}
+}
+
+// WithLabelSelector limits the watches created by the Watcher to objects matching the label selector.
+func WithLabelSelector(selector string) WatcherOption {
+	return func(w *Watcher) {
+		w.selector = selector
+	}
+}
+
 func WithNamespace(namespace string) WatcherOption {
 	return func(w *Watcher) {
 		w.namespace = namespace
 	}
 }
+
+type typedListerOptions struct {
+	scheme       *runtime.Scheme
+	cacheObjects bool
+	cacheSize    int
+}
+
+type TypedListerOption func(*typedListerOptions)
+
+func WithScheme(s *runtime.Scheme) TypedListerOption {
+	return func(o *typedListerOptions) {
+		o.scheme = s
+	}
+}
+
+// WithConvertedCache keeps converted objects keyed by UID and reuses them until the
+// resourceVersion changes. Like objects from an Informer cache, they must not be mutated.
+// At most DefaultConvertedCacheSize objects are kept, see WithConvertedCacheSize.
+func WithConvertedCache(enabled bool) TypedListerOption {
+	return func(o *typedListerOptions) {
+		o.cacheObjects = enabled
+	}
+}
+
+// WithConvertedCacheSize bounds the number of converted objects kept by WithConvertedCache. Once full,
+// the least recently used object is dropped, a full List also drops the objects that were deleted.
+func WithConvertedCacheSize(n int) TypedListerOption {
+	return func(o *typedListerOptions) {
+		o.cacheSize = n
+	}
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+var _ dynamic.Interface = (*rotatingClient)(nil)
+
+// rotatingClient is the dynamic.Interface of the informers of a Watcher. Informers request the resource
+// from the client on every list and watch, so replacing the client moves them to it on their next request.
+type rotatingClient struct {
+	mu         sync.Mutex
+	client     dynamic.Interface
+	generation int
+	watches    map[*rotatingWatch]struct{}
+}
+
+func newRotatingClient(client dynamic.Interface) *rotatingClient {
+	return &rotatingClient{client: client, watches: map[*rotatingWatch]struct{}{}}
+}
+
+func (r *rotatingClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &rotatingResource{NamespaceableResourceInterface: r.client.Resource(gvr), rotating: r, generation: r.generation}
+}
+
+// rotate replaces the client and stops the watches opened with the previous one. The reflectors of the
+// informers open a new watch from their last resource version, keeping the objects already in their store.
+func (r *rotatingClient) rotate(client dynamic.Interface) {
+	r.mu.Lock()
+	r.client = client
+	r.generation++
+	watches := r.watches
+	r.watches = map[*rotatingWatch]struct{}{}
+	r.mu.Unlock()
+
+	for w := range watches {
+		w.Interface.Stop()
+	}
+}
+
+// track records the watch so it can be stopped by rotate. A watch opened with a client that was rotated
+// out while the request was in flight is stopped right away.
+func (r *rotatingClient) track(generation int, w watch.Interface, err error) (watch.Interface, error) {
+	if err != nil {
+		return w, err
+	}
+
+	r.mu.Lock()
+	if generation != r.generation {
+		r.mu.Unlock()
+		w.Stop()
+		return w, nil
+	}
+	tracked := &rotatingWatch{Interface: w, rotating: r}
+	r.watches[tracked] = struct{}{}
+	r.mu.Unlock()
+	return tracked, nil
+}
+
+func (r *rotatingClient) untrack(w *rotatingWatch) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.watches, w)
+}
+
+type rotatingResource struct {
+	dynamic.NamespaceableResourceInterface
+	rotating   *rotatingClient
+	generation int
+}
+
+func (r *rotatingResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &rotatingNamespacedResource{
+		ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace),
+		rotating:          r.rotating,
+		generation:        r.generation,
+	}
+}
+
+func (r *rotatingResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	w, err := r.NamespaceableResourceInterface.Watch(ctx, opts)
+	return r.rotating.track(r.generation, w, err)
+}
+
+type rotatingNamespacedResource struct {
+	dynamic.ResourceInterface
+	rotating   *rotatingClient
+	generation int
+}
+
+func (r *rotatingNamespacedResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	w, err := r.ResourceInterface.Watch(ctx, opts)
+	return r.rotating.track(r.generation, w, err)
+}
+
+type rotatingWatch struct {
+	watch.Interface
+	rotating *rotatingClient
+}
+
+func (w *rotatingWatch) Stop() {
+	w.rotating.untrack(w)
+	w.Interface.Stop()
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	kcache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// LoadWatch adds a read-only WatchDetail serving the objects to the ResourceWatches registry under the key,
+// replacing and stopping a watch loaded earlier with the same key. A watch run by an Informer is never
+// replaced, an *errors.WatchExists is returned instead, so load under a cluster name that no Watcher uses.
+// No Informer is run, the watch is synced immediately and never receives events. The registered EventHandlers are notified of each object.
+// It is used to serve objects captured from a cluster, such as a snapshot, through the ResourceLister interface.
+func LoadWatch(key WatchKey, res resource.Resource, objects []runtime.Object) (*WatchDetail, error) {
+	existing, loaded := loadedWatch(key)
+	if loaded && existing == nil {
+		return nil, &errors.WatchExists{Key: key.String()}
+	}
+
+	indexer := kcache.NewIndexer(kcache.MetaNamespaceKeyFunc, kcache.Indexers{kcache.NamespaceIndex: kcache.MetaNamespaceIndexFunc})
+	for _, obj := range objects {
+		if err := indexer.Add(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	informer := &staticInformer{indexer: indexer, resource: res.GroupVersionResource().GroupResource()}
+	detail := &WatchDetail{
+		namespace: key.Namespace,
+		Resource:  res,
+		Informer:  informer,
+		informer:  informer,
+		Queue:     workqueue.NewNamed(res.Key()),
+		StopCh:    make(chan struct{}),
+		Logger:    zap.NewNop(),
+		key:       key,
+	}
+	detail.status.setState(WatchSyncing)
+
+	if existing != nil {
+		existing.Stop()
+	}
+	appendResourceWatches(detail)
+
+	for _, obj := range objects {
+		eventHandlers.add(key, obj)
+	}
+	return detail, nil
+}
+
+// loadedWatch returns the watch with the key when it was added by LoadWatch. It returns a nil WatchDetail
+// and true when the key is used by another watch.
+func loadedWatch(key WatchKey) (*WatchDetail, bool) {
+	v, ok := ResourceWatches.Load(key)
+	if !ok {
+		return nil, false
+	}
+	if detail, ok := v.(*WatchDetail); ok {
+		if _, static := detail.informer.(*staticInformer); static {
+			return detail, true
+		}
+	}
+	return nil, true
+}
+
+// staticInformer is an always synced Informer and Lister for a fixed set of objects.
+type staticInformer struct {
+	indexer  kcache.Indexer
+	resource schema.GroupResource
+}
+
+var (
+	_ informers.GenericInformer  = (*staticInformer)(nil)
+	_ kcache.SharedIndexInformer = (*staticInformer)(nil)
+)
+
+func (s *staticInformer) Informer() kcache.SharedIndexInformer { return s }
+func (s *staticInformer) Lister() kcache.GenericLister {
+	return kcache.NewGenericLister(s.indexer, s.resource)
+}
+
+func (s *staticInformer) AddEventHandler(kcache.ResourceEventHandler) {}
+func (s *staticInformer) AddEventHandlerWithResyncPeriod(kcache.ResourceEventHandler, time.Duration) {
+}
+func (s *staticInformer) GetStore() kcache.Store                              { return s.indexer }
+func (s *staticInformer) GetController() kcache.Controller                    { return nil }
+func (s *staticInformer) Run(stopCh <-chan struct{})                          { <-stopCh }
+func (s *staticInformer) HasSynced() bool                                     { return true }
+func (s *staticInformer) LastSyncResourceVersion() string                     { return "" }
+func (s *staticInformer) SetWatchErrorHandler(kcache.WatchErrorHandler) error { return nil }
+func (s *staticInformer) AddIndexers(indexers kcache.Indexers) error {
+	return s.indexer.AddIndexers(indexers)
+}
+func (s *staticInformer) GetIndexer() kcache.Indexer { return s.indexer }
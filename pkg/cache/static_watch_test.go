@@ -0,0 +1,87 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+func TestLoadWatch(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	added := []string{}
+	remove := cache.AddEventHandler(cache.EventHandlerFuncs{
+		AddFunc: func(key cache.WatchKey, obj runtime.Object) {
+			added = append(added, key.Namespace+"/"+obj.(*unstructured.Unstructured).GetName())
+		},
+	})
+	defer remove()
+
+	key := cache.WatchKey{GroupVersionResource: podResource.GroupVersionResource()}
+	replaced, err := cache.LoadWatch(key, podResource, nil)
+	assert.Nil(t, err)
+
+	detail, err := cache.LoadWatch(key, podResource, []runtime.Object{
+		newUnstructured("v1", "Pod", "default", "nginx", "1", "1"),
+		newUnstructured("v1", "Pod", "test", "redis", "2", "1"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, replaced.IsRunning())
+	assert.Equal(t, []string{"/nginx", "/redis"}, added)
+	assert.True(t, detail.HasSynced())
+	assert.Nil(t, detail.WaitForSync(context.TODO()))
+	assert.Equal(t, cache.WatchSynced, detail.Status().State)
+	assert.Equal(t, 2, detail.Status().ObjectCount)
+
+	lister, err := cache.WatchForResource(podResource, "test")
+	assert.Nil(t, err)
+	objs, err := lister.List(labels.Everything())
+	assert.Nil(t, err)
+	assert.Len(t, objs, 1)
+
+	obj, err := lister.GetNamespaced("test", "redis")
+	assert.Nil(t, err)
+	assert.NotNil(t, obj)
+	_, err = lister.GetNamespaced("test", "nginx")
+	assert.NotNil(t, err)
+}
+
+func TestLoadWatchKeepsInformerWatches(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(ctesting.FakeDynamicClient{}),
+		cache.WithDynamicSharedInformerFactory(wtesting.NewFakeDynamicSharedInformerFactory()),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+	live, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	defer live.Stop()
+
+	key := cache.WatchKey{GroupVersionResource: podResource.GroupVersionResource(), Namespace: "default"}
+	_, err = cache.LoadWatch(key, podResource, nil)
+	exists := &errors.WatchExists{}
+	assert.ErrorAs(t, err, &exists)
+	assert.Equal(t, key.String(), exists.Key)
+	assert.Equal(t, 1, live.IsRunning())
+
+	// a watch loaded under another cluster leaves the running watch in place
+	key.Cluster = "snapshot"
+	_, err = cache.LoadWatch(key, podResource, nil)
+	assert.Nil(t, err)
+	lister, err := cache.WatchForResource(podResource, "default")
+	assert.Nil(t, err)
+	assert.Equal(t, live.Key(), lister.Key())
+}
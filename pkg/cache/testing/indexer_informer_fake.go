@@ -0,0 +1,142 @@
+package testing
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ dynamicinformer.DynamicSharedInformerFactory = (*FakeIndexerInformerFactory)(nil)
+
+// FakeIndexerInformerFactory creates a FakeIndexerInformer for each resource. Unlike FakeDynamicSharedInformerFactory
+// the informers keep the objects they are given, so Listers reflect the events delivered to them.
+type FakeIndexerInformerFactory struct {
+	mu        sync.Mutex
+	informers map[schema.GroupVersionResource]*FakeIndexerInformer
+}
+
+func NewFakeIndexerInformerFactory() *FakeIndexerInformerFactory {
+	return &FakeIndexerInformerFactory{
+		informers: map[schema.GroupVersionResource]*FakeIndexerInformer{},
+	}
+}
+
+func (f *FakeIndexerInformerFactory) Start(stopCh <-chan struct{}) {}
+func (f *FakeIndexerInformerFactory) ForResource(gvr schema.GroupVersionResource) informers.GenericInformer {
+	return f.Informer(gvr)
+}
+func (f *FakeIndexerInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool {
+	return map[schema.GroupVersionResource]bool{}
+}
+
+// Informer returns the FakeIndexerInformer for the resource, creating it if needed.
+func (f *FakeIndexerInformerFactory) Informer(gvr schema.GroupVersionResource) *FakeIndexerInformer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	informer, ok := f.informers[gvr]
+	if !ok {
+		informer = NewFakeIndexerInformer(gvr.GroupResource())
+		f.informers[gvr] = informer
+	}
+	return informer
+}
+
+var (
+	_ informers.GenericInformer = (*FakeIndexerInformer)(nil)
+	_ cache.SharedIndexInformer = (*FakeIndexerInformer)(nil)
+)
+
+// FakeIndexerInformer is an always synced informer backed by an Indexer. Add, Update and Delete change the
+// Indexer and call the registered handlers the way a running informer does for watch events.
+type FakeIndexerInformer struct {
+	mu       sync.RWMutex
+	indexer  cache.Indexer
+	resource schema.GroupResource
+	handlers []cache.ResourceEventHandler
+}
+
+func NewFakeIndexerInformer(resource schema.GroupResource) *FakeIndexerInformer {
+	return &FakeIndexerInformer{
+		indexer:  cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		resource: resource,
+	}
+}
+
+// Add adds the object to the Indexer and calls OnAdd for each handler.
+func (s *FakeIndexerInformer) Add(obj interface{}) error {
+	if err := s.indexer.Add(obj); err != nil {
+		return err
+	}
+	for _, handler := range s.Handlers() {
+		handler.OnAdd(obj)
+	}
+	return nil
+}
+
+// Update replaces the object in the Indexer and calls OnUpdate for each handler, or OnAdd
+// when the object was not in the Indexer.
+func (s *FakeIndexerInformer) Update(obj interface{}) error {
+	old, exists, err := s.indexer.Get(obj)
+	if err != nil {
+		return err
+	}
+	if err := s.indexer.Update(obj); err != nil {
+		return err
+	}
+	for _, handler := range s.Handlers() {
+		if exists {
+			handler.OnUpdate(old, obj)
+		} else {
+			handler.OnAdd(obj)
+		}
+	}
+	return nil
+}
+
+// Delete removes the object from the Indexer and calls OnDelete for each handler.
+func (s *FakeIndexerInformer) Delete(obj interface{}) error {
+	if err := s.indexer.Delete(obj); err != nil {
+		return err
+	}
+	for _, handler := range s.Handlers() {
+		handler.OnDelete(obj)
+	}
+	return nil
+}
+
+// Handlers returns the registered handlers.
+func (s *FakeIndexerInformer) Handlers() []cache.ResourceEventHandler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]cache.ResourceEventHandler{}, s.handlers...)
+}
+
+func (s *FakeIndexerInformer) Informer() cache.SharedIndexInformer { return s }
+func (s *FakeIndexerInformer) Lister() cache.GenericLister {
+	return cache.NewGenericLister(s.indexer, s.resource)
+}
+
+func (s *FakeIndexerInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+func (s *FakeIndexerInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) {
+	s.AddEventHandler(handler)
+}
+func (s *FakeIndexerInformer) GetStore() cache.Store                              { return s.indexer }
+func (s *FakeIndexerInformer) GetController() cache.Controller                    { return nil }
+func (s *FakeIndexerInformer) Run(stopCh <-chan struct{})                         {}
+func (s *FakeIndexerInformer) HasSynced() bool                                    { return true }
+func (s *FakeIndexerInformer) LastSyncResourceVersion() string                    { return "" }
+func (s *FakeIndexerInformer) SetWatchErrorHandler(cache.WatchErrorHandler) error { return nil }
+func (s *FakeIndexerInformer) AddIndexers(indexers cache.Indexers) error {
+	return s.indexer.AddIndexers(indexers)
+}
+func (s *FakeIndexerInformer) GetIndexer() cache.Indexer { return s.indexer }
@@ -50,7 +50,10 @@ func (s FakeGenericInformer) Informer() cache.SharedIndexInformer {
 func (s FakeGenericInformer) Lister() cache.GenericLister { return s.GenericLister }
 
 type FakeSharedIndexInformer struct {
-	Handlers []cache.ResourceEventHandler
+	Handlers          []cache.ResourceEventHandler
+	HasSyncedFn       func() bool
+	WatchErrorHandler cache.WatchErrorHandler
+	ResourceVersion   string
 }
 
 func NewFakeSharedIndexInformer() *FakeSharedIndexInformer {
@@ -67,10 +70,16 @@ func (s FakeSharedIndexInformer) AddEventHandlerWithResyncPeriod(handler cache.R
 }
 func (s FakeSharedIndexInformer) GetStore() cache.Store           { return nil }
 func (s FakeSharedIndexInformer) GetController() cache.Controller { return nil }
-func (s FakeSharedIndexInformer) Run(stopCh <-chan struct{})      {}
-func (s FakeSharedIndexInformer) HasSynced() bool                 { return true }
-func (s FakeSharedIndexInformer) LastSyncResourceVersion() string { return "" }
-func (s FakeSharedIndexInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+func (s *FakeSharedIndexInformer) Run(stopCh <-chan struct{})     {}
+func (s FakeSharedIndexInformer) HasSynced() bool {
+	if s.HasSyncedFn != nil {
+		return s.HasSyncedFn()
+	}
+	return true
+}
+func (s *FakeSharedIndexInformer) LastSyncResourceVersion() string { return s.ResourceVersion }
+func (s *FakeSharedIndexInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	s.WatchErrorHandler = handler
 	return nil
 }
 func (s FakeSharedIndexInformer) AddIndexers(indexers cache.Indexers) error { return nil }
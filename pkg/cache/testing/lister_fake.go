@@ -0,0 +1,71 @@
+package testing
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+var _ cache.ResourceLister = (*FakeResourceLister)(nil)
+
+// FakeResourceLister is a ResourceLister serving Objects, filtering them by label selector.
+type FakeResourceLister struct {
+	ListErr error
+	Objects []runtime.Object
+
+	WatchKey cache.WatchKey
+}
+
+func NewFakeResourceLister(objects ...runtime.Object) *FakeResourceLister {
+	return &FakeResourceLister{Objects: objects}
+}
+
+// List will return all objects matching the selector
+func (f *FakeResourceLister) List(selector labels.Selector) (ret []runtime.Object, err error) {
+	for _, obj := range f.Objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if selector.Matches(labels.Set(accessor.GetLabels())) {
+			ret = append(ret, obj)
+		}
+	}
+	return ret, f.ListErr
+}
+
+// Get will return the first object with the name
+func (f *FakeResourceLister) Get(name string) (runtime.Object, error) {
+	return f.GetNamespaced("", name)
+}
+
+// GetNamespaced will return the object with the namespace and name, an empty namespace matches any namespace
+func (f *FakeResourceLister) GetNamespaced(namespace, name string) (runtime.Object, error) {
+	for _, obj := range f.Objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if accessor.GetName() == name && (namespace == "" || accessor.GetNamespace() == namespace) {
+			return obj, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: f.WatchKey.GroupVersionResource.Resource}, name)
+}
+
+func (f *FakeResourceLister) Drain(ch chan<- interface{}, stopCh chan struct{}) {}
+func (f *FakeResourceLister) Stop()                                             {}
+func (f *FakeResourceLister) Namespace() string                                 { return f.WatchKey.Namespace }
+func (f *FakeResourceLister) Namespaces() []string                              { return []string{f.WatchKey.Namespace} }
+func (f *FakeResourceLister) Key() string                                       { return f.WatchKey.String() }
+func (f *FakeResourceLister) Keys() []cache.WatchKey                            { return []cache.WatchKey{f.WatchKey} }
+func (f *FakeResourceLister) IsRunning() int                                    { return 1 }
+func (f *FakeResourceLister) Statuses() []cache.WatchStatus                     { return nil }
+func (f *FakeResourceLister) HasSynced() bool                                   { return true }
+func (f *FakeResourceLister) WaitForSync(ctx context.Context) error             { return nil }
@@ -0,0 +1,68 @@
+package testing
+
+import (
+	"context"
+	stdtesting "testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// PodResource is the resource of v1 Pods.
+var PodResource = resource.Resource{
+	GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+	APIResource: metav1.APIResource{
+		Name:       "pods",
+		Namespaced: true,
+		Kind:       "Pod",
+		Verbs:      metav1.Verbs{"get", "list", "watch"},
+	},
+}
+
+// NewPod returns a v1 Pod whose UID is its namespace and name.
+func NewPod(namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion("v1")
+	u.SetKind("Pod")
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetUID(types.UID(namespace + "/" + name))
+	return u
+}
+
+// WatchPods creates a watch of PodResource in the namespace, metav1.NamespaceAll for every namespace, with a
+// Watcher of its own and returns the FakeIndexerInformer driving its events. The objects are added to the
+// informer before the watch is created. The watch is stopped when the test ends.
+func WatchPods(t stdtesting.TB, namespace string, objs ...*unstructured.Unstructured) *FakeIndexerInformer {
+	t.Helper()
+
+	factory := NewFakeIndexerInformerFactory()
+	informer := factory.Informer(PodResource.GroupVersionResource())
+	for _, obj := range objs {
+		if err := informer.GetIndexer().Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	watcher, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())),
+		cache.WithDynamicSharedInformerFactory(factory),
+		cache.WithLogger(zap.NewNop()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lister, err := watcher.Watch(context.TODO(), namespace, PodResource, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(lister.Stop)
+	return informer
+}
@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+// DefaultConvertedCacheSize is the number of converted objects kept by a TypedLister with WithConvertedCache.
+const DefaultConvertedCacheSize = 10000
+
+// TypedLister wraps a ResourceLister and converts the unstructured objects it returns
+// into T, a pointer to a Go type registered with the configured scheme (e.g. *corev1.Pod).
+// Use NewTypedLister to create instances of TypedLister.
+type TypedLister[T runtime.Object] struct {
+	Lister ResourceLister
+
+	scheme       *runtime.Scheme
+	cacheObjects bool
+	cacheSize    int
+	gvk          schema.GroupVersionKind
+	// mu guards objects and lru, the front of lru is the most recently used converted object
+	mu      *sync.Mutex
+	objects map[types.UID]*list.Element
+	lru     *list.List
+}
+
+type typedEntry[T runtime.Object] struct {
+	uid             types.UID
+	resourceVersion string
+	object          T
+}
+
+// NewTypedLister creates a TypedLister for the given ResourceLister. The scheme defaults to the
+// client-go scheme, use WithScheme to convert to custom types.
+func NewTypedLister[T runtime.Object](lister ResourceLister, options ...TypedListerOption) (*TypedLister[T], error) {
+	o := &typedListerOptions{scheme: scheme.Scheme, cacheSize: DefaultConvertedCacheSize}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	var zero T
+	if reflect.TypeOf(zero) == nil || reflect.TypeOf(zero).Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("typed lister, type %T must be a pointer to a struct", zero)
+	}
+
+	gvks, _, err := o.scheme.ObjectKinds(newTypedObject[T]())
+	if err != nil {
+		return nil, fmt.Errorf("typed lister, %w", err)
+	}
+
+	return &TypedLister[T]{
+		Lister:       lister,
+		scheme:       o.scheme,
+		cacheObjects: o.cacheObjects,
+		cacheSize:    o.cacheSize,
+		gvk:          gvks[0],
+		mu:           &sync.Mutex{},
+		objects:      map[types.UID]*list.Element{},
+		lru:          list.New(),
+	}, nil
+}
+
+// List converts the results of the underlying List call. Objects that fail to convert are
+// skipped and reported in the returned error, along with any error from the underlying List.
+func (t *TypedLister[T]) List(selector labels.Selector) ([]T, error) {
+	objs, listErr := t.Lister.List(selector)
+
+	result := make([]T, 0, len(objs))
+	seen := map[types.UID]struct{}{}
+	convErrs := []*errors.ObjectConversionError{}
+	for _, obj := range objs {
+		typed, err := t.convert(obj)
+		if err != nil {
+			convErrs = append(convErrs, err)
+			continue
+		}
+		if accessor, ok := interface{}(typed).(interface{ GetUID() types.UID }); ok {
+			seen[accessor.GetUID()] = struct{}{}
+		}
+		result = append(result, typed)
+	}
+
+	// only a full listing is able to tell which cached conversions are for deleted objects
+	if t.cacheObjects && listErr == nil && selector.Empty() {
+		t.mu.Lock()
+		for uid, e := range t.objects {
+			if _, ok := seen[uid]; !ok {
+				t.lru.Remove(e)
+				delete(t.objects, uid)
+			}
+		}
+		t.mu.Unlock()
+	}
+
+	if len(convErrs) > 0 {
+		return result, &errors.TypedListErrors{ListErr: listErr, Errs: convErrs}
+	}
+	return result, listErr
+}
+
+// Get converts the result of the underlying Get call.
+func (t *TypedLister[T]) Get(name string) (T, error) {
+	var zero T
+	obj, err := t.Lister.Get(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, convErr := t.convert(obj)
+	if convErr != nil {
+		return zero, convErr
+	}
+	return typed, nil
+}
+
+// GetNamespaced converts the result of the underlying GetNamespaced call.
+func (t *TypedLister[T]) GetNamespaced(namespace, name string) (T, error) {
+	var zero T
+	obj, err := t.Lister.GetNamespaced(namespace, name)
+	if err != nil {
+		return zero, err
+	}
+	typed, convErr := t.convert(obj)
+	if convErr != nil {
+		return zero, convErr
+	}
+	return typed, nil
+}
+
+func (t *TypedLister[T]) convert(obj runtime.Object) (T, *errors.ObjectConversionError) {
+	var zero T
+
+	if typed, ok := obj.(T); ok {
+		return typed, nil
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return zero, &errors.ObjectConversionError{Err: fmt.Errorf("unexpected object type %T", obj)}
+	}
+
+	// objects without a UID, such as those built by hand, can not be told apart
+	cacheable := t.cacheObjects && u.GetUID() != ""
+	if cacheable {
+		if typed, ok := t.load(u.GetUID(), u.GetResourceVersion()); ok {
+			return typed, nil
+		}
+	}
+
+	// objects of another version may have different fields, they are not decoded into T
+	if gvk := u.GroupVersionKind(); gvk != t.gvk {
+		return zero, &errors.ObjectConversionError{
+			Name:      u.GetName(),
+			Namespace: u.GetNamespace(),
+			Err:       fmt.Errorf("object kind %s does not match %s", u.GroupVersionKind(), t.gvk),
+		}
+	}
+
+	typed := newTypedObject[T]()
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), typed); err != nil {
+		return zero, &errors.ObjectConversionError{Name: u.GetName(), Namespace: u.GetNamespace(), Err: err}
+	}
+
+	if cacheable {
+		t.store(typedEntry[T]{uid: u.GetUID(), resourceVersion: u.GetResourceVersion(), object: typed})
+	}
+	return typed, nil
+}
+
+// load returns the converted object of the UID when it was converted at the resourceVersion.
+func (t *TypedLister[T]) load(uid types.UID, resourceVersion string) (T, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.objects[uid]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	entry := e.Value.(typedEntry[T])
+	if entry.resourceVersion != resourceVersion {
+		var zero T
+		return zero, false
+	}
+	t.lru.MoveToFront(e)
+	return entry.object, true
+}
+
+// store keeps the entry, replacing the entry of a previous resourceVersion. Once the cache is full the
+// least recently used entry is evicted.
+func (t *TypedLister[T]) store(entry typedEntry[T]) {
+	if t.cacheSize < 1 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.objects[entry.uid]; ok {
+		e.Value = entry
+		t.lru.MoveToFront(e)
+		return
+	}
+	t.objects[entry.uid] = t.lru.PushFront(entry)
+	if t.lru.Len() > t.cacheSize {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.objects, oldest.Value.(typedEntry[T]).uid)
+	}
+}
+
+func newTypedObject[T runtime.Object]() T {
+	var zero T
+	return reflect.New(reflect.TypeOf(zero).Elem()).Interface().(T)
+}
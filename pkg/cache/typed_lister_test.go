@@ -0,0 +1,178 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+func newUnstructured(apiVersion, kind, namespace, name, uid, resourceVersion string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetUID(types.UID(uid))
+	u.SetResourceVersion(resourceVersion)
+	return u
+}
+
+func TestTypedLister(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	dynFake := ctesting.FakeDynamicClient{}
+
+	pod := newUnstructured("v1", "Pod", "default", "nginx", "1", "10")
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Objects = []runtime.Object{pod}
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Object = pod
+
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(dsifFake),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+
+	wd, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+
+	pods, err := cache.NewTypedLister[*corev1.Pod](wd)
+	assert.Nil(t, err)
+
+	objs, err := pods.List(labels.Everything())
+	assert.Nil(t, err)
+	assert.Len(t, objs, 1)
+	assert.Equal(t, "nginx", objs[0].Name)
+	assert.Equal(t, "default", objs[0].Namespace)
+
+	obj, err := pods.Get("nginx")
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx", obj.Name)
+
+	deployments, err := cache.NewTypedLister[*appsv1.Deployment](wd)
+	assert.Nil(t, err)
+
+	_, err = deployments.Get("nginx")
+	convErr := &errors.ObjectConversionError{}
+	assert.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "nginx", convErr.Name)
+
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Objects = []runtime.Object{pod, pod}
+	depObjs, err := deployments.List(labels.Everything())
+	assert.Len(t, depObjs, 0)
+	listErrs := &errors.TypedListErrors{}
+	assert.ErrorAs(t, err, &listErrs)
+	assert.Nil(t, listErrs.ListErr)
+	assert.Len(t, listErrs.Errs, 2)
+
+	// objects of another version of the kind are not decoded
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Object = newUnstructured("v2", "Pod", "default", "nginx", "1", "10")
+	_, err = pods.Get("nginx")
+	assert.ErrorAs(t, err, &convErr)
+}
+
+func TestTypedListerConvertedCache(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	dynFake := ctesting.FakeDynamicClient{}
+
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(dsifFake),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+
+	wd, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+
+	pods, err := cache.NewTypedLister[*corev1.Pod](wd, cache.WithConvertedCache(true))
+	assert.Nil(t, err)
+
+	pod := newUnstructured("v1", "Pod", "default", "nginx", "1", "10")
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Object = pod
+
+	first, err := pods.Get("nginx")
+	assert.Nil(t, err)
+	second, err := pods.Get("nginx")
+	assert.Nil(t, err)
+	assert.Same(t, first, second)
+
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Object = newUnstructured("v1", "Pod", "default", "nginx", "1", "11")
+	third, err := pods.Get("nginx")
+	assert.Nil(t, err)
+	assert.NotSame(t, first, third)
+	assert.Equal(t, "11", third.ResourceVersion)
+
+	// objects without a UID are not cached
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Object = newUnstructured("v1", "Pod", "default", "nginx", "", "12")
+	first, err = pods.Get("nginx")
+	assert.Nil(t, err)
+	second, err = pods.Get("nginx")
+	assert.Nil(t, err)
+	assert.NotSame(t, first, second)
+}
+
+func TestTypedListerConvertedCacheSize(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(ctesting.FakeDynamicClient{}),
+		cache.WithDynamicSharedInformerFactory(dsifFake),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+	wd, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+
+	pods, err := cache.NewTypedLister[*corev1.Pod](wd, cache.WithConvertedCache(true), cache.WithConvertedCacheSize(1))
+	assert.Nil(t, err)
+	get := func(obj *unstructured.Unstructured) *corev1.Pod {
+		dsifFake.GenericInformer.GenericLister.NamespaceLister.Object = obj
+		pod, err := pods.Get(obj.GetName())
+		assert.Nil(t, err)
+		return pod
+	}
+
+	nginx := newUnstructured("v1", "Pod", "default", "nginx", "1", "10")
+	redis := newUnstructured("v1", "Pod", "default", "redis", "2", "10")
+	cachedNginx := get(nginx)
+	assert.Same(t, cachedNginx, get(nginx))
+	// the cache is full, redis evicts the least recently used nginx
+	cachedRedis := get(redis)
+	assert.Same(t, cachedRedis, get(redis))
+	cachedNginx, previous := get(nginx), cachedNginx
+	assert.NotSame(t, previous, cachedNginx)
+	assert.Same(t, cachedNginx, get(nginx))
+
+	// a full List drops the deleted objects
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Objects = []runtime.Object{}
+	_, err = pods.List(labels.Everything())
+	assert.Nil(t, err)
+	assert.NotSame(t, cachedNginx, get(nginx))
+}
+
+func TestTypedListerErr(t *testing.T) {
+	_, err := cache.NewTypedLister[*unstructured.Unstructured](nil, cache.WithScheme(runtime.NewScheme()))
+	assert.Error(t, err)
+
+	_, err = cache.NewTypedLister[runtime.Object](nil)
+	assert.EqualError(t, err, "typed lister, type <nil> must be a pointer to a struct")
+}
@@ -1,11 +1,13 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -13,12 +15,13 @@ import (
 	kcache "k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
 	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 )
 
 var (
 	DefaultResyncDuration = time.Second * 180
-	ResourceWatches       = &sync.Map{} // sync.Map{"resourceKey": [sync.Map{"namespace.resourceKey":"watchDetail"}]}
+	ResourceWatches       = &sync.Map{} // key:WatchKey, value:*WatchDetail
 )
 
 // WatchDetail holds the details of an Informer and Lister for a specific resource.
@@ -34,10 +37,26 @@ type WatchDetail struct {
 
 	namespace string
 	informer  informers.GenericInformer
+	status    watchStatus
+	key       WatchKey
+
+	// done is closed when the Informer loop exits, nil when the WatchDetail runs no loop
+	done chan struct{}
+	// drains counts the running Drain loops, drained is closed once a Wait sees them all exit
+	drainMu sync.Mutex
+	drains  int
+	drained chan struct{}
+	// stop closes the StopCh once
+	stop sync.Once
+	// forget notifies the EventHandlers of the deletion of the cached objects once the watch is stopped
+	forget sync.Once
 }
 
 var _ ResourceLister = (*WatchDetail)(nil)
 
+// Key returns the namespace and resource of the WatchDetail as namespace.version.Kind.
+//
+// Deprecated: use WatchKey().String().
 func (w *WatchDetail) Key() string {
 	return fmt.Sprintf("%s.%s", w.namespace, w.Resource.Key())
 }
@@ -46,6 +65,19 @@ func (w *WatchDetail) Namespace() string {
 	return w.namespace
 }
 
+// WatchKey returns the key of the WatchDetail in the ResourceWatches registry.
+func (w *WatchDetail) WatchKey() WatchKey {
+	return w.key
+}
+
+func (w *WatchDetail) Keys() []WatchKey {
+	return []WatchKey{w.key}
+}
+
+func (w *WatchDetail) Namespaces() []string {
+	return []string{w.namespace}
+}
+
 func (w *WatchDetail) List(selector labels.Selector) ([]runtime.Object, error) {
 	if w.namespace == metav1.NamespaceAll {
 		return w.informer.Lister().List(selector)
@@ -60,6 +92,26 @@ func (w *WatchDetail) Get(name string) (runtime.Object, error) {
 	return w.informer.Lister().ByNamespace(w.namespace).Get(name)
 }
 
+// GetNamespaced retrieves the object by namespace and name. A NotFound error is returned
+// when the namespace is not covered by the WatchDetail.
+func (w *WatchDetail) GetNamespaced(namespace, name string) (runtime.Object, error) {
+	if namespace == metav1.NamespaceAll {
+		return w.informer.Lister().Get(name)
+	}
+	if w.namespace != metav1.NamespaceAll && w.namespace != namespace {
+		return nil, apierrors.NewNotFound(w.Resource.GroupVersionResource().GroupResource(), name)
+	}
+	return w.informer.Lister().ByNamespace(namespace).Get(name)
+}
+
+// logger returns the Logger of the WatchDetail, a no-op logger when none is set.
+func (w *WatchDetail) logger() *zap.Logger {
+	if w.Logger == nil {
+		return zap.NewNop()
+	}
+	return w.Logger
+}
+
 // IsRunning returns true if the Informer loop for the WatchDetail is running.
 func (w *WatchDetail) IsRunning() int {
 	select {
@@ -70,16 +122,117 @@ func (w *WatchDetail) IsRunning() int {
 	}
 }
 
-// Stop closes the StopCh shutting down the Drain and Informer loops.
+// HasSynced returns true once the Informer has completed its initial List.
+func (w *WatchDetail) HasSynced() bool {
+	return w.informer.Informer().HasSynced()
+}
+
+// WaitForSync blocks until the Informer has synced. An error is returned if the watch
+// is stopped or the context is done before that happens.
+func (w *WatchDetail) WaitForSync(ctx context.Context) error {
+	if w.HasSynced() {
+		return nil
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	stopCh := make(chan struct{})
+	go func() {
+		defer close(stopCh)
+		select {
+		case <-ctx.Done():
+		case <-w.StopCh:
+		case <-done:
+		}
+	}()
+
+	if kcache.WaitForCacheSync(stopCh, w.HasSynced) {
+		return nil
+	}
+
+	err := ctx.Err()
+	if err == nil {
+		err = fmt.Errorf("watch stopped")
+	}
+	return &errors.WatchNotSynced{Keys: []string{w.WatchKey().String()}, Err: err}
+}
+
+// Stop closes the StopCh shutting down the Drain and Informer loops. The registered EventHandlers are
+// notified of the deletion of the objects cached by the watch.
 func (w *WatchDetail) Stop() {
-	if w.IsRunning() == 1 {
+	w.closeStopCh()
+	// release Drain loops waiting for the next event
+	if w.Queue != nil {
+		w.Queue.ShutDown()
+	}
+	w.forget.Do(w.notifyDeletes)
+}
+
+func (w *WatchDetail) closeStopCh() {
+	w.stop.Do(func() {
 		close(w.StopCh)
+	})
+}
+
+func (w *WatchDetail) notifyDeletes() {
+	if w.informer == nil {
+		return
+	}
+	objs, err := w.List(labels.Everything())
+	if err != nil {
+		w.logger().Warn("unable to notify event handlers of stopped watch",
+			zap.String("key", w.WatchKey().String()),
+			zap.Error(err),
+		)
+		return
+	}
+	for _, obj := range objs {
+		eventHandlers.delete(w.key, obj)
+	}
+}
+
+// Wait blocks until the Informer and Drain loops of the stopped WatchDetail have exited. A
+// *errors.WatchNotStopped is returned when the context is done first.
+func (w *WatchDetail) Wait(ctx context.Context) error {
+	for _, done := range []chan struct{}{w.done, w.drainsDone()} {
+		if done == nil {
+			continue
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return &errors.WatchNotStopped{Keys: []string{w.WatchKey().String()}, Err: ctx.Err()}
+		}
+	}
+	return nil
+}
+
+// drainsDone returns a channel closed once the running Drain loops exit, nil when none is running.
+func (w *WatchDetail) drainsDone() chan struct{} {
+	w.drainMu.Lock()
+	defer w.drainMu.Unlock()
+	if w.drains == 0 {
+		return nil
+	}
+	if w.drained == nil {
+		w.drained = make(chan struct{})
 	}
+	return w.drained
 }
 
 // Drain will get events off of the WatchDetail.Queue and send them to the provided channel.
+// A stopped WatchDetail starts no Drain loop.
 func (w *WatchDetail) Drain(ch chan<- interface{}, stopCh chan struct{}) {
+	w.drainMu.Lock()
+	defer w.drainMu.Unlock()
+	if w.IsRunning() == 0 {
+		return
+	}
+	w.drains++
+
 	go func() {
+		defer w.drainDone()
 		for {
 			select {
 			// Main stopCh for errors/controllers
@@ -94,12 +247,36 @@ func (w *WatchDetail) Drain(ch chan<- interface{}, stopCh chan struct{}) {
 				i, shutdown := w.Queue.Get()
 				if shutdown {
 					w.Logger.Debug("processing queue and shutting down")
-					ch <- i
+					w.send(ch, i, stopCh)
 					return
 				}
 				w.Logger.Debug("processing queue")
-				ch <- i
+				if !w.send(ch, i, stopCh) {
+					return
+				}
 			}
 		}
 	}()
 }
+
+func (w *WatchDetail) drainDone() {
+	w.drainMu.Lock()
+	defer w.drainMu.Unlock()
+	w.drains--
+	if w.drains == 0 && w.drained != nil {
+		close(w.drained)
+		w.drained = nil
+	}
+}
+
+// send sends the event to the channel of a Drain loop, false is returned when a stopCh is closed first.
+func (w *WatchDetail) send(ch chan<- interface{}, i interface{}, stopCh chan struct{}) bool {
+	select {
+	case ch <- i:
+		return true
+	case <-w.StopCh:
+		return false
+	case <-stopCh:
+		return false
+	}
+}
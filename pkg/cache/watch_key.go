@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WatchKey uniquely identifies a watch in the ResourceWatches registry.
+// An empty Namespace is a NamespaceAll watch and an empty Selector watches every object.
+type WatchKey struct {
+	Cluster              string
+	GroupVersionResource schema.GroupVersionResource
+	Namespace            string
+	Selector             string
+}
+
+// String encodes the WatchKey as cluster/group/version/resource/namespace with an optional
+// ?labelSelector= suffix. Every element is path escaped so the result can be parsed with ParseWatchKey.
+func (k WatchKey) String() string {
+	key := strings.Join([]string{
+		url.PathEscape(k.Cluster),
+		url.PathEscape(k.GroupVersionResource.Group),
+		url.PathEscape(k.GroupVersionResource.Version),
+		url.PathEscape(k.GroupVersionResource.Resource),
+		url.PathEscape(k.Namespace),
+	}, "/")
+	if k.Selector != "" {
+		key += "?" + url.Values{"labelSelector": []string{k.Selector}}.Encode()
+	}
+	return key
+}
+
+// ParseWatchKey parses the output of WatchKey.String.
+func ParseWatchKey(s string) (WatchKey, error) {
+	path, query := s, ""
+	if i := strings.Index(s, "?"); i >= 0 {
+		path, query = s[:i], s[i+1:]
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 5 {
+		return WatchKey{}, fmt.Errorf("invalid watch key %q, expected cluster/group/version/resource/namespace", s)
+	}
+	for i, p := range parts {
+		unescaped, err := url.PathUnescape(p)
+		if err != nil {
+			return WatchKey{}, fmt.Errorf("invalid watch key %q, %w", s, err)
+		}
+		parts[i] = unescaped
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return WatchKey{}, fmt.Errorf("invalid watch key %q, %w", s, err)
+	}
+
+	return WatchKey{
+		Cluster:              parts[0],
+		GroupVersionResource: schema.GroupVersionResource{Group: parts[1], Version: parts[2], Resource: parts[3]},
+		Namespace:            parts[4],
+		Selector:             values.Get("labelSelector"),
+	}, nil
+}
+
+func uniqueWatchKeys(keys []WatchKey) []WatchKey {
+	seen := make(map[WatchKey]struct{})
+	list := []WatchKey{}
+	for _, key := range keys {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			list = append(list, key)
+		}
+	}
+	return list
+}
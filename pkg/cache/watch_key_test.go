@@ -0,0 +1,133 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+)
+
+func TestWatchKeyString(t *testing.T) {
+	keys := []cache.WatchKey{
+		{},
+		{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}},
+		{Cluster: "kind/dev", GroupVersionResource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, Namespace: "default"},
+		{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Selector: "app in (a,b),tier=web"},
+	}
+
+	assert.Equal(t, "////", keys[0].String())
+	assert.Equal(t, "//v1/pods/", keys[1].String())
+	assert.Equal(t, "kind%2Fdev/apps/v1/deployments/default", keys[2].String())
+
+	for _, key := range keys {
+		parsed, err := cache.ParseWatchKey(key.String())
+		assert.Nil(t, err)
+		assert.Equal(t, key, parsed)
+	}
+
+	_, err := cache.ParseWatchKey("v1/pods")
+	assert.EqualError(t, err, "invalid watch key \"v1/pods\", expected cluster/group/version/resource/namespace")
+}
+
+func TestWatchKeyRegistry(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dynFake := ctesting.FakeDynamicClient{}
+	dev, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(wtesting.NewFakeDynamicSharedInformerFactory()),
+	)
+	assert.Nil(t, err)
+	prod, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(wtesting.NewFakeDynamicSharedInformerFactory()),
+		cache.WithCluster("prod"),
+	)
+	assert.Nil(t, err)
+
+	devPods, err := dev.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	prodPods, err := prod.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	assert.NotEqual(t, devPods.Keys(), prodPods.Keys())
+	assert.Len(t, cache.WatchStatuses(), 2)
+
+	_, err = dev.Watch(context.TODO(), "kube-system", podResource, false)
+	assert.Nil(t, err)
+
+	lister, err := cache.WatchForResource(podResource, "default", "kube-system")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default", "kube-system"}, lister.Namespaces())
+	assert.Equal(t, []cache.WatchKey{
+		{GroupVersionResource: podResource.GroupVersionResource(), Namespace: "default"},
+		{GroupVersionResource: podResource.GroupVersionResource(), Namespace: "kube-system"},
+	}, lister.Keys())
+
+	lister, err = cache.WatchForClusterResource("prod", podResource)
+	assert.Nil(t, err)
+	assert.Equal(t, []cache.WatchKey{
+		{Cluster: "prod", GroupVersionResource: podResource.GroupVersionResource(), Namespace: "default"},
+	}, lister.Keys())
+
+	_, err = cache.WatchForClusterResource("prod", podResource, "kube-system")
+	assert.EqualError(t, err, "no matching watch found for resource: v1.Pod in namespaces: [kube-system]")
+
+	lister, err = cache.WatchForKind("prod", schema.GroupKind{Kind: "Pod"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default"}, lister.Namespaces())
+	assert.True(t, cache.IsKindWatched("prod", schema.GroupKind{Kind: "Pod"}))
+	assert.False(t, cache.IsKindWatched("prod", schema.GroupKind{Group: "apps", Kind: "Deployment"}))
+
+	_, err = cache.WatchForKind("prod", schema.GroupKind{Group: "apps", Kind: "Deployment"})
+	assert.EqualError(t, err, "no watch found for kind: Deployment.apps")
+	cache.ResourceWatches = &sync.Map{}
+}
+
+func TestWatchForResourceExactKey(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	defer func() { cache.ResourceWatches = &sync.Map{} }()
+
+	watchers := map[string]*cache.Watcher{}
+	for _, cluster := range []string{"dev", "prod"} {
+		w, err := cache.NewWatcher(context.TODO(),
+			cache.WithDynamicClient(ctesting.FakeDynamicClient{}),
+			cache.WithDynamicSharedInformerFactory(wtesting.NewFakeDynamicSharedInformerFactory()),
+			cache.WithCluster(cluster),
+		)
+		assert.Nil(t, err)
+		watchers[cluster] = w
+	}
+	_, err := watchers["dev"].Watch(context.TODO(), "", podResource, false)
+	assert.Nil(t, err)
+	_, err = watchers["prod"].Watch(context.TODO(), "test", podResource, false)
+	assert.Nil(t, err)
+	_, err = watchers["prod"].Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+
+	// the watches of the namespaces are loaded by their keys in the requested order
+	lister, err := cache.WatchForClusterResource("prod", podResource, "test", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test", "default"}, lister.Namespaces())
+	assert.Equal(t, []cache.WatchKey{
+		{Cluster: "prod", GroupVersionResource: podResource.GroupVersionResource(), Namespace: "test"},
+		{Cluster: "prod", GroupVersionResource: podResource.GroupVersionResource(), Namespace: "default"},
+	}, lister.Keys())
+
+	// the NamespaceAll watch serves the namespaces without a watch of their own
+	lister, err = cache.WatchForClusterResource("dev", podResource, "test", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test", "default"}, lister.Namespaces())
+	assert.Equal(t, []cache.WatchKey{
+		{Cluster: "dev", GroupVersionResource: podResource.GroupVersionResource()},
+	}, lister.Keys())
+
+	lister, err = cache.WatchForClusterResource("prod", podResource)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default", "test"}, lister.Namespaces())
+}
@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kcache "k8s.io/client-go/tools/cache"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
+)
+
+type WatchState string
+
+const (
+	// WatchStarting is the state of a watch that has been created but whose Informer has not started.
+	WatchStarting WatchState = "starting"
+	// WatchSyncing is the state of a watch whose Informer has not finished its initial List.
+	WatchSyncing WatchState = "syncing"
+	// WatchSynced is the state of a healthy watch.
+	WatchSynced WatchState = "synced"
+	// WatchErroring is the state of a watch that was shut down by an unrecoverable error.
+	WatchErroring WatchState = "erroring"
+	// WatchBackingOff is the state of a watch that encountered an error and is waiting to retry.
+	WatchBackingOff WatchState = "backing off"
+	// WatchStopped is the state of a watch that was stopped by a caller.
+	WatchStopped WatchState = "stopped"
+)
+
+// ActiveWatchStates are the states of a watch whose Informer loop is running.
+var ActiveWatchStates = []WatchState{WatchStarting, WatchSyncing, WatchSynced, WatchBackingOff}
+
+// WatchStatus is a point-in-time record of the health of a single WatchDetail.
+type WatchStatus struct {
+	Key             WatchKey
+	Resource        string
+	Namespace       string
+	State           WatchState
+	LastError       error
+	LastErrorTime   time.Time
+	LastEventTime   time.Time
+	ObjectCount     int
+	RestartCount    int
+	ResourceVersion string
+	StartedAt       time.Time
+}
+
+// Healthy returns true when the watch is running and has not encountered an error since it last synced.
+func (s WatchStatus) Healthy() bool {
+	switch s.State {
+	case WatchStarting, WatchSyncing, WatchSynced:
+		return true
+	default:
+		return false
+	}
+}
+
+// watchStatus holds the mutable parts of a WatchStatus, it is safe to use the zero value.
+type watchStatus struct {
+	mu sync.Mutex
+
+	state         WatchState
+	lastError     error
+	lastErrorTime time.Time
+	lastEventTime time.Time
+	restartCount  int
+	errorRV       string
+	startedAt     time.Time
+}
+
+func (s *watchStatus) setState(state WatchState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	if state == WatchSyncing {
+		s.startedAt = time.Now()
+	}
+}
+
+func (s *watchStatus) recordEvent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastEventTime = time.Now()
+}
+
+// recordError tracks an error returned to the Reflector. Terminal errors shut down the Informer,
+// all other errors cause the Reflector to back off and restart the ListAndWatch.
+func (s *watchStatus) recordError(err error, resourceVersion string, terminal bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err
+	s.lastErrorTime = time.Now()
+	s.errorRV = resourceVersion
+	if terminal {
+		s.state = WatchErroring
+		return
+	}
+	s.state = WatchBackingOff
+	s.restartCount += 1
+}
+
+// recordEvent updates the last event time and the event metrics for an Informer event.
+func (w *WatchDetail) recordEvent(eventType string) {
+	w.status.recordEvent()
+	metrics.WatchEvents.WithLabelValues(w.key.Cluster, w.Resource.Key(), w.namespace, eventType).Inc()
+}
+
+// objectCount counts the cached objects of the WatchDetail from the keys of the Informer store, without
+// copying the objects. The namespace index is used when the Informer is shared by several namespaces.
+func (w *WatchDetail) objectCount() int {
+	informer := w.informer.Informer()
+	if w.namespace == metav1.NamespaceAll {
+		if store := informer.GetStore(); store != nil {
+			return len(store.ListKeys())
+		}
+	} else if indexer := informer.GetIndexer(); indexer != nil {
+		if keys, err := indexer.IndexKeys(kcache.NamespaceIndex, w.namespace); err == nil {
+			return len(keys)
+		}
+	}
+
+	// informers without a store or namespace index
+	objs, err := w.List(labels.Everything())
+	if err != nil {
+		return 0
+	}
+	return len(objs)
+}
+
+// namespaceObjectCounts counts the cached objects of the WatchDetail by their namespace. A NamespaceAll watch
+// counts the keys of the namespace index, or lists its objects when the Informer has no namespace index.
+func (w *WatchDetail) namespaceObjectCounts() map[string]int {
+	if w.namespace != metav1.NamespaceAll {
+		return map[string]int{w.namespace: w.objectCount()}
+	}
+
+	counts := map[string]int{}
+	if indexer := w.informer.Informer().GetIndexer(); indexer != nil {
+		if _, ok := indexer.GetIndexers()[kcache.NamespaceIndex]; ok {
+			for _, namespace := range indexer.ListIndexFuncValues(kcache.NamespaceIndex) {
+				if keys, err := indexer.IndexKeys(kcache.NamespaceIndex, namespace); err == nil && len(keys) > 0 {
+					counts[namespace] = len(keys)
+				}
+			}
+			return counts
+		}
+	}
+
+	objs, err := w.List(labels.Everything())
+	if err != nil {
+		return counts
+	}
+	for _, obj := range objs {
+		if accessor, err := meta.Accessor(obj); err == nil {
+			counts[accessor.GetNamespace()]++
+		}
+	}
+	return counts
+}
+
+// Status returns the current WatchStatus for the WatchDetail.
+func (w *WatchDetail) Status() WatchStatus {
+	status := WatchStatus{
+		Key:       w.WatchKey(),
+		Resource:  w.Resource.Key(),
+		Namespace: w.namespace,
+	}
+
+	if w.informer != nil {
+		status.ResourceVersion = w.informer.Informer().LastSyncResourceVersion()
+		status.ObjectCount = w.objectCount()
+	}
+
+	w.status.mu.Lock()
+	defer w.status.mu.Unlock()
+
+	state := w.status.state
+	switch {
+	case w.IsRunning() == 0:
+		if state != WatchErroring {
+			state = WatchStopped
+		}
+	case state == WatchSyncing && w.HasSynced():
+		state = WatchSynced
+	case state == WatchBackingOff && status.ResourceVersion != w.status.errorRV:
+		// the Reflector has completed a List since the error
+		state = WatchSynced
+	case state == "":
+		state = WatchStarting
+	}
+	w.status.state = state
+
+	status.State = state
+	status.LastError = w.status.lastError
+	status.LastErrorTime = w.status.lastErrorTime
+	status.LastEventTime = w.status.lastEventTime
+	status.RestartCount = w.status.restartCount
+	status.StartedAt = w.status.startedAt
+	return status
+}
+
+// Statuses returns a single element list containing the WatchDetail status.
+func (w *WatchDetail) Statuses() []WatchStatus {
+	return []WatchStatus{w.Status()}
+}
+
+// WatchStatuses returns the status of every watch in the cache.
+// If states are provided, only watches in one of those states are returned.
+func WatchStatuses(states ...WatchState) []WatchStatus {
+	statuses := []WatchStatus{}
+	for _, detail := range watchDetails() {
+		status := detail.Status()
+		if len(states) > 0 && !hasWatchState(states, status.State) {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func hasWatchState(states []WatchState, state WatchState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// watchStats summarizes every watch in the cache for the metrics collector.
+func watchStats() []metrics.WatchStat {
+	stats := []metrics.WatchStat{}
+	for _, detail := range watchDetails() {
+		stat := metrics.WatchStat{
+			Cluster:   detail.key.Cluster,
+			Resource:  detail.Resource.Key(),
+			Namespace: detail.namespace,
+			Running:   detail.IsRunning() == 1,
+		}
+		if detail.informer != nil {
+			stat.Objects = detail.namespaceObjectCounts()
+		}
+		if detail.Queue != nil {
+			stat.QueueDepth = detail.Queue.Len()
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
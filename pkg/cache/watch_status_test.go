@@ -0,0 +1,132 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
+)
+
+func TestWatchStatus(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	dynFake := ctesting.FakeDynamicClient{}
+
+	synced := false
+	informer := dsifFake.GenericInformer.SharedIndexInformer
+	informer.HasSyncedFn = func() bool { return synced }
+	informer.ResourceVersion = "10"
+	dsifFake.GenericInformer.GenericLister.NamespaceLister.Objects = []runtime.Object{nil, nil}
+
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(dsifFake),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+
+	lister, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	wd := lister.(*cache.WatchDetail)
+
+	assert.Eventually(t, func() bool { return wd.Status().State == cache.WatchSyncing }, time.Second, time.Millisecond)
+
+	synced = true
+	status := wd.Status()
+	assert.Equal(t, cache.WatchSynced, status.State)
+	assert.Equal(t, "//v1/pods/default", status.Key.String())
+	assert.Equal(t, "v1.Pod", status.Resource)
+	assert.Equal(t, "default", status.Namespace)
+	assert.Equal(t, 2, status.ObjectCount)
+	assert.Equal(t, "10", status.ResourceVersion)
+	assert.False(t, status.StartedAt.IsZero())
+	assert.True(t, status.LastEventTime.IsZero())
+	assert.True(t, status.Healthy())
+
+	informer.Handlers[0].OnAdd(nil)
+	assert.False(t, wd.Status().LastEventTime.IsZero())
+
+	informer.WatchErrorHandler(nil, fmt.Errorf("connection refused"))
+	status = wd.Status()
+	assert.Equal(t, cache.WatchBackingOff, status.State)
+	assert.EqualError(t, status.LastError, "connection refused")
+	assert.Equal(t, 1, status.RestartCount)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WatchRestarts.WithLabelValues("", "v1.Pod", "default")))
+	assert.False(t, status.Healthy())
+
+	informer.ResourceVersion = "11"
+	assert.Equal(t, cache.WatchSynced, wd.Status().State)
+
+	assert.Len(t, lister.Statuses(), 1)
+	wrapped, err := cache.WatchForResource(podResource, "default")
+	assert.Nil(t, err)
+	assert.Len(t, wrapped.Statuses(), 1)
+
+	informer.WatchErrorHandler(nil, io.ErrUnexpectedEOF)
+	status = wd.Status()
+	assert.Equal(t, cache.WatchErroring, status.State)
+	assert.Equal(t, io.ErrUnexpectedEOF, status.LastError)
+	assert.Len(t, cache.WatchStatuses(cache.ActiveWatchStates...), 0)
+	assert.Len(t, cache.WatchStatuses(cache.WatchErroring), 1)
+	cache.ResourceWatches = &sync.Map{}
+}
+
+func TestWatchStatusStopped(t *testing.T) {
+	w := &cache.WatchDetail{StopCh: make(chan struct{})}
+	assert.Equal(t, cache.WatchStarting, w.Status().State)
+
+	w.Stop()
+	assert.Equal(t, cache.WatchStopped, w.Status().State)
+	assert.False(t, w.Status().Healthy())
+}
+
+func TestWatchStatusObjectCount(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	defer func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	assert.Nil(t, cluster.Create(context.TODO(),
+		newUnstructured("v1", "Pod", "default", "nginx", "1", ""),
+		newUnstructured("v1", "Pod", "default", "redis", "2", ""),
+		newUnstructured("v1", "Pod", "test", "nginx", "3", ""),
+	))
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	// the informers are not filtered by namespace, the namespace is counted from the index
+	counts := map[string]int{"default": 2, "test": 1, "": 3}
+	for namespace, count := range counts {
+		w, err := cache.NewWatcher(context.TODO(), cache.WithDynamicClient(cluster.Dynamic()), cache.WithCluster(namespace), cache.WithLogger(zap.NewNop()))
+		assert.Nil(t, err)
+		lister, err := w.Watch(context.TODO(), namespace, podResource, false)
+		assert.Nil(t, err)
+		assert.Nil(t, lister.WaitForSync(ctx))
+		assert.Equal(t, count, lister.(*cache.WatchDetail).Status().ObjectCount, namespace)
+	}
+
+	// the metrics count the objects of a NamespaceAll watch by their namespace
+	for _, stat := range metrics.WatchStatsFn() {
+		if stat.Namespace == "" {
+			assert.Equal(t, map[string]int{"default": 2, "test": 1}, stat.Objects)
+		} else {
+			assert.Equal(t, map[string]int{stat.Namespace: counts[stat.Namespace]}, stat.Objects)
+		}
+		assert.Equal(t, stat.Namespace, stat.Cluster)
+	}
+}
@@ -1,6 +1,7 @@
 package cache_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,6 +9,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
 )
 
 func TestWatchIsRunning(t *testing.T) {
@@ -71,3 +73,24 @@ func TestWatchDrainShutdown(t *testing.T) {
 	s := <-eventCh
 	assert.Equal(t, "shutdown", s.(string))
 }
+
+func TestWatchWait(t *testing.T) {
+	w := &cache.WatchDetail{StopCh: make(chan struct{}), Queue: workqueue.New(), Logger: zap.NewNop()}
+	// nothing reads the drained event, the Drain loop is blocked sending it
+	w.Drain(make(chan interface{}), make(chan struct{}))
+	w.Queue.Add("string1")
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	err := w.Wait(ctx)
+	notStopped := &errors.WatchNotStopped{}
+	assert.ErrorAs(t, err, &notStopped)
+
+	w.Stop()
+	w.Stop()
+	assert.Nil(t, w.Wait(context.TODO()))
+
+	// a stopped WatchDetail starts no Drain loop
+	w.Drain(make(chan interface{}), make(chan struct{}))
+	assert.Nil(t, w.Wait(ctx))
+}
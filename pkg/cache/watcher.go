@@ -4,17 +4,20 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 
 	"go.uber.org/zap"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	kcache "k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
-	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
 	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 )
 
@@ -22,9 +25,16 @@ import (
 // Use NewWatcher to create instances of Watcher.
 type Watcher struct {
 	dclient         dynamic.Interface
+	rotating        *rotatingClient
 	informerFactory dynamicinformer.DynamicSharedInformerFactory
 	namespace       string
+	cluster         string
+	selector        string
 	logger          *zap.Logger
+
+	mu      sync.Mutex
+	details []*WatchDetail
+	stopped bool
 }
 
 // NewWatcher creates a Watcher object. This object is used to hold the reference
@@ -43,16 +53,35 @@ func NewWatcher(ctx context.Context, options ...WatcherOption) (*Watcher, error)
 	if w.dclient == nil {
 		return nil, fmt.Errorf("dynamic client nil, use WithDynamicClient option")
 	}
+	w.rotating = newRotatingClient(w.dclient)
 
-	if w.namespace != "" && w.informerFactory == nil {
-		w.informerFactory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(w.dclient, DefaultResyncDuration, w.namespace, nil)
+	if (w.namespace != "" || w.selector != "") && w.informerFactory == nil {
+		var tweakListOptions dynamicinformer.TweakListOptionsFunc
+		if w.selector != "" {
+			tweakListOptions = func(opts *metav1.ListOptions) {
+				opts.LabelSelector = w.selector
+			}
+		}
+		w.informerFactory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(w.rotating, DefaultResyncDuration, w.namespace, tweakListOptions)
 	} else if w.informerFactory == nil {
-		w.informerFactory = dynamicinformer.NewDynamicSharedInformerFactory(w.dclient, DefaultResyncDuration)
+		w.informerFactory = dynamicinformer.NewDynamicSharedInformerFactory(w.rotating, DefaultResyncDuration)
 	}
 
 	return w, nil
 }
 
+// Cluster returns the cluster name used in the WatchKey of the watches created by the Watcher.
+func (w *Watcher) Cluster() string {
+	return w.cluster
+}
+
+// UpdateDynamicClient moves the watches of the Watcher to the dynamic client, such as one created with
+// rotated credentials. Running watches reconnect with the new client from their last resource version and
+// keep the objects already cached. Informers of a WithDynamicSharedInformerFactory are not moved.
+func (w *Watcher) UpdateDynamicClient(d dynamic.Interface) {
+	w.rotating.rotate(d)
+}
+
 // Watch creates a new WatchDetail and starts the watch loop for the given Resource
 // If queueEvents is true, all events for the resource will be added to the WatcheDetail.Queue
 // To handle the events use WatchDetail.Drain
@@ -60,11 +89,18 @@ func (w *Watcher) Watch(ctx context.Context, namespace string, res resource.Reso
 	if w.namespace != "" && namespace != w.namespace {
 		return nil, fmt.Errorf("unable to create watch, resource namespace:%s does not match watcher namespace:%s", namespace, w.namespace)
 	}
+	if w.isStopped() {
+		return nil, fmt.Errorf("unable to create watch, watcher stopped")
+	}
 
-	lister, err := WatchForResource(res, namespace)
+	lister, err := watchForResource(w.cluster, w.selector, res, namespace)
 	if err == nil {
 		return lister, nil
 	}
+	w.logger.Debug("creating watch",
+		zap.String("resource", res.Key()),
+		zap.String("namespace", namespace),
+	)
 
 	genericInformer := w.informerFactory.ForResource(res.GroupVersionResource())
 
@@ -76,201 +112,310 @@ func (w *Watcher) Watch(ctx context.Context, namespace string, res resource.Reso
 		Queue:       workqueue.NewNamed(res.Key()),
 		StopCh:      make(chan struct{}),
 		Logger:      w.logger,
+		done:        make(chan struct{}),
+		key: WatchKey{
+			Cluster:              w.cluster,
+			GroupVersionResource: res.GroupVersionResource(),
+			Namespace:            namespace,
+			Selector:             w.selector,
+		},
 	}
 
-	// boardcast function that will publish changes to a channel for clients
-	if detail.queueEvents {
-		genericInformer.Informer().AddEventHandler(kcache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				w.logger.Debug("watch add",
-					zap.String("obj", fmt.Sprintf("%v", obj)),
-				)
+	// record event times for the WatchStatus, notify the registered EventHandlers and, if requested,
+	// broadcast changes to a channel for clients
+	genericInformer.Informer().AddEventHandler(kcache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.logger.Debug("watch add",
+				zap.Stringer("obj", objectRef{obj}),
+			)
+			detail.recordEvent("add")
+			eventHandlers.add(detail.key, obj)
+			if detail.queueEvents {
 				detail.Queue.Add(obj)
-			},
-			DeleteFunc: func(obj interface{}) {
-				w.logger.Debug("watch delete",
-					zap.String("obj", fmt.Sprintf("%v", obj)),
-				)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			w.logger.Debug("watch delete",
+				zap.Stringer("obj", objectRef{obj}),
+			)
+			detail.recordEvent("delete")
+			eventHandlers.delete(detail.key, obj)
+			if detail.queueEvents {
 				detail.Queue.Done(obj)
-			},
-			UpdateFunc: func(new, old interface{}) {
-				w.logger.Debug("watch update",
-					zap.String("obj", fmt.Sprintf("%v", new)),
-				)
-				detail.Queue.Add(new)
-			},
-		})
-	}
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.logger.Debug("watch update",
+				zap.Stringer("obj", objectRef{newObj}),
+			)
+			detail.recordEvent("update")
+			eventHandlers.update(detail.key, oldObj, newObj)
+			if detail.queueEvents {
+				detail.Queue.Add(newObj)
+			}
+		},
+	})
 
-	detail.informer.Informer().SetWatchErrorHandler(WatchErrorHandlerFactory(w.logger, detail.Key(), detail.StopCh))
+	// the handler closes its own channel on terminal errors, the StopCh is closed once through the detail
+	terminated := make(chan struct{})
+	errorHandler := WatchErrorHandlerFactory(w.logger, detail.WatchKey().String(), terminated)
+	detail.informer.Informer().SetWatchErrorHandler(func(r *kcache.Reflector, err error) {
+		errorHandler(r, err)
+		select {
+		case <-terminated:
+			detail.closeStopCh()
+		default:
+		}
+		terminal := detail.IsRunning() == 0
+		detail.status.recordError(err, detail.informer.Informer().LastSyncResourceVersion(), terminal)
+		if !terminal {
+			metrics.WatchRestarts.WithLabelValues(w.cluster, res.Key(), namespace).Inc()
+		}
+	})
 
+	// Stop does not see a watch created after it, the watch is not started when the Watcher was stopped
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("unable to create watch, watcher stopped")
+	}
+	w.details = append(w.details, detail)
+	appendResourceWatches(detail)
+	w.mu.Unlock()
+
+	detail.status.setState(WatchStarting)
 	go func() {
+		defer close(detail.done)
 		w.logger.Debug("starting informer",
-			zap.String("key", detail.Key()),
+			zap.String("key", detail.WatchKey().String()),
 		)
+		detail.status.setState(WatchSyncing)
 		detail.informer.Informer().Run(detail.StopCh)
 	}()
 
-	if err := appendResourceWatches(res.Key(), detail); err != nil {
-		return detail, err
-	}
-
 	return detail, nil
 }
 
-func appendResourceWatches(key string, detail *WatchDetail) error {
-	v, ok := ResourceWatches.Load(key)
-	if !ok {
-		detailMap := &sync.Map{}
-		detailMap.Store(detail.Key(), detail)
-		ResourceWatches.Store(key, detailMap)
-		return nil
+func (w *Watcher) isStopped() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stopped
+}
+
+// Stop stops the watches created by the Watcher, removes them from ResourceWatches and waits for their
+// Informer and Drain loops to exit. The returned error is a *errors.WatchNotStopped containing the keys
+// of the watches whose loops did not exit before the context was done. The Watcher does not create watches
+// after Stop.
+func (w *Watcher) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	details := w.details
+	w.details = nil
+	w.stopped = true
+	w.mu.Unlock()
+
+	for _, detail := range details {
+		detail.Stop()
+		if v, ok := ResourceWatches.Load(detail.key); ok && v == detail {
+			ResourceWatches.Delete(detail.key)
+		}
 	}
-	detailMap, ok := v.(*sync.Map)
-	if !ok {
-		return fmt.Errorf("append, found key: %s, unable to cast to []*WatchDetail", key)
+
+	notStopped := &errors.WatchNotStopped{}
+	for _, detail := range details {
+		if err := detail.Wait(ctx); err != nil {
+			notStopped.Keys = append(notStopped.Keys, detail.WatchKey().String())
+			notStopped.Err = ctx.Err()
+		}
+	}
+	if len(notStopped.Keys) > 0 {
+		sort.Strings(notStopped.Keys)
+		return notStopped
 	}
-	detailMap.Store(detail.Key(), detail)
-	ResourceWatches.Store(key, detailMap)
 	return nil
 }
 
+// WaitForSync waits for the running watches created by the Watcher to sync or for the context to be done.
+// The returned error is a *errors.WatchNotSynced containing the keys of the watches that did not sync.
+func (w *Watcher) WaitForSync(ctx context.Context) error {
+	w.mu.Lock()
+	details := w.details
+	w.mu.Unlock()
+
+	listers := []ResourceLister{}
+	for _, detail := range details {
+		if detail.IsRunning() == 1 {
+			listers = append(listers, detail)
+		}
+	}
+	return waitForSync(ctx, listers)
+}
+
+func appendResourceWatches(detail *WatchDetail) {
+	ResourceWatches.Store(detail.WatchKey(), detail)
+}
+
 // WatcherStop stops all running watchers.
 func WatcherStop() {
-	ResourceWatches.Range(func(k, v interface{}) bool {
-		value, ok := v.(*sync.Map)
-		if !ok {
-			return true
+	for _, detail := range watchDetails() {
+		detail.Stop()
+	}
+}
+
+// StopClusterWatches stops the watches of the named cluster and removes them from ResourceWatches.
+func StopClusterWatches(cluster string) {
+	for _, detail := range watchDetails() {
+		if detail.key.Cluster == cluster {
+			detail.Stop()
+			ResourceWatches.Delete(detail.key)
 		}
-		value.Range(func(k, v interface{}) bool {
-			detailValue, ok := v.(*WatchDetail)
-			if !ok {
-				return false
-			}
-			detailValue.Stop()
-			return true
-		})
-		return true
-	})
+	}
 }
 
-// WatchForResource returns a WatchDetail for the given Resource.
+// WatchForResource returns a ResourceLister for the given Resource from the unfiltered watches of the default cluster.
 func WatchForResource(r resource.Resource, namespaces ...string) (ResourceLister, error) {
-	v, ok := ResourceWatches.Load(r.Key())
-	if !ok {
-		return nil, fmt.Errorf("no watch found for resource: %+v", r)
-	}
+	return watchForResource("", "", r, namespaces...)
+}
 
-	detailMap, ok := v.(*sync.Map)
-	if !ok {
-		return nil, fmt.Errorf("watch, found key:%s, unable to cast to *sync.Map", r.Key())
-	}
+// WatchForClusterResource returns a ResourceLister for the given Resource from the unfiltered watches of the named cluster.
+func WatchForClusterResource(cluster string, r resource.Resource, namespaces ...string) (ResourceLister, error) {
+	return watchForResource(cluster, "", r, namespaces...)
+}
 
-	mapValues := []*WatchDetail{}
-	detailMap.Range(func(k, v interface{}) bool {
-		if v, ok := v.(*WatchDetail); ok {
-			mapValues = append(mapValues, v)
+// WatchForKind returns a ResourceLister from the unfiltered watches of the named cluster for the group and kind.
+func WatchForKind(cluster string, gk schema.GroupKind, namespaces ...string) (ResourceLister, error) {
+	for _, detail := range watchDetails() {
+		key := detail.WatchKey()
+		if key.Cluster == cluster && key.Selector == "" && detail.Resource.GroupVersionKind.GroupKind() == gk {
+			return watchForResource(cluster, "", detail.Resource, namespaces...)
 		}
-		return true
-	})
+	}
+	return nil, fmt.Errorf("no watch found for kind: %s", gk)
+}
 
-	wrappedWatches := []ResourceLister{}
-	if len(namespaces) == 0 { // no explict namespace, use all
-		logging.Logger.Info("no namespaces provided using NamespaceAll", zap.String("resource", r.Key()))
-		listers := []ResourceLister{}
-		for _, detail := range mapValues {
-			listers = append(listers, detail)
+func watchForResource(cluster, selector string, r resource.Resource, namespaces ...string) (ResourceLister, error) {
+	base := WatchKey{Cluster: cluster, GroupVersionResource: r.GroupVersionResource(), Selector: selector}
+	if len(namespaces) == 0 || containsNamespaceAll(namespaces) { // no explicit namespace or NamespaceAll, use all
+		details := matchingWatchDetails(base)
+		if len(details) == 0 {
+			return nil, fmt.Errorf("no watch found for resource: %+v", r)
 		}
-		wrappedWatches = listers
-	} else if len(namespaces) == 1 && namespaces[0] == metav1.NamespaceAll { // only one namespace and it is all, use all
-		logging.Logger.Info("only NamespaceAll in namespace list", zap.String("resource", r.Key()))
+		logger := details[0].logger()
+		logger.Debug("using all namespaces", zap.String("resource", r.Key()), zap.Strings("namespaces", namespaces))
 		listers := []ResourceLister{}
-		for _, detail := range mapValues {
+		for _, detail := range details {
 			listers = append(listers, detail)
 		}
-		wrappedWatches = listers
-	} else {
-		for _, ns := range namespaces {
-			if ns == metav1.NamespaceAll { // encountered NamespaceAll, use all
-				logging.Logger.Info("found NamespaceAll in namespace list", zap.String("resource", r.Key()), zap.String("namespace", ns))
-				listers := []ResourceLister{}
-				for _, detail := range mapValues {
-					listers = append(listers, detail)
-				}
-				wrappedWatches = listers
-				break
-			}
-			for _, wd := range mapValues {
-				if wd.Namespace() == metav1.NamespaceAll {
-					filterDetail := &FilteredWatchDetail{Detail: wd, namespace: ns}
-					wrappedWatches = append(wrappedWatches, filterDetail)
-					logging.Logger.Info("found NamespaceAll creating filtered watch detail", zap.String("resource", r.Key()), zap.String("namespace", ns))
-					continue
-				}
-
-				if wd.Namespace() == ns {
-					wrappedWatches = append(wrappedWatches, wd)
-					logging.Logger.Info("found watcher for namespace", zap.String("resource", r.Key()), zap.String("namespace", ns))
-					continue
-				}
-			}
+		return &WrappedWatchDetails{Listers: listers, Logger: logger}, nil
+	}
+
+	// the watch of the namespace is loaded by its key, the NamespaceAll watch is the fallback
+	var logger *zap.Logger
+	wrappedWatches := []ResourceLister{}
+	for _, ns := range namespaces {
+		key := base
+		key.Namespace = ns
+		if detail, ok := loadWatchDetail(key); ok {
+			logger = detail.logger()
+			wrappedWatches = append(wrappedWatches, detail)
+			logger.Debug("found watcher for namespace", zap.String("resource", r.Key()), zap.String("namespace", ns))
+			continue
+		}
+		if detail, ok := loadWatchDetail(base); ok {
+			logger = detail.logger()
+			wrappedWatches = append(wrappedWatches, &FilteredWatchDetail{Detail: detail, namespace: ns})
+			logger.Debug("found NamespaceAll creating filtered watch detail", zap.String("resource", r.Key()), zap.String("namespace", ns))
 		}
 	}
 
 	if len(wrappedWatches) == 0 {
+		if len(matchingWatchDetails(base)) == 0 {
+			return nil, fmt.Errorf("no watch found for resource: %+v", r)
+		}
 		return nil, fmt.Errorf("no matching watch found for resource: %s in namespaces: %+v", r.Key(), namespaces)
 	}
-	return &WrappedWatchDetails{Listers: wrappedWatches}, nil
+	return &WrappedWatchDetails{Listers: wrappedWatches, Logger: logger}, nil
 }
 
-// WatchList returns the current list of watchers from the cache.
-// If onlyRunning is true, the list will only include running watchers.
-func WatchList(onlyRunning bool) []ResourceLister {
-	watches := []ResourceLister{}
-	ResourceWatches.Range(func(k, v interface{}) bool {
-		value, ok := v.(*sync.Map)
-		if !ok {
-			return false
+// loadWatchDetail returns the WatchDetail stored under the key.
+func loadWatchDetail(key WatchKey) (*WatchDetail, bool) {
+	v, ok := ResourceWatches.Load(key)
+	if !ok {
+		return nil, false
+	}
+	detail, ok := v.(*WatchDetail)
+	return detail, ok
+}
+
+// matchingWatchDetails returns the WatchDetails of every namespace for the cluster, resource and selector
+// of the key, sorted by namespace.
+func matchingWatchDetails(key WatchKey) []*WatchDetail {
+	details := []*WatchDetail{}
+	for _, detail := range watchDetails() {
+		k := detail.WatchKey()
+		if k.Cluster == key.Cluster && k.GroupVersionResource == key.GroupVersionResource && k.Selector == key.Selector {
+			details = append(details, detail)
 		}
-		value.Range(func(k, v interface{}) bool {
-			detailValue, ok := v.(*WatchDetail)
-			if !ok {
-				return false
-			}
-			if onlyRunning && detailValue.IsRunning() == 0 {
-				return true
-			}
-			watches = append(watches, detailValue)
-			return true
-		})
-		return true
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].namespace < details[j].namespace
 	})
-	return watches
+	return details
 }
 
-// WatchCount returns the current count of watchers from the cache.
-// If onlyRunning is true, the count will only include running watchers.
-func WatchCount(onlyRunning bool) int {
-	count := 0
+func containsNamespaceAll(namespaces []string) bool {
+	for _, ns := range namespaces {
+		if ns == metav1.NamespaceAll {
+			return true
+		}
+	}
+	return false
+}
+
+// watchDetails returns every WatchDetail in the cache.
+func watchDetails() []*WatchDetail {
+	details := []*WatchDetail{}
 	ResourceWatches.Range(func(k, v interface{}) bool {
-		value, ok := v.(*sync.Map)
-		if !ok {
-			return false
+		if detail, ok := v.(*WatchDetail); ok {
+			details = append(details, detail)
 		}
-		value.Range(func(k, v interface{}) bool {
-			detailValue, ok := v.(*WatchDetail)
-			if !ok {
-				return false
-			}
-			if onlyRunning && detailValue.IsRunning() == 0 {
-				return true
-			}
-			count += 1
-			return true
-		})
 		return true
 	})
-	return count
+	return details
+}
+
+// IsKindWatched returns true when a running watch of the cluster exists for the group and kind.
+func IsKindWatched(cluster string, gk schema.GroupKind) bool {
+	for _, detail := range watchDetails() {
+		if detail.key.Cluster == cluster && detail.Resource.GroupVersionKind.GroupKind() == gk && detail.IsRunning() == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitForWatchesSync waits for every running watch in the cache to sync or for the context to be done.
+// The returned error is a *errors.WatchNotSynced containing the keys of the watches that did not sync.
+func WaitForWatchesSync(ctx context.Context) error {
+	listers := []ResourceLister{}
+	for _, detail := range watchDetails() {
+		if detail.IsRunning() == 1 {
+			listers = append(listers, detail)
+		}
+	}
+	return waitForSync(ctx, listers)
+}
+
+// objectRef logs the namespace/name of an object, it is only formatted when the entry is written.
+type objectRef struct {
+	obj interface{}
+}
+
+func (r objectRef) String() string {
+	key, err := kcache.DeletionHandlingMetaNamespaceKeyFunc(r.obj)
+	if err != nil {
+		return fmt.Sprintf("%T", r.obj)
+	}
+	return key
 }
 
 // WatchErrorHandlerFactory handles Reflector errors and ensures the Informer loop is shutdown when
@@ -4,16 +4,20 @@ import (
 	"context"
 	"io"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	rtesting "k8s.io/apimachinery/pkg/runtime/testing"
+	"k8s.io/client-go/dynamic"
 	kcache "k8s.io/client-go/tools/cache"
 
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
@@ -57,12 +61,14 @@ func TestResourceWatchesBadKey(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, w)
 
-	cache.ResourceWatches.Store("Version.Kind", "bad-string-should-be-map")
+	// values that are not a *WatchDetail are ignored
+	cache.ResourceWatches.Store("Version.Kind", "bad-string-should-be-watch-detail")
 	_, err = cache.WatchForResource(resource.Resource{GroupVersionKind: schema.GroupVersionKind{Version: "Version", Kind: "Kind"}})
-	assert.EqualError(t, err, "watch, found key:Version.Kind, unable to cast to *sync.Map")
+	assert.EqualError(t, err, "no watch found for resource: {GroupVersionKind:/Version, Kind=Kind APIResource:{Name: SingularName: Namespaced:false Group: Version: Kind: Verbs:[] ShortNames:[] Categories:[] StorageVersionHash:}}")
 
 	_, err = w.Watch(context.TODO(), "", resource.Resource{GroupVersionKind: schema.GroupVersionKind{Version: "Version", Kind: "Kind"}}, false)
-	assert.EqualError(t, err, "append, found key: Version.Kind, unable to cast to []*WatchDetail")
+	assert.Nil(t, err)
+	assert.Len(t, cache.WatchStatuses(), 1)
 }
 
 func TestResourceWatchesAddReuse(t *testing.T) {
@@ -92,7 +98,7 @@ func TestResourceWatchesAddReuse(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	assert.Equal(t, w1.Key(), w2.Key())
+	assert.Equal(t, w1.Keys(), w2.Keys())
 }
 
 func TestWatcherNamespaceAll(t *testing.T) {
@@ -153,6 +159,11 @@ func TestWatcherAppendMulti(t *testing.T) {
 	wd, err = w.Watch(context.TODO(), "different-ns", deploymentResource, false)
 	assert.Nil(t, err)
 	assert.NotNil(t, wd)
+
+	_, err = wd.GetNamespaced("default", "test-name")
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = wd.GetNamespaced("different-ns", "test-name")
+	assert.Nil(t, err)
 }
 
 func TestWatcherQueueEvents(t *testing.T) {
@@ -244,14 +255,14 @@ func TestWatcherHelpers(t *testing.T) {
 	}
 	assert.NotNil(t, v)
 
-	watchers := cache.WatchList(false)
-	assert.Len(t, watchers, 2)
-	assert.Equal(t, cache.WatchCount(false), 2)
+	statuses := cache.WatchStatuses()
+	assert.Len(t, statuses, 2)
 
 	podWatcher.Stop()
-	watchers = cache.WatchList(true)
-	assert.Len(t, watchers, 1)
-	assert.Equal(t, cache.WatchCount(true), 1)
+	statuses = cache.WatchStatuses(cache.ActiveWatchStates...)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "/apps/v1/deployments/default", statuses[0].Key.String())
+	assert.Len(t, cache.WatchStatuses(cache.WatchStopped), 1)
 }
 
 func TestWatchErrorHandlerFactory(t *testing.T) {
@@ -290,20 +301,34 @@ func TestWatchErrorHandlerFactory(t *testing.T) {
 func TestWatcherHelpersBad(t *testing.T) {
 	cache.ResourceWatches = &sync.Map{}
 	cache.ResourceWatches.Store("test", "test")
-	assert.Equal(t, 0, cache.WatchCount(false))
-	assert.Len(t, cache.WatchList(false), 0)
+	assert.Len(t, cache.WatchStatuses(), 0)
 	cache.WatcherStop()
 
 	badWatchMap := &sync.Map{}
 	badWatchMap.Store("key", "value")
 	cache.ResourceWatches.Store("test", badWatchMap)
 
-	assert.Equal(t, 0, cache.WatchCount(false))
-	assert.Len(t, cache.WatchList(false), 0)
+	assert.Len(t, cache.WatchStatuses(), 0)
 	cache.WatcherStop()
 	cache.ResourceWatches = &sync.Map{}
 }
 
+func TestStopClusterWatches(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	alpha, err := cache.LoadWatch(cache.WatchKey{Cluster: "alpha", GroupVersionResource: podResource.GroupVersionResource()}, podResource, nil)
+	assert.Nil(t, err)
+	_, err = cache.LoadWatch(cache.WatchKey{Cluster: "beta", GroupVersionResource: podResource.GroupVersionResource()}, podResource, nil)
+	assert.Nil(t, err)
+
+	cache.StopClusterWatches("alpha")
+	assert.Equal(t, 0, alpha.IsRunning())
+	_, err = cache.WatchForClusterResource("alpha", podResource)
+	assert.NotNil(t, err)
+	_, err = cache.WatchForClusterResource("beta", podResource)
+	assert.Nil(t, err)
+}
+
 var deploymentResource = resource.Resource{
 	GroupVersionKind: schema.GroupVersionKind{Version: "v1", Group: "apps", Kind: "Deployment"},
 	APIResource: metav1.APIResource{
@@ -329,3 +354,112 @@ var podResource = resource.Resource{
 		Verbs:        metav1.Verbs{"get", "list", "watch", "delete", "create"},
 	},
 }
+
+// countingDynamic counts the requests for resources made through the dynamic client.
+type countingDynamic struct {
+	dynamic.Interface
+	requests int32
+}
+
+func (d *countingDynamic) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	atomic.AddInt32(&d.requests, 1)
+	return d.Interface.Resource(gvr)
+}
+
+func TestWatcherUpdateDynamicClient(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	defer func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	assert.Nil(t, cluster.Create(context.TODO(), newUnstructured("v1", "Pod", "default", "nginx", "1", "")))
+
+	old := &countingDynamic{Interface: cluster.Dynamic()}
+	w, err := cache.NewWatcher(context.TODO(), cache.WithDynamicClient(old), cache.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+	lister, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, lister.WaitForSync(ctx))
+
+	rotated := &countingDynamic{Interface: cluster.Dynamic()}
+	w.UpdateDynamicClient(rotated)
+
+	// the cached objects are kept and the informer reconnects with the new client
+	objs, err := lister.List(labels.Everything())
+	assert.Nil(t, err)
+	assert.Len(t, objs, 1)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&rotated.requests) > 0 }, 5*time.Second, 10*time.Millisecond)
+	requests := atomic.LoadInt32(&old.requests)
+
+	assert.Nil(t, cluster.Create(context.TODO(), newUnstructured("v1", "Pod", "default", "redis", "2", "")))
+	assert.Eventually(t, func() bool {
+		objs, err := lister.List(labels.Everything())
+		return err == nil && len(objs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, requests, atomic.LoadInt32(&old.requests))
+
+	// the rotated watcher is still the watcher of the resource
+	again, err := w.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	assert.Len(t, again.(*cache.WrappedWatchDetails).Listers, 1)
+	assert.Equal(t, 1, len(cache.WatchStatuses()))
+}
+
+func TestWatcherStop(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	defer func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	stopping, err := cache.NewWatcher(context.TODO(), cache.WithDynamicClient(cluster.Dynamic()), cache.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+	running, err := cache.NewWatcher(context.TODO(), cache.WithDynamicClient(cluster.Dynamic()), cache.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+
+	stopped, err := stopping.Watch(context.TODO(), "default", podResource, true)
+	assert.Nil(t, err)
+	stopped.Drain(make(chan interface{}), make(chan struct{}))
+	kept, err := running.Watch(context.TODO(), "test", podResource, false)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, stopping.Stop(ctx))
+	assert.Equal(t, 0, stopped.IsRunning())
+	assert.Nil(t, stopped.(*cache.WatchDetail).Wait(ctx))
+	_, err = cache.WatchForResource(podResource, "default")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, kept.IsRunning())
+
+	// a stopped Watcher does not create watches
+	_, err = stopping.Watch(context.TODO(), "default", podResource, false)
+	assert.EqualError(t, err, "unable to create watch, watcher stopped")
+	_, err = cache.WatchForResource(podResource, "default")
+	assert.NotNil(t, err)
+}
+
+func TestWatcherLogsCreatedWatches(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	defer func() { cache.ResourceWatches = &sync.Map{} }()
+
+	core, logs := observer.New(zap.DebugLevel)
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(ctesting.FakeDynamicClient{}),
+		cache.WithDynamicSharedInformerFactory(wtesting.NewFakeDynamicSharedInformerFactory()),
+		cache.WithLogger(zap.New(core)),
+	)
+	assert.Nil(t, err)
+
+	// the existing watch is returned without creating another one
+	for i := 0; i < 2; i++ {
+		_, err = w.Watch(context.TODO(), "default", podResource, false)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, logs.FilterMessage("creating watch").Len())
+}
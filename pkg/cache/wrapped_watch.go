@@ -1,26 +1,35 @@
 package cache
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
-	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // WrappedWatchDetails combines multiple WatchDetail in to a single call
 // to allow for simple multi-namespace List and Get calls.
-// As a result of this, the other ResourceLister calls have been encoded to
-// be multi-value as a comma seprated string. The responsiblilty of parsing these
-// values is the callers.
+// Use Keys and Namespaces to get the values for each of the wrapped ResourceListers.
 type WrappedWatchDetails struct {
 	Listers []ResourceLister
+	// Logger logs the ResourceListers that failed to list, no-op when nil.
+	Logger *zap.Logger
 }
 
 var _ ResourceLister = (*WrappedWatchDetails)(nil)
 
+// Key returns the keys of the wrapped ResourceListers as a comma separated string.
+//
+// Deprecated: use Keys.
 func (w *WrappedWatchDetails) Key() string {
 	keys := []string{}
 	for _, detail := range w.Listers {
@@ -29,6 +38,27 @@ func (w *WrappedWatchDetails) Key() string {
 	return strings.Join(uniqueStringSlice(keys), ",")
 }
 
+// Keys returns the unique WatchKeys of the wrapped ResourceListers.
+func (w *WrappedWatchDetails) Keys() []WatchKey {
+	keys := []WatchKey{}
+	for _, detail := range w.Listers {
+		keys = append(keys, detail.Keys()...)
+	}
+	return uniqueWatchKeys(keys)
+}
+
+// Namespaces returns the unique namespaces of the wrapped ResourceListers.
+func (w *WrappedWatchDetails) Namespaces() []string {
+	namespaces := []string{}
+	for _, detail := range w.Listers {
+		namespaces = append(namespaces, detail.Namespaces()...)
+	}
+	return uniqueStringSlice(namespaces)
+}
+
+// Namespace returns the namespaces of the wrapped ResourceListers as a comma separated string.
+//
+// Deprecated: use Namespaces.
 func (w *WrappedWatchDetails) Namespace() string {
 	namespaces := []string{}
 	for _, detail := range w.Listers {
@@ -37,31 +67,43 @@ func (w *WrappedWatchDetails) Namespace() string {
 	return strings.Join(uniqueStringSlice(namespaces), ",")
 }
 
+// List returns the objects from all of the wrapped ResourceListers. When some of the ResourceListers
+// fail, the objects from the others are returned along with an *errors.ListerErrors.
 func (w *WrappedWatchDetails) List(selector labels.Selector) ([]runtime.Object, error) {
-	errors := []string{}
+	listErrs := &errors.ListerErrors{}
 	objects := []runtime.Object{}
 	for _, detail := range w.Listers {
 		listObjects, err := detail.List(selector)
 		if err != nil {
-			logging.Logger.Error("failed to list",
+			w.logger().Error("failed to list",
 				zap.String("resource", detail.Key()),
 				zap.String("namespace", detail.Namespace()),
 				zap.Error(err),
 			)
-			errors = append(errors, err.Error())
+			listErrs.Errs = append(listErrs.Errs, &errors.ListerError{Key: detail.Key(), Namespace: detail.Namespace(), Err: err})
 			continue
 		}
 		objects = append(objects, listObjects...)
 	}
-	if len(errors) == 0 {
+	if len(listErrs.Errs) == 0 {
 		return objects, nil
 	}
-	return objects, fmt.Errorf(strings.Join(errors, ","))
+	return objects, listErrs
+}
+
+func (w *WrappedWatchDetails) logger() *zap.Logger {
+	if w.Logger == nil {
+		return zap.NewNop()
+	}
+	return w.Logger
 }
 
+// Get returns the object with the given name. If objects with the name are found in more than
+// one namespace an *errors.AmbiguousObject is returned, use GetNamespaced to select one.
 func (w *WrappedWatchDetails) Get(name string) (runtime.Object, error) {
 	var object runtime.Object
 	namespaces := []string{}
+	found := []string{}
 	for _, detail := range w.Listers {
 		getObj, err := detail.Get(name)
 		if err != nil {
@@ -69,13 +111,48 @@ func (w *WrappedWatchDetails) Get(name string) (runtime.Object, error) {
 			continue
 		}
 		object = getObj
+		found = append(found, objectNamespace(getObj, detail.Namespace()))
 	}
 	if object == nil {
 		return nil, fmt.Errorf("unable to find object %s in any namespace of: %+v", name, namespaces)
 	}
+	if found = uniqueStringSlice(found); len(found) > 1 {
+		sort.Strings(found)
+		return nil, &errors.AmbiguousObject{Name: name, Namespaces: found}
+	}
 	return object, nil
 }
 
+// GetNamespaced returns the object with the given namespace and name from the first wrapped
+// ResourceLister that covers the namespace.
+func (w *WrappedWatchDetails) GetNamespaced(namespace, name string) (runtime.Object, error) {
+	var lastErr error
+	for _, detail := range w.Listers {
+		if detail.Namespace() != metav1.NamespaceAll && detail.Namespace() != namespace {
+			continue
+		}
+		obj, err := detail.GetNamespaced(namespace, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return obj, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("unable to find object %s, namespace %s is not watched", name, namespace)
+}
+
+// objectNamespace returns the namespace of the object, or fallback if it can not be determined.
+func objectNamespace(obj runtime.Object, fallback string) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return fallback
+	}
+	return accessor.GetNamespace()
+}
+
 // Stop closes the StopCh shutting down the Drain and Informer loops.
 func (w *WrappedWatchDetails) Stop() {
 	for _, detail := range w.Listers {
@@ -97,6 +174,78 @@ func (w *WrappedWatchDetails) IsRunning() int {
 	}
 	return count
 }
+
+// Statuses returns the WatchStatus for each of the wrapped ResourceListers.
+func (w *WrappedWatchDetails) Statuses() []WatchStatus {
+	statuses := []WatchStatus{}
+	for _, detail := range w.Listers {
+		statuses = append(statuses, detail.Statuses()...)
+	}
+	return statuses
+}
+
+// HasSynced returns true when all of the wrapped ResourceListers have synced.
+func (w *WrappedWatchDetails) HasSynced() bool {
+	for _, detail := range w.Listers {
+		if !detail.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForSync waits for all of the wrapped ResourceListers to sync. The returned error
+// contains the keys of every ResourceLister that did not sync.
+func (w *WrappedWatchDetails) WaitForSync(ctx context.Context) error {
+	return waitForSync(ctx, w.Listers)
+}
+
+func waitForSync(ctx context.Context, listers []ResourceLister) error {
+	var (
+		mu       sync.Mutex
+		group    sync.WaitGroup
+		keys     []string
+		firstErr error
+	)
+
+	for _, detail := range listers {
+		group.Add(1)
+
+		d := detail
+		go func() {
+			defer group.Done()
+
+			err := d.WaitForSync(ctx)
+			if err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			notSynced := &errors.WatchNotSynced{}
+			if stderrors.As(err, &notSynced) {
+				keys = append(keys, notSynced.Keys...)
+				err = notSynced.Err
+			} else {
+				for _, key := range d.Keys() {
+					keys = append(keys, key.String())
+				}
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+
+	group.Wait()
+
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	return &errors.WatchNotSynced{Keys: uniqueStringSlice(keys), Err: firstErr}
+}
+
 func uniqueStringSlice(nsSlice []string) []string {
 	keys := make(map[string]bool)
 	list := []string{}
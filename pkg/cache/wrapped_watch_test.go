@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
@@ -19,6 +20,7 @@ import (
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
 	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
 )
 
 func TestWrappedWatchDetails(t *testing.T) {
@@ -33,12 +35,11 @@ func TestWrappedWatchDetails(t *testing.T) {
 		}
 		return nil
 	})))
-	logging.Logger = logger
 
 	w, err := cache.NewWatcher(context.TODO(),
 		cache.WithDynamicClient(&dynFake),
 		cache.WithDynamicSharedInformerFactory(dsifFake),
-		cache.WithLogger(zap.NewNop()),
+		cache.WithLogger(logger),
 	)
 	assert.Nil(t, err)
 
@@ -52,7 +53,7 @@ func TestWrappedWatchDetails(t *testing.T) {
 		t.Fatalf(err.Error())
 	}
 
-	wrapped := &cache.WrappedWatchDetails{Listers: []cache.ResourceLister{podWd, deployWd}}
+	wrapped := &cache.WrappedWatchDetails{Listers: []cache.ResourceLister{podWd, deployWd}, Logger: logger}
 	dsifFake.GenericInformer.GenericLister.NamespaceLister.Objects = []runtime.Object{nil}
 	dsifFake.GenericInformer.GenericLister.NamespaceLister.Object = &rtesting.MockCacheableObject{}
 
@@ -68,14 +69,32 @@ func TestWrappedWatchDetails(t *testing.T) {
 	}
 	assert.Len(t, objs, 2)
 
+	// the same name exists in both namespaces
 	_, err = wrapped.Get("test-obj")
+	assert.EqualError(t, err, "AmbiguousObject - name:test-obj, namespaces:[default different-ns]")
+
+	obj, err := wrapped.GetNamespaced("default", "test-obj")
+	assert.Nil(t, err)
+	assert.NotNil(t, obj)
+
+	_, err = wrapped.GetNamespaced("not-watched", "test-obj")
+	assert.EqualError(t, err, "unable to find object test-obj, namespace not-watched is not watched")
+
+	single := &cache.WrappedWatchDetails{Listers: []cache.ResourceLister{podWd}}
+	obj, err = single.Get("test-obj")
 	assert.Nil(t, err)
+	assert.NotNil(t, obj)
 
 	dsifFake.GenericInformer.GenericLister.NamespaceLister.ListErr = fmt.Errorf("test lister error")
 	dsifFake.GenericInformer.GenericLister.NamespaceLister.GetErr = fmt.Errorf("test get error")
 	objs, err = wrapped.List(labels.Everything())
 	assert.EqualError(t, err, "test lister error,test lister error")
 	assert.Len(t, objs, 0)
+	listErrs := &errors.ListerErrors{}
+	assert.ErrorAs(t, err, &listErrs)
+	assert.Len(t, listErrs.Errs, 2)
+	assert.Equal(t, "default.v1.Pod", listErrs.Errs[0].Key)
+	assert.Equal(t, "different-ns", listErrs.Errs[1].Namespace)
 	// set true by zap.Logger
 	assert.True(t, listErr)
 
@@ -87,8 +106,6 @@ func TestWrappedWatchDetails(t *testing.T) {
 	wrapped.Stop()
 	assert.Equal(t, podWd.IsRunning(), 0)
 	assert.Equal(t, deployWd.IsRunning(), 0)
-
-	logging.Logger, _ = zap.NewProduction()
 }
 
 func TestWrappedWatchDrainStopMain(t *testing.T) {
@@ -123,3 +140,52 @@ func TestWrappedWatchDrainStopMain(t *testing.T) {
 	wrapped.Stop()
 	wrapped.Drain(eventCh, stopCh)
 }
+
+func TestWrappedWatchWaitForSync(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	syncedFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	unsyncedFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	unsyncedFake.GenericInformer.SharedIndexInformer.HasSyncedFn = func() bool { return false }
+	dynFake := ctesting.FakeDynamicClient{}
+
+	synced, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(&dynFake),
+		cache.WithDynamicSharedInformerFactory(syncedFake),
+	)
+	assert.Nil(t, err)
+	unsynced, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(&dynFake),
+		cache.WithDynamicSharedInformerFactory(unsyncedFake),
+	)
+	assert.Nil(t, err)
+
+	podWd, err := synced.Watch(context.TODO(), "default", podResource, false)
+	assert.Nil(t, err)
+	deployWd, err := unsynced.Watch(context.TODO(), "default", deploymentResource, false)
+	assert.Nil(t, err)
+
+	assert.True(t, podWd.HasSynced())
+	assert.Nil(t, podWd.WaitForSync(context.TODO()))
+
+	wrapped := &cache.WrappedWatchDetails{Listers: []cache.ResourceLister{podWd, deployWd}}
+	assert.False(t, wrapped.HasSynced())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	err = wrapped.WaitForSync(ctx)
+	notSynced := &errors.WatchNotSynced{}
+	assert.ErrorAs(t, err, &notSynced)
+	assert.Equal(t, []string{"/apps/v1/deployments/default"}, notSynced.Keys)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = cache.WaitForWatchesSync(ctx)
+	assert.ErrorAs(t, err, &notSynced)
+	assert.Equal(t, []string{"/apps/v1/deployments/default"}, notSynced.Keys)
+
+	deployWd.Stop()
+	err = deployWd.WaitForSync(context.TODO())
+	assert.EqualError(t, err, "WatchNotSynced - keys:[/apps/v1/deployments/default], watch stopped")
+	assert.Nil(t, cache.WaitForWatchesSync(context.TODO()))
+	cache.ResourceWatches = &sync.Map{}
+}
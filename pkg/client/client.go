@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -14,7 +15,8 @@ import (
 
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
-	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 )
 
 type ModeType uint
@@ -30,6 +32,15 @@ type Client struct {
 	SkipSubjectAccessChecks bool
 	RESTConfig              *rest.Config
 	Logger                  *zap.Logger
+	MetricsRegisterer       prometheus.Registerer
+	Throttle                *resource.Throttle
+	AccessConcurrency       int
+
+	// Kubeconfig and KubeContext are the kubeconfig files and context the client was loaded from, see
+	// NewClientFromKubeconfig and SwitchContext.
+	Kubeconfig     string
+	KubeContext    string
+	fromKubeconfig bool
 
 	watcher   *cache.Watcher
 	WatcherFn func(context.Context, *zap.Logger, dynamic.Interface) (*cache.Watcher, error)
@@ -46,17 +57,23 @@ type Client struct {
 	SubjectAccessFn func(context.Context, kubernetes.Interface) (typedAuthv1.SelfSubjectAccessReviewInterface, error)
 	subjectAccess   typedAuthv1.SelfSubjectAccessReviewInterface
 
+	// refreshers, handlers and the watches of the watcher are stopped by Close
+	refreshCtx    context.Context
+	cancelRefresh context.CancelFunc
+	refreshers    map[string]chan struct{}
+	handlers      []func()
+	closed        bool
+
 	mu sync.Mutex
 }
 
 func NewClient(ctx context.Context, options ...ClientOption) (*Client, error) {
-	defer logging.Logger.Sync()
-
 	c := &Client{
 		ResourceMode:            Auto,
 		NamespaceMode:           Auto,
 		SkipSubjectAccessChecks: false,
-		Logger:                  logging.Logger,
+		AccessConcurrency:       resource.DefaultAccessConcurrency,
+		Logger:                  zap.NewNop(),
 		WatcherFn:               NewWatcher,
 		ClientsetFn:             NewClientset,
 		DynamicClientFn:         NewDynamicClient,
@@ -68,6 +85,12 @@ func NewClient(ctx context.Context, options ...ClientOption) (*Client, error) {
 		opt(c)
 	}
 
+	if c.MetricsRegisterer != nil {
+		if err := metrics.Register(c.MetricsRegisterer); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := c.UpdateRESTConfig(ctx, c.RESTConfig); err != nil {
 		return nil, err
 	}
@@ -75,59 +98,93 @@ func NewClient(ctx context.Context, options ...ClientOption) (*Client, error) {
 	return c, nil
 }
 
+// UpdateRESTConfig rebuilds the clients of the Client from the config. The first call creates the watcher
+// with WatcherFn, later calls move the running watches to the new dynamic client, see RefreshCredentials.
 func (c *Client) UpdateRESTConfig(ctx context.Context, config *rest.Config) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if err := CheckRestConfig(ctx, config, c.Logger); err != nil {
+	clients, err := c.newClients(ctx, config)
+	if err != nil {
 		return err
 	}
 	c.RESTConfig = config
+	c.setClients(clients)
 
-	clientset, err := c.ClientsetFn(ctx, c.RESTConfig)
+	if c.watcher != nil {
+		c.watcher.UpdateDynamicClient(c.dynamic)
+		return nil
+	}
+	watcher, err := c.WatcherFn(ctx, c.Logger, c.dynamic)
 	if err != nil {
 		return err
 	}
-	c.clientset = clientset
+	c.watcher = watcher
+	return nil
+}
 
-	dynclient, err := c.DynamicClientFn(ctx, c.RESTConfig)
-	if err != nil {
-		return err
+// clients are the clients of a Client built from its rest.Config.
+type clients struct {
+	clientset       kubernetes.Interface
+	dynamic         dynamic.Interface
+	serverResources discovery.ServerResourcesInterface
+	subjectAccess   typedAuthv1.SelfSubjectAccessReviewInterface
+}
+
+// newClients builds the clients for the config with the Fn fields of the Client without changing it.
+func (c *Client) newClients(ctx context.Context, config *rest.Config) (clients, error) {
+	// when a Throttle is configured it governs the request rate, so the QPS and Burst recommendations do not apply
+	if c.Throttle != nil {
+		if config == nil {
+			return clients{}, &errors.NilRESTConfig{}
+		}
+	} else if err := CheckRestConfig(ctx, config, c.Logger); err != nil {
+		return clients{}, err
 	}
-	c.dynamic = dynclient
 
-	serverResources, err := c.ServerResourcesFn(ctx, c.clientset)
+	clientset, err := c.ClientsetFn(ctx, config)
 	if err != nil {
-		return err
+		return clients{}, err
 	}
-	c.serverResources = serverResources
-
-	subjectAccess, err := c.SubjectAccessFn(ctx, c.clientset)
+	dynclient, err := c.DynamicClientFn(ctx, config)
 	if err != nil {
-		return err
+		return clients{}, err
 	}
-	c.subjectAccess = subjectAccess
-
-	watcher, err := c.WatcherFn(ctx, c.Logger, c.dynamic)
+	serverResources, err := c.ServerResourcesFn(ctx, clientset)
 	if err != nil {
-		return err
+		return clients{}, err
 	}
-	c.watcher = watcher
-	return nil
+	subjectAccess, err := c.SubjectAccessFn(ctx, clientset)
+	if err != nil {
+		return clients{}, err
+	}
+	return clients{
+		clientset:       clientset,
+		dynamic:         dynclient,
+		serverResources: serverResources,
+		subjectAccess:   subjectAccess,
+	}, nil
+}
+
+func (c *Client) setClients(clients clients) {
+	c.clientset = clients.clientset
+	c.dynamic = clients.dynamic
+	c.serverResources = clients.serverResources
+	c.subjectAccess = clients.subjectAccess
 }
 
 func CheckRestConfig(ctx context.Context, config *rest.Config, logger *zap.Logger) error {
 	if config == nil {
 		return &errors.NilRESTConfig{}
 	} else {
-		if config.QPS < 400 {
+		if config.QPS < RecommendedQPS {
 			logger.Warn("rest.Config QPS below 400",
 				// key-value pairs
 				zap.String("recommended", ">=400"),
 				zap.Float32("qps", config.QPS),
 			)
 		}
-		if config.Burst < 800 {
+		if config.Burst < RecommendedBurst {
 			logger.Warn("rest.Config Burst below 800",
 				// key-value pairs
 				zap.String("recommended", ">=800"),
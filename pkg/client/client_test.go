@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
@@ -20,6 +21,8 @@ import (
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	"github.com/wwitzel3/k8s-resource-client/pkg/client"
 	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 )
 
 var config = &rest.Config{QPS: 400, Burst: 800}
@@ -100,6 +103,31 @@ func TestNewClientRestConfigWarnings(t *testing.T) {
 	assert.True(t, qpsWarning)
 	assert.True(t, burstWarning)
 }
+func TestNewClientThrottleSkipsRestConfigWarnings(t *testing.T) {
+	warnings := false
+	logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.Hooks(func(e zapcore.Entry) error {
+		if e.Level == zap.WarnLevel {
+			warnings = true
+		}
+		return nil
+	})))
+
+	throttle := resource.NewThrottle(20, 40)
+	c, err := client.NewClient(context.TODO(),
+		client.WithRESTConfig(&rest.Config{QPS: 50, Burst: 100}),
+		client.WithLogger(logger),
+		client.WithThrottle(throttle),
+		client.WithAccessConcurrency(5),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, throttle, c.Throttle)
+	assert.Equal(t, 5, c.AccessConcurrency)
+	assert.False(t, warnings)
+
+	_, err = client.NewClient(context.TODO(), client.WithThrottle(throttle))
+	assert.ErrorIs(t, err, &errors.NilRESTConfig{})
+}
+
 func TestNewClient(t *testing.T) {
 	c, err := client.NewClient(context.TODO(), client.WithRESTConfig(config))
 	if err != nil {
@@ -154,6 +182,16 @@ func TestNewClientOptions(t *testing.T) {
 	assert.Equal(t, c.SkipSubjectAccessChecks, true)
 }
 
+func TestNewClientMetricsRegisterer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	c, err := client.NewClient(context.TODO(), client.WithRESTConfig(config), client.WithMetricsRegisterer(registry))
+	assert.Nil(t, err)
+	assert.Equal(t, registry, c.MetricsRegisterer)
+
+	err = registry.Register(metrics.DiscoveryDuration)
+	assert.IsType(t, prometheus.AlreadyRegisteredError{}, err)
+}
+
 func TestNewClientset(t *testing.T) {
 	config := &rest.Config{
 		RateLimiter: nil,
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+// RefreshFunc refreshes state of the client, such as RefreshAccess, AutoDiscoverResources or
+// AutoDiscoverNamespaces.
+type RefreshFunc func(context.Context, *Client) error
+
+// StartRefresh calls fn every interval until the client is closed. Errors returned by fn are logged.
+// The name identifies the refresher, only one refresher with a name can run at once.
+func (c *Client) StartRefresh(name string, interval time.Duration, fn RefreshFunc) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return fmt.Errorf("client closed")
+	}
+	if _, ok := c.refreshers[name]; ok {
+		return fmt.Errorf("refresher %s already running", name)
+	}
+	if c.refreshers == nil {
+		c.refreshers = map[string]chan struct{}{}
+		c.refreshCtx, c.cancelRefresh = context.WithCancel(context.Background())
+	}
+
+	done := make(chan struct{})
+	c.refreshers[name] = done
+	go func(ctx context.Context) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx, c); err != nil && ctx.Err() == nil {
+					c.Logger.Warn("refresh failed", zap.String("name", name), zap.Error(err))
+				}
+			}
+		}
+	}(c.refreshCtx)
+	return nil
+}
+
+// AddEventHandler registers the handler with cache.AddEventHandler until the client is closed.
+// The returned function removes the handler earlier.
+func (c *Client) AddEventHandler(handler cache.EventHandler) (func(), error) {
+	// the handler is registered without holding the lock, it is called for the existing objects
+	remove := cache.AddEventHandler(handler)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		remove()
+		return nil, fmt.Errorf("client closed")
+	}
+	c.handlers = append(c.handlers, remove)
+	return remove, nil
+}
+
+// Close stops the refreshers, the event handlers and the watches of the client, the watches are removed
+// from cache.ResourceWatches. It waits for the refreshers and the Informer and Drain loops of the watches
+// to exit until the context is done, those that did not are returned in an *errors.ClientNotClosed.
+// Watches of other clients keep running. The client must not be used after Close.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	if c.cancelRefresh != nil {
+		c.cancelRefresh()
+	}
+	refreshers, handlers, watcher := c.refreshers, c.handlers, c.watcher
+	c.handlers = nil
+	c.mu.Unlock()
+
+	// removing a handler a second time is a no-op
+	for _, remove := range handlers {
+		remove()
+	}
+
+	notClosed := &errors.ClientNotClosed{}
+	if watcher != nil {
+		if err := stopWatcher(ctx, watcher); err != nil {
+			stderrors.As(err, &notClosed)
+		}
+	}
+
+	names := make([]string, 0, len(refreshers))
+	for name := range refreshers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		select {
+		case <-refreshers[name]:
+		case <-ctx.Done():
+			notClosed.Refreshers = append(notClosed.Refreshers, name)
+			notClosed.Err = ctx.Err()
+		}
+	}
+
+	if len(notClosed.Refreshers) > 0 || len(notClosed.Watches) > 0 {
+		return notClosed
+	}
+	return nil
+}
+
+// stopWatcher stops the watches of the watcher and waits for them to exit until the context is done, the
+// watches that did not are returned in an *errors.ClientNotClosed.
+func stopWatcher(ctx context.Context, watcher *cache.Watcher) error {
+	notStopped := &errors.WatchNotStopped{}
+	if err := watcher.Stop(ctx); stderrors.As(err, &notStopped) {
+		return &errors.ClientNotClosed{Watches: notStopped.Keys, Err: notStopped.Err}
+	}
+	return nil
+}
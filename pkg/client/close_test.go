@@ -0,0 +1,110 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+func TestClose(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	defer func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	assert.Nil(t, cluster.Create(context.TODO(), wtesting.NewPod("default", "nginx"), wtesting.NewPod("test", "redis")))
+	pods, _ := cluster.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+
+	closing, err := cluster.NewClient(context.TODO(), client.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+	other, err := cluster.NewClient(context.TODO(), client.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+
+	// nothing reads the drained events, Close releases the Drain loop
+	listers, err := client.WatchResource(context.TODO(), closing, pods, true, []string{"default"})
+	assert.Nil(t, err)
+	listers[0].Drain(make(chan interface{}), make(chan struct{}))
+	others, err := client.WatchResource(context.TODO(), other, pods, false, []string{"test"})
+	assert.Nil(t, err)
+	assert.Nil(t, client.WaitForWatchesSync(context.TODO(), closing, 5*time.Second))
+
+	var refreshes int32
+	assert.Nil(t, closing.StartRefresh("count", 5*time.Millisecond, func(context.Context, *client.Client) error {
+		atomic.AddInt32(&refreshes, 1)
+		return nil
+	}))
+	assert.NotNil(t, closing.StartRefresh("count", time.Second, client.RefreshAccess))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&refreshes) > 0 }, 5*time.Second, 5*time.Millisecond)
+
+	var adds int32
+	_, err = closing.AddEventHandler(cache.EventHandlerFuncs{AddFunc: func(cache.WatchKey, runtime.Object) {
+		atomic.AddInt32(&adds, 1)
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&adds))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, closing.Close(ctx))
+	assert.Nil(t, closing.Close(ctx))
+
+	// only the watches of the closed client are stopped and removed
+	assert.Equal(t, 0, listers[0].IsRunning())
+	_, err = cache.WatchForResource(pods, "default")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, others[0].IsRunning())
+
+	stopped := atomic.LoadInt32(&refreshes)
+	assert.Nil(t, cluster.Create(context.TODO(), wtesting.NewPod("test", "nginx")))
+	assert.Eventually(t, func() bool {
+		objs, err := others[0].List(labels.Everything())
+		return err == nil && len(objs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&adds))
+	assert.Equal(t, stopped, atomic.LoadInt32(&refreshes))
+
+	assert.NotNil(t, closing.StartRefresh("access", time.Second, client.RefreshAccess))
+	_, err = closing.AddEventHandler(cache.EventHandlerFuncs{})
+	assert.NotNil(t, err)
+	_, err = client.WatchResource(context.TODO(), closing, pods, false, []string{"default"})
+	assert.EqualError(t, err, "client closed")
+}
+
+func TestCloseDeadline(t *testing.T) {
+	c, err := ctesting.NewFakeCluster().NewClient(context.TODO(), client.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	var once sync.Once
+	assert.Nil(t, c.StartRefresh("stuck", time.Millisecond, func(context.Context, *client.Client) error {
+		once.Do(func() { close(started) })
+		<-release
+		return nil
+	}))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	err = c.Close(ctx)
+	notClosed := &errors.ClientNotClosed{}
+	assert.ErrorAs(t, err, &notClosed)
+	assert.Equal(t, []string{"stuck"}, notClosed.Refreshers)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
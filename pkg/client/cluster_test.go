@@ -0,0 +1,121 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+)
+
+func TestFakeClusterDiscovery(t *testing.T) {
+	cache.Resources = cache.NewResourceCache()
+	cache.Namespaces = []string{}
+	defer func() {
+		cache.Resources = cache.NewResourceCache()
+		cache.Namespaces = []string{}
+		cache.Access = nil
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	widget := cluster.AddCRD("example.com", "v1alpha1", "Widget", "widgets", true)
+	assert.Nil(t, cluster.AddNamespaces(context.TODO(), "default", "test"))
+	cluster.Allow(ctesting.AccessRule{
+		Namespaces: []string{"default"},
+		APIGroups:  []string{""},
+		Resources:  []string{"pods"},
+		Verbs:      []string{"list", "watch"},
+	})
+
+	c, err := cluster.NewClient(context.TODO(), client.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.AutoDiscoverResources(context.TODO(), c))
+	assert.Contains(t, cache.Resources.Get("namespace"), widget)
+
+	assert.Nil(t, client.AutoDiscoverNamespaces(context.TODO(), c))
+	assert.Equal(t, []string{"default", "test"}, cache.Namespaces)
+
+	pods, ok := cluster.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+	assert.True(t, ok)
+	assert.Nil(t, client.AutoDiscoverAccess(context.TODO(), c, "default", pods, widget))
+	assert.True(t, cache.Access.AllowedAll("default", pods, client.AutoAccessVerbs))
+	assert.False(t, cache.Access.Allowed("default", widget, "list"))
+	assert.Len(t, cluster.AccessReviews(), 4)
+
+	// partial discovery failures still return the served resources
+	cache.Resources = cache.NewResourceCache()
+	cluster.DiscoveryErr = fmt.Errorf("unavailable")
+	assert.Nil(t, client.AutoDiscoverResources(context.TODO(), c))
+	assert.Contains(t, cache.Resources.Get("namespace"), widget)
+}
+
+func TestFakeClusterWatch(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	cluster := ctesting.NewFakeCluster()
+	assert.Nil(t, cluster.AddNamespaces(context.TODO(), "default"))
+	assert.Nil(t, cluster.Create(context.TODO(), wtesting.NewPod("default", "nginx")))
+
+	c, err := cluster.NewClient(context.TODO(), client.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+
+	pods, _ := cluster.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+	listers, err := client.WatchResource(context.TODO(), c, pods, false, []string{"default"})
+	assert.Nil(t, err)
+	defer listers[0].Stop()
+	assert.Nil(t, client.WaitForWatchesSync(context.TODO(), c, 5*time.Second))
+
+	names := func() []string {
+		objs, err := listers[0].List(labels.Everything())
+		assert.Nil(t, err)
+		names := []string{}
+		for _, obj := range objs {
+			names = append(names, obj.(*unstructured.Unstructured).GetName())
+		}
+		return names
+	}
+	assert.Equal(t, []string{"nginx"}, names())
+
+	// mutations are seen by the watch
+	assert.Nil(t, cluster.Create(context.TODO(), wtesting.NewPod("default", "redis")))
+	assert.Eventually(t, func() bool { return len(names()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, cluster.Delete(context.TODO(), wtesting.NewPod("default", "nginx")))
+	assert.Eventually(t, func() bool { return len(names()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"redis"}, names())
+
+	ri := cluster.Dynamic().Resource(pods.GroupVersionResource()).Namespace("default")
+	patched, err := ri.Patch(context.TODO(), "redis", types.MergePatchType, []byte(`{"metadata":{"labels":{"app":"redis"}}}`), metav1.PatchOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "redis", patched.GetLabels()["app"])
+	assert.Eventually(t, func() bool {
+		obj, err := listers[0].GetNamespaced("default", "redis")
+		return err == nil && obj.(*unstructured.Unstructured).GetLabels()["app"] == "redis"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// stale updates conflict
+	stale := wtesting.NewPod("default", "redis")
+	stale.SetResourceVersion("1")
+	assert.True(t, apierrors.IsConflict(cluster.Update(context.TODO(), stale)))
+
+	cluster.InjectError("get", pods.GroupVersionResource(), apierrors.NewServiceUnavailable("down"))
+	_, err = ri.Get(context.TODO(), "redis", metav1.GetOptions{})
+	assert.True(t, apierrors.IsServiceUnavailable(err))
+
+	_, err = cluster.Dynamic().Resource(schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}).List(context.TODO(), metav1.ListOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+// RefreshCredentials rebuilds the clients and their transports from config, such as a config with a
+// rotated token. When config is nil a client created with NewClientFromKubeconfig loads its kubeconfig
+// context again, other clients return an *errors.NilRESTConfig. Client-go caches the transports of exec
+// credential plugins per config, pass a freshly loaded config to pick up credentials rotated by a plugin.
+//
+// Running watches reconnect with the new credentials from their last resource version and keep their
+// cached objects. Unless SkipSubjectAccessChecks is set the entries of cache.Access are reviewed again,
+// the new credentials may belong to a different identity.
+func (c *Client) RefreshCredentials(ctx context.Context, config *rest.Config) error {
+	if config == nil {
+		var err error
+		if config, err = c.reloadRESTConfig(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.UpdateRESTConfig(ctx, config); err != nil {
+		return err
+	}
+
+	if cache.Access == nil || c.SkipSubjectAccessChecks {
+		return nil
+	}
+	return RefreshAccess(ctx, c)
+}
+
+func (c *Client) reloadRESTConfig() (*rest.Config, error) {
+	if !c.fromKubeconfig {
+		return nil, &errors.NilRESTConfig{}
+	}
+	return KubeconfigRESTConfig(c.Kubeconfig, c.KubeContext)
+}
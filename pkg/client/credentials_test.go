@@ -0,0 +1,113 @@
+package client_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+func TestRefreshCredentials(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	cache.Resources = cache.NewResourceCache()
+	defer func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+		cache.Resources = cache.NewResourceCache()
+		cache.Access = nil
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	assert.Nil(t, cluster.AddNamespaces(context.TODO(), "default"))
+	assert.Nil(t, cluster.Create(context.TODO(), wtesting.NewPod("default", "nginx")))
+	cluster.AllowAll()
+
+	configs := []*rest.Config{}
+	c, err := cluster.NewClient(context.TODO(),
+		client.WithLogger(zap.NewNop()),
+		client.WithDynamicClientFn(func(_ context.Context, config *rest.Config) (dynamic.Interface, error) {
+			configs = append(configs, config)
+			return cluster.Dynamic(), nil
+		}),
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.AutoDiscoverResources(context.TODO(), c))
+	pods, _ := cluster.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+	assert.Nil(t, client.AutoDiscoverAccess(context.TODO(), c, "default", pods))
+	listers, err := client.WatchResource(context.TODO(), c, pods, false, []string{"default"})
+	assert.Nil(t, err)
+	assert.Nil(t, client.WaitForWatchesSync(context.TODO(), c, 5*time.Second))
+
+	// the rotated credentials belong to an identity without access
+	cluster.ResetAccess()
+	rotated := &rest.Config{QPS: 400, Burst: 800, BearerToken: "rotated"}
+	assert.Nil(t, c.RefreshCredentials(context.TODO(), rotated))
+	assert.Equal(t, rotated, c.RESTConfig)
+	assert.Equal(t, rotated, configs[len(configs)-1])
+	assert.False(t, cache.Access.Allowed("default", pods, "list"))
+	assert.Len(t, cluster.AccessReviews(), 2)
+
+	// the running watch is kept with its objects and follows the cluster
+	assert.Len(t, cache.WatchStatuses(), 1)
+	objs, err := listers[0].List(labels.Everything())
+	assert.Nil(t, err)
+	assert.Len(t, objs, 1)
+	assert.Nil(t, cluster.Create(context.TODO(), wtesting.NewPod("default", "redis")))
+	assert.Eventually(t, func() bool {
+		objs, err := listers[0].List(labels.Everything())
+		return err == nil && len(objs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// without a config only clients loaded from a kubeconfig can refresh
+	nilConfig := &errors.NilRESTConfig{}
+	assert.ErrorAs(t, c.RefreshCredentials(context.TODO(), nil), &nilConfig)
+	assert.Len(t, configs, 2)
+	assert.Same(t, rotated, c.RESTConfig)
+}
+
+func TestRefreshCredentialsKubeconfig(t *testing.T) {
+	defer func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	kubeconfig := writeKubeconfigs(t)
+	tokens := []string{}
+	options := append(cluster.ClientOptions(),
+		client.WithLogger(zap.NewNop()),
+		client.WithDynamicClientFn(func(_ context.Context, config *rest.Config) (dynamic.Interface, error) {
+			tokens = append(tokens, config.BearerToken)
+			return cluster.Dynamic(), nil
+		}),
+	)
+	c, err := client.NewClientFromKubeconfig(context.TODO(), kubeconfig, "beta", options...)
+	assert.Nil(t, err)
+
+	// the token is rotated in the kubeconfig file
+	beta := filepath.SplitList(kubeconfig)[1]
+	rotated := strings.Replace(betaKubeconfig, "token: secret", "token: rotated", 1)
+	assert.Nil(t, os.WriteFile(beta, []byte(rotated), 0o600))
+
+	assert.Nil(t, c.RefreshCredentials(context.TODO(), nil))
+	assert.Equal(t, "https://beta.example.com", c.RESTConfig.Host)
+	assert.Equal(t, "rotated", c.RESTConfig.BearerToken)
+	assert.Equal(t, []string{"secret", "rotated"}, tokens)
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -19,17 +20,126 @@ func AutoDiscoverAccess(ctx context.Context, client *Client, namespace string, r
 		namespace,
 		resources,
 		resource.WithMinimumRBAC(AutoAccessVerbs),
+		resource.WithConcurrency(client.AccessConcurrency),
+		resource.WithThrottle(client.Throttle),
+		resource.WithLogger(client.Logger),
 	)
 	return nil
 }
 
 func UpdateResourceAccess(ctx context.Context, client *Client, res resource.Resource, namespaces []string) error {
+	return UpdateResourceVerbAccess(ctx, client, res, namespaces, []string{"list", "watch"})
+}
+
+// UpdateResourceVerbAccess reviews the verbs on the resource in each namespace and stores the results in cache.Access.
+func UpdateResourceVerbAccess(ctx context.Context, client *Client, res resource.Resource, namespaces []string, verbs []string) error {
 	if cache.Access == nil {
 		return fmt.Errorf("nil cache.Access")
 	}
 	for _, ns := range namespaces {
-		cache.Access.Update(ctx, client.subjectAccess, ns, res, "list")
-		cache.Access.Update(ctx, client.subjectAccess, ns, res, "watch")
+		for _, verb := range verbs {
+			cache.Access.Update(ctx, client.subjectAccess, ns, res, verb)
+		}
+	}
+	return nil
+}
+
+// RefreshAccess reviews every entry of cache.Access again and replaces cache.Access with the results.
+// The entries are reviewed with the resource of cache.Resources, or else the resource they were reviewed
+// with before. Entries with neither, such as those of NewResourceAccessFromEntries, are dropped.
+func RefreshAccess(ctx context.Context, client *Client) error {
+	if cache.Access == nil {
+		return fmt.Errorf("nil cache.Access")
+	}
+	return refreshAccess(ctx, client, cache.Access)
+}
+
+func refreshAccess(ctx context.Context, client *Client, previous resource.ResourceAccess) error {
+	resources := map[string]resource.Resource{}
+	for _, key := range []string{"namespace", "cluster"} {
+		for _, res := range cache.Resources.Get(key) {
+			resources[res.Key()] = res
+		}
+	}
+
+	entries := previous.Entries()
+	reviews := make([]accessReview, 0, len(entries))
+	for _, entry := range entries {
+		res, ok := resources[entry.Resource]
+		if !ok {
+			res, ok = previous.Resource(entry.Resource)
+		}
+		if ok {
+			reviews = append(reviews, accessReview{namespace: entry.Namespace, resource: res, verb: entry.Verb})
+		}
+	}
+
+	access, err := reviewAccess(ctx, client, reviews)
+	if err != nil {
+		return err
 	}
+	cache.Access = access
 	return nil
 }
+
+// DiscoverAccess reviews the AutoAccessVerbs of the namespaced resources in each namespace and of the cluster
+// resources across all namespaces, without updating cache.Access.
+func DiscoverAccess(ctx context.Context, client *Client, namespaces []string, resources *cache.ResourceCache) (resource.ResourceAccess, error) {
+	reviews := []accessReview{}
+	for _, verb := range AutoAccessVerbs {
+		for _, ns := range namespaces {
+			for _, res := range resources.Get("namespace") {
+				reviews = append(reviews, accessReview{namespace: ns, resource: res, verb: verb})
+			}
+		}
+		for _, res := range resources.Get("cluster") {
+			reviews = append(reviews, accessReview{resource: res, verb: verb})
+		}
+	}
+	return reviewAccess(ctx, client, reviews)
+}
+
+type accessReview struct {
+	namespace string
+	resource  resource.Resource
+	verb      string
+}
+
+// reviewAccess issues the reviews with AccessConcurrency workers into a new ResourceAccess.
+func reviewAccess(ctx context.Context, client *Client, reviews []accessReview) (resource.ResourceAccess, error) {
+	access := resource.NewResourceAccessFromEntries(nil,
+		resource.WithMinimumRBAC(AutoAccessVerbs),
+		resource.WithConcurrency(client.AccessConcurrency),
+		resource.WithThrottle(client.Throttle),
+		resource.WithLogger(client.Logger),
+	)
+
+	concurrency := client.AccessConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	work := make(chan accessReview)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for review := range work {
+				access.Update(ctx, client.subjectAccess, review.namespace, review.resource, review.verb)
+			}
+		}()
+	}
+	for _, review := range reviews {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- review
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return access, nil
+}
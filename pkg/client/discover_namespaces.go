@@ -2,16 +2,29 @@ package client
 
 import (
 	"context"
+	"time"
 
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // AutoDiscoverNamespaces makes a best-effort attempt using the dynamic client to list all the namespaces in the cluster
 // and update the cache.Namespaces list with the results. This is commonly used as a startup routine.
 func AutoDiscoverNamespaces(ctx context.Context, client *Client) error {
+	namespaces, err := DiscoverNamespaces(ctx, client)
+	if err != nil {
+		return err
+	}
+	cache.Namespaces = append(cache.Namespaces, namespaces...)
+	return nil
+}
+
+// DiscoverNamespaces lists the namespaces of the cluster with the dynamic client without updating cache.Namespaces.
+func DiscoverNamespaces(ctx context.Context, client *Client) ([]string, error) {
 	client.Logger.Info("discovering namespaces")
 
 	res := schema.GroupVersionResource{
@@ -21,14 +34,20 @@ func AutoDiscoverNamespaces(ctx context.Context, client *Client) error {
 
 	nri := client.dynamic.Resource(res)
 
-	list, err := nri.List(ctx, metav1.ListOptions{})
+	start := time.Now()
+	var list *unstructured.UnstructuredList
+	err := client.Throttle.Do(ctx, func() (err error) {
+		list, err = nri.List(ctx, metav1.ListOptions{})
+		return err
+	})
+	metrics.ObserveDiscovery("namespaces", start, err)
 	if err != nil {
-		return &errors.NamespaceDiscoveryError{Err: err}
+		return nil, &errors.NamespaceDiscoveryError{Err: err}
 	}
 
+	namespaces := make([]string, 0, len(list.Items))
 	for _, ns := range list.Items {
-		cache.Namespaces = append(cache.Namespaces, ns.GetName())
+		namespaces = append(namespaces, ns.GetName())
 	}
-
-	return nil
+	return namespaces, nil
 }
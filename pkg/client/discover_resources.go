@@ -12,29 +12,65 @@ import (
 // that were provided and update the cache.ResourceMap. This operation is expensive on large clusters and should be considered part
 // of a startup routine and a long-duration periodic task.
 func AutoDiscoverResources(ctx context.Context, client *Client) error {
+	resources, err := DiscoverResources(ctx, client)
+	if err != nil {
+		return err
+	}
+	cache.Resources.Add("namespace", resources.Get("namespace")...)
+	cache.Resources.Add("cluster", resources.Get("cluster")...)
+	return nil
+}
+
+// DiscoverResources lists the resources of the cluster into a new ResourceCache without updating cache.Resources.
+// Namespaced resources are added under "namespace" and the others under "cluster".
+func DiscoverResources(ctx context.Context, client *Client) (*cache.ResourceCache, error) {
 	client.Logger.Info("discovering resources")
 	resources, err := ResourceList(ctx, client, false)
 	if err != nil {
-		return &errors.ResourceDiscoveryError{Err: []error{err}}
+		return nil, &errors.ResourceDiscoveryError{Err: []error{err}}
 	}
+
+	discovered := cache.NewResourceCache()
 	for _, resource := range resources {
 		if resource.APIResource.Namespaced {
-			cache.Resources.Add("namespace", resource)
+			discovered.Add("namespace", resource)
 		} else {
-			cache.Resources.Add("cluster", resource)
+			discovered.Add("cluster", resource)
 		}
 	}
-
-	return nil
+	return discovered, nil
 }
 
 // ResourceListForNamespace uses a Discovery Client and attempts to list all of the known resources for the given namespace.
 // This method can be used to populate initial resource lists as well as refresh existing caches.
 func ResourceList(ctx context.Context, client *Client, namespaced bool) ([]resource.Resource, error) {
-	scopedResources, err := resource.ResourceList(ctx, client.Logger, client.serverResources, namespaced)
+	var scopedResources []resource.Resource
+	err := client.Throttle.Do(ctx, func() (err error) {
+		scopedResources, err = resource.ResourceList(ctx, client.Logger, client.serverResources, namespaced)
+		return err
+	})
 	if err != nil {
 		// TODO: consider if we want a typed-error
 		return nil, err
 	}
 	return scopedResources, nil
 }
+
+// Ping checks that the API server of the client is reachable by requesting the core v1 resources. The
+// discovery request does not take a context, Ping returns when the context is done without waiting for it.
+func Ping(ctx context.Context, client *Client) error {
+	return client.Throttle.Do(ctx, func() error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := client.serverResources.ServerResourcesForGroupVersion("v1")
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
@@ -11,6 +12,7 @@ import (
 	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
 	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 	rtesting "github.com/wwitzel3/k8s-resource-client/pkg/resource/testing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
@@ -41,6 +43,34 @@ func TestUpdateResourceAccess(t *testing.T) {
 
 }
 
+func TestRefreshAccess(t *testing.T) {
+	cache.Resources = cache.NewResourceCache()
+	defer func() {
+		cache.Access = nil
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	widget := cluster.AddCRD("example.com", "v1alpha1", "Widget", "widgets", true)
+	cluster.AllowAll()
+	c, err := cluster.NewClient(context.TODO())
+	assert.Nil(t, err)
+
+	// the widgets are not in cache.Resources, the resource reviewed before is used
+	assert.Nil(t, client.AutoDiscoverAccess(context.TODO(), c, "default", widget))
+	assert.True(t, cache.Access.AllowedAll("default", widget, client.AutoAccessVerbs))
+
+	cluster.ResetAccess()
+	assert.Nil(t, client.RefreshAccess(context.TODO(), c))
+	assert.Len(t, cluster.AccessReviews(), 2)
+	assert.Len(t, cache.Access.Entries(), 2)
+	assert.False(t, cache.Access.Allowed("default", widget, "list"))
+
+	// entries loaded without their resources are dropped
+	cache.Access = resource.NewResourceAccessFromEntries(cache.Access.Entries())
+	assert.Nil(t, client.RefreshAccess(context.TODO(), c))
+	assert.Len(t, cache.Access.Entries(), 0)
+}
+
 func TestAutoDiscoverNamespacesErr(t *testing.T) {
 	assert.Len(t, cache.Namespaces, 0)
 
@@ -171,3 +201,38 @@ func TestAutoDiscoverResourcesErr(t *testing.T) {
 	err = client.AutoDiscoverResources(context.TODO(), c)
 	assert.EqualError(t, err, "ResourceDiscoveryError - [get preferred resources: fake server resources error]")
 }
+
+// blockingServerResources blocks discovery requests until unblock is closed.
+type blockingServerResources struct {
+	discovery.ServerResourcesInterface
+	unblock chan struct{}
+}
+
+func (b blockingServerResources) ServerResourcesForGroupVersion(string) (*metav1.APIResourceList, error) {
+	<-b.unblock
+	return &metav1.APIResourceList{}, nil
+}
+
+func TestPing(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	c, err := client.NewClient(context.TODO(),
+		client.WithRESTConfig(&rest.Config{}),
+		client.WithClientsetFn(ctesting.FakeClientset),
+		client.WithDynamicClientFn(ctesting.FakeDynamicFactory(nil, false)),
+		client.WithServerResourcesFn(func(context.Context, kubernetes.Interface) (discovery.ServerResourcesInterface, error) {
+			return blockingServerResources{unblock: unblock}, nil
+		}),
+	)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.Ping(ctx, c), context.DeadlineExceeded)
+
+	cluster := ctesting.NewFakeCluster()
+	c, err = cluster.NewClient(context.TODO())
+	assert.Nil(t, err)
+	assert.Nil(t, client.Ping(context.TODO(), c))
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+const (
+	// RecommendedQPS is the minimum rest.Config QPS recommended for discovery and access reviews.
+	RecommendedQPS = 400
+	// RecommendedBurst is the minimum rest.Config Burst recommended for discovery and access reviews.
+	RecommendedBurst = 800
+)
+
+// KubeconfigRESTConfig loads the rest.Config of the kube context from the kubeconfig. The kubeconfig is a
+// list of files separated like KUBECONFIG that are merged, when empty the KUBECONFIG environment variable
+// or ~/.kube/config is used. An empty kube context uses the current-context. When no kubeconfig is found
+// the in-cluster config is used. QPS and Burst are set to RecommendedQPS and RecommendedBurst when unset.
+func KubeconfigRESTConfig(kubeconfig, kubeContext string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.Precedence = filepath.SplitList(kubeconfig)
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, &errors.KubeconfigError{Kubeconfig: kubeconfig, Context: kubeContext, Err: err}
+	}
+	return withRecommendedRates(config), nil
+}
+
+// InClusterRESTConfig loads the rest.Config from the service account of the pod. QPS and Burst are set to
+// RecommendedQPS and RecommendedBurst when unset.
+func InClusterRESTConfig() (*rest.Config, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, &errors.KubeconfigError{Err: err}
+	}
+	return withRecommendedRates(config), nil
+}
+
+func withRecommendedRates(config *rest.Config) *rest.Config {
+	if config.QPS == 0 && config.Burst == 0 {
+		config.QPS = RecommendedQPS
+		config.Burst = RecommendedBurst
+	}
+	return config
+}
+
+// NewClientFromKubeconfig creates a client for the kube context of the kubeconfig, see KubeconfigRESTConfig.
+// The loaded rest.Config replaces any WithRESTConfig option.
+func NewClientFromKubeconfig(ctx context.Context, kubeconfig, kubeContext string, options ...ClientOption) (*Client, error) {
+	config, err := KubeconfigRESTConfig(kubeconfig, kubeContext)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, WithRESTConfig(config), func(c *Client) {
+		c.Kubeconfig = kubeconfig
+		c.KubeContext = kubeContext
+		c.fromKubeconfig = true
+	})
+	return NewClient(ctx, options...)
+}
+
+// NewInClusterClient creates a client from the in-cluster config, see InClusterRESTConfig.
+// The loaded rest.Config replaces any WithRESTConfig option.
+func NewInClusterClient(ctx context.Context, options ...ClientOption) (*Client, error) {
+	config, err := InClusterRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(ctx, append(options, WithRESTConfig(config))...)
+}
+
+// SwitchContext points the client at another kube context of its Kubeconfig. The clients for the new cluster
+// are built first, in Auto mode its namespaces and resources are discovered, and when cache.Access is set it
+// is reviewed again for the namespaces and resources unless SkipSubjectAccessChecks is set. Only then the
+// client, cache.Namespaces, cache.Resources and cache.Access are replaced, so when any step fails the client
+// is left unchanged on its cluster. The watches of the previous cluster share their keys with those of the
+// new one, they are stopped and removed from cache.ResourceWatches before the new watcher is installed and
+// waited for until the context is done, those that did not exit are returned in an *errors.ClientNotClosed.
+func (c *Client) SwitchContext(ctx context.Context, kubeContext string) error {
+	c.mu.Lock()
+	closed := c.closed
+	namespaces, resources, previousAccess := cache.Namespaces, cache.Resources, cache.Access
+	c.mu.Unlock()
+	if closed {
+		return fmt.Errorf("client closed")
+	}
+
+	config, err := KubeconfigRESTConfig(c.Kubeconfig, kubeContext)
+	if err != nil {
+		return err
+	}
+	clients, err := c.newClients(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	// the new cluster is discovered through a staging client
+	staging := &Client{Logger: c.Logger, Throttle: c.Throttle, AccessConcurrency: c.AccessConcurrency}
+	staging.setClients(clients)
+
+	if c.NamespaceMode == Auto {
+		if namespaces, err = DiscoverNamespaces(ctx, staging); err != nil {
+			return err
+		}
+	}
+	if c.ResourceMode == Auto {
+		if resources, err = DiscoverResources(ctx, staging); err != nil {
+			return err
+		}
+	}
+	var access resource.ResourceAccess
+	if previousAccess != nil && !c.SkipSubjectAccessChecks {
+		if access, err = DiscoverAccess(ctx, staging, namespaces, resources); err != nil {
+			return err
+		}
+	}
+	watcher, err := c.WatcherFn(ctx, c.Logger, clients.dynamic)
+	if err != nil {
+		return err
+	}
+
+	// the client is locked until the switch is done, concurrent switches and Close wait for it
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("client closed")
+	}
+	if c.watcher != nil {
+		err = stopWatcher(ctx, c.watcher)
+	}
+	c.RESTConfig = config
+	c.KubeContext = kubeContext
+	c.setClients(clients)
+	c.watcher = watcher
+
+	cache.Namespaces = namespaces
+	cache.Resources = resources
+	cache.Access = access
+	return err
+}
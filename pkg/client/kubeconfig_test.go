@@ -0,0 +1,195 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	typedAuthv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+const alphaKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: alpha
+  cluster:
+    server: https://alpha.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: alpha
+  context:
+    cluster: alpha
+    user: admin
+current-context: alpha
+`
+
+const betaKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: beta
+  cluster:
+    server: https://beta.example.com
+users:
+- name: viewer
+  user:
+    token: secret
+contexts:
+- name: beta
+  context:
+    cluster: beta
+    user: viewer
+`
+
+// writeKubeconfigs writes the alpha and beta kubeconfigs and returns them as a KUBECONFIG list.
+func writeKubeconfigs(t *testing.T) string {
+	dir := t.TempDir()
+	alpha, beta := filepath.Join(dir, "alpha"), filepath.Join(dir, "beta")
+	assert.Nil(t, os.WriteFile(alpha, []byte(alphaKubeconfig), 0o600))
+	assert.Nil(t, os.WriteFile(beta, []byte(betaKubeconfig), 0o600))
+	return strings.Join([]string{alpha, beta}, string(filepath.ListSeparator))
+}
+
+func TestKubeconfigRESTConfig(t *testing.T) {
+	kubeconfig := writeKubeconfigs(t)
+
+	config, err := client.KubeconfigRESTConfig(kubeconfig, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://alpha.example.com", config.Host)
+	assert.Equal(t, float32(client.RecommendedQPS), config.QPS)
+	assert.Equal(t, client.RecommendedBurst, config.Burst)
+
+	config, err = client.KubeconfigRESTConfig(kubeconfig, "beta")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://beta.example.com", config.Host)
+
+	_, err = client.KubeconfigRESTConfig(kubeconfig, "gamma")
+	kubeconfigErr := &errors.KubeconfigError{}
+	assert.ErrorAs(t, err, &kubeconfigErr)
+	assert.Equal(t, "gamma", kubeconfigErr.Context)
+
+	// the files of KUBECONFIG are merged when no kubeconfig is given
+	t.Setenv("KUBECONFIG", kubeconfig)
+	config, err = client.KubeconfigRESTConfig("", "beta")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://beta.example.com", config.Host)
+}
+
+func TestNewClientFromKubeconfigErr(t *testing.T) {
+	_, err := client.NewClientFromKubeconfig(context.TODO(), filepath.Join(t.TempDir(), "missing"), "alpha")
+	assert.NotNil(t, err)
+}
+
+func TestSwitchContext(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	cache.Resources = cache.NewResourceCache()
+	cache.Namespaces = []string{}
+	defer func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+		cache.Resources = cache.NewResourceCache()
+		cache.Namespaces = []string{}
+		cache.Access = nil
+	}()
+
+	alpha, beta := ctesting.NewFakeCluster(), ctesting.NewFakeCluster()
+	widget := beta.AddCRD("example.com", "v1alpha1", "Widget", "widgets", true)
+	assert.Nil(t, alpha.AddNamespaces(context.TODO(), "default"))
+	assert.Nil(t, beta.AddNamespaces(context.TODO(), "default", "test"))
+	alpha.AllowAll()
+	beta.AllowAll()
+
+	// the clusters are selected by the host of the rest.Config, the clientset is created first
+	fakes := map[string]*ctesting.FakeCluster{
+		"https://alpha.example.com": alpha,
+		"https://beta.example.com":  beta,
+	}
+	var current *ctesting.FakeCluster
+	c, err := client.NewClientFromKubeconfig(context.TODO(), writeKubeconfigs(t), "",
+		client.WithLogger(zap.NewNop()),
+		client.WithClientsetFn(func(ctx context.Context, config *rest.Config) (kubernetes.Interface, error) {
+			current = fakes[config.Host]
+			return ctesting.FakeClientset(ctx, config)
+		}),
+		client.WithDynamicClientFn(func(_ context.Context, config *rest.Config) (dynamic.Interface, error) {
+			return fakes[config.Host].Dynamic(), nil
+		}),
+		client.WithServerResourcesFn(func(context.Context, kubernetes.Interface) (discovery.ServerResourcesInterface, error) {
+			return current.ServerResources(), nil
+		}),
+		client.WithSubjectAccessFn(func(context.Context, kubernetes.Interface) (typedAuthv1.SelfSubjectAccessReviewInterface, error) {
+			return current.SubjectAccess(), nil
+		}),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "", c.KubeContext)
+	assert.Equal(t, "https://alpha.example.com", c.RESTConfig.Host)
+
+	assert.Nil(t, client.AutoDiscoverNamespaces(context.TODO(), c))
+	assert.Nil(t, client.AutoDiscoverResources(context.TODO(), c))
+	pods, _ := alpha.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+	assert.Nil(t, client.AutoDiscoverAccess(context.TODO(), c, "default", pods))
+	_, err = client.WatchResource(context.TODO(), c, pods, false, []string{"default"})
+	assert.Nil(t, err)
+	assert.Nil(t, client.WaitForWatchesSync(context.TODO(), c, 5*time.Second))
+
+	// a missing context or a failed discovery leaves the client on its cluster
+	assert.NotNil(t, c.SwitchContext(context.TODO(), "gamma"))
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	beta.InjectError("list", namespaces, fmt.Errorf("unavailable"))
+	assert.NotNil(t, c.SwitchContext(context.TODO(), "beta"))
+	beta.InjectError("list", namespaces, nil)
+	assert.Equal(t, "https://alpha.example.com", c.RESTConfig.Host)
+	assert.Equal(t, []string{"default"}, cache.Namespaces)
+	assert.True(t, cache.Access.AllowedAll("default", pods, client.AutoAccessVerbs))
+	_, err = cache.WatchForResource(pods, "default")
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.SwitchContext(context.TODO(), "beta"))
+	assert.Equal(t, "beta", c.KubeContext)
+	assert.Equal(t, "https://beta.example.com", c.RESTConfig.Host)
+
+	// the watches of alpha are stopped and the caches hold the discovery of beta
+	_, err = cache.WatchForResource(pods, "default")
+	assert.NotNil(t, err)
+	listers, err := client.WatchResource(context.TODO(), c, pods, false, []string{"default"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, listers[0].IsRunning())
+	assert.Equal(t, []string{"default", "test"}, cache.Namespaces)
+	assert.Contains(t, cache.Resources.Get("namespace"), widget)
+
+	// the access is reviewed for every namespace and resource of beta
+	reviews := len(cache.Namespaces) * len(cache.Resources.Get("namespace")) * len(client.AutoAccessVerbs)
+	assert.Len(t, beta.AccessReviews(), reviews)
+	assert.True(t, cache.Access.AllowedAll("default", pods, client.AutoAccessVerbs))
+	assert.True(t, cache.Access.AllowedAll("test", widget, client.AutoAccessVerbs))
+
+	beta.ResetAccess()
+	assert.Nil(t, client.RefreshAccess(context.TODO(), c))
+	assert.False(t, cache.Access.Allowed("default", pods, "list"))
+	assert.Len(t, beta.AccessReviews(), reviews)
+
+	// a closed client is not switched
+	assert.Nil(t, c.Close(context.TODO()))
+	assert.EqualError(t, c.SwitchContext(context.TODO(), "alpha"), "client closed")
+	assert.Equal(t, "beta", c.KubeContext)
+}
@@ -3,7 +3,11 @@ package client
 import (
 	"context"
 
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 	"go.uber.org/zap"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -73,3 +77,30 @@ func WithLogger(logger *zap.Logger) ClientOption {
 		c.Logger = logger
 	}
 }
+
+// WithLogr logs to the logr.Logger, see logging.FromLogr.
+func WithLogr(logger logr.Logger) ClientOption {
+	return WithLogger(logging.FromLogr(logger))
+}
+
+// WithMetricsRegisterer registers the library metrics with the given Registerer, e.g. prometheus.DefaultRegisterer.
+func WithMetricsRegisterer(registerer prometheus.Registerer) ClientOption {
+	return func(c *Client) {
+		c.MetricsRegisterer = registerer
+	}
+}
+
+// WithThrottle rate limits discovery and SelfSubjectAccessReview requests and retries them when the
+// API server responds with 429 TooManyRequests. Use resource.NewThrottle to create a Throttle.
+func WithThrottle(t *resource.Throttle) ClientOption {
+	return func(c *Client) {
+		c.Throttle = t
+	}
+}
+
+// WithAccessConcurrency sets the number of SelfSubjectAccessReviews that will be in flight at once.
+func WithAccessConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.AccessConcurrency = n
+	}
+}
@@ -0,0 +1,461 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// clusterStore holds the objects of a FakeCluster. Every change is assigned the next resourceVersion
+// and kept in a history, so watches started from a resourceVersion receive the changes they missed.
+type clusterStore struct {
+	mu       sync.Mutex
+	rv       int64
+	objects  map[schema.GroupVersionResource]map[string]*unstructured.Unstructured
+	history  []storeEvent
+	watchers map[*storeWatcher]struct{}
+}
+
+type storeEvent struct {
+	rv        int64
+	gvr       schema.GroupVersionResource
+	eventType watch.EventType
+	object    *unstructured.Unstructured
+}
+
+func newClusterStore() *clusterStore {
+	return &clusterStore{
+		objects:  map[schema.GroupVersionResource]map[string]*unstructured.Unstructured{},
+		watchers: map[*storeWatcher]struct{}{},
+	}
+}
+
+func objectKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// record assigns the next resourceVersion to the object and sends the change to the watchers, the
+// caller must hold the lock.
+func (s *clusterStore) record(gvr schema.GroupVersionResource, eventType watch.EventType, obj *unstructured.Unstructured) {
+	s.rv++
+	obj.SetResourceVersion(strconv.FormatInt(s.rv, 10))
+
+	event := storeEvent{rv: s.rv, gvr: gvr, eventType: eventType, object: obj.DeepCopy()}
+	s.history = append(s.history, event)
+	for w := range s.watchers {
+		w.send(event)
+	}
+}
+
+func (s *clusterStore) create(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects, ok := s.objects[gvr]
+	if !ok {
+		objects = map[string]*unstructured.Unstructured{}
+		s.objects[gvr] = objects
+	}
+	key := objectKey(obj.GetNamespace(), obj.GetName())
+	if _, ok := objects[key]; ok {
+		return nil, apierrors.NewAlreadyExists(gvr.GroupResource(), obj.GetName())
+	}
+
+	obj = obj.DeepCopy()
+	obj.SetUID(types.UID(fmt.Sprintf("%s-%d", gvr.Resource, s.rv+1)))
+	obj.SetCreationTimestamp(metav1.Now())
+	obj.SetGeneration(1)
+	s.record(gvr, watch.Added, obj)
+	objects[key] = obj
+	return obj.DeepCopy(), nil
+}
+
+func (s *clusterStore) update(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := objectKey(obj.GetNamespace(), obj.GetName())
+	existing, ok := s.objects[gvr][key]
+	if !ok {
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), obj.GetName())
+	}
+	if rv := obj.GetResourceVersion(); rv != "" && rv != existing.GetResourceVersion() {
+		return nil, apierrors.NewConflict(gvr.GroupResource(), obj.GetName(),
+			fmt.Errorf("the object has been modified, resourceVersion %s is not %s", rv, existing.GetResourceVersion()))
+	}
+
+	obj = obj.DeepCopy()
+	obj.SetUID(existing.GetUID())
+	obj.SetCreationTimestamp(existing.GetCreationTimestamp())
+	obj.SetGeneration(existing.GetGeneration() + 1)
+	s.record(gvr, watch.Modified, obj)
+	s.objects[gvr][key] = obj
+	return obj.DeepCopy(), nil
+}
+
+func (s *clusterStore) get(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.objects[gvr][objectKey(namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (s *clusterStore) delete(gvr schema.GroupVersionResource, namespace, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := objectKey(namespace, name)
+	obj, ok := s.objects[gvr][key]
+	if !ok {
+		return apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+	delete(s.objects[gvr], key)
+	s.record(gvr, watch.Deleted, obj.DeepCopy())
+	return nil
+}
+
+// list returns the matching objects sorted by namespace and name and the current resourceVersion.
+func (s *clusterStore) list(gvr schema.GroupVersionResource, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objs := []*unstructured.Unstructured{}
+	for _, obj := range s.objects[gvr] {
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		objs = append(objs, obj.DeepCopy())
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return objectKey(objs[i].GetNamespace(), objs[i].GetName()) < objectKey(objs[j].GetNamespace(), objs[j].GetName())
+	})
+	return objs, strconv.FormatInt(s.rv, 10)
+}
+
+// watch starts a watch of the resource, changes after resourceVersion are sent first. An empty or "0"
+// resourceVersion starts from the current state.
+func (s *clusterStore) watch(gvr schema.GroupVersionResource, namespace string, selector labels.Selector, resourceVersion string) (watch.Interface, error) {
+	var from int64
+	if resourceVersion != "" && resourceVersion != "0" {
+		rv, err := strconv.ParseInt(resourceVersion, 10, 64)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resourceVersion %q", resourceVersion))
+		}
+		from = rv
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from == 0 {
+		from = s.rv
+	}
+	w := newStoreWatcher(s, gvr, namespace, selector)
+	for _, event := range s.history {
+		if event.rv > from {
+			w.send(event)
+		}
+	}
+	s.watchers[w] = struct{}{}
+	return w, nil
+}
+
+func (s *clusterStore) stopWatcher(w *storeWatcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.watchers, w)
+}
+
+// storeWatcher is a watch.Interface for a single resource. Events are queued without a limit and
+// delivered by a goroutine, so a slow consumer does not block changes to the store.
+type storeWatcher struct {
+	store     *clusterStore
+	gvr       schema.GroupVersionResource
+	namespace string
+	selector  labels.Selector
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []watch.Event
+	stopped bool
+	result  chan watch.Event
+	done    chan struct{}
+}
+
+var _ watch.Interface = (*storeWatcher)(nil)
+
+func newStoreWatcher(store *clusterStore, gvr schema.GroupVersionResource, namespace string, selector labels.Selector) *storeWatcher {
+	w := &storeWatcher{
+		store:     store,
+		gvr:       gvr,
+		namespace: namespace,
+		selector:  selector,
+		result:    make(chan watch.Event),
+		done:      make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+func (w *storeWatcher) send(event storeEvent) {
+	if event.gvr != w.gvr {
+		return
+	}
+	if w.namespace != "" && event.object.GetNamespace() != w.namespace {
+		return
+	}
+	if !w.selector.Matches(labels.Set(event.object.GetLabels())) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, watch.Event{Type: event.eventType, Object: event.object.DeepCopy()})
+	w.cond.Signal()
+}
+
+func (w *storeWatcher) run() {
+	defer close(w.result)
+	for {
+		w.mu.Lock()
+		for len(w.pending) == 0 && !w.stopped {
+			w.cond.Wait()
+		}
+		if w.stopped {
+			w.mu.Unlock()
+			return
+		}
+		event := w.pending[0]
+		w.pending = w.pending[1:]
+		w.mu.Unlock()
+
+		select {
+		case w.result <- event:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *storeWatcher) Stop() {
+	w.store.stopWatcher(w)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.done)
+		w.cond.Signal()
+	}
+}
+
+func (w *storeWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// clusterDynamicClient is a dynamic.Interface serving the objects of a FakeCluster.
+type clusterDynamicClient struct {
+	cluster *FakeCluster
+}
+
+var _ dynamic.Interface = (*clusterDynamicClient)(nil)
+
+func (d *clusterDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &clusterResourceClient{cluster: d.cluster, gvr: gvr}
+}
+
+type clusterResourceClient struct {
+	cluster   *FakeCluster
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+var _ dynamic.NamespaceableResourceInterface = (*clusterResourceClient)(nil)
+
+func (c *clusterResourceClient) Namespace(namespace string) dynamic.ResourceInterface {
+	ret := *c
+	ret.namespace = namespace
+	return &ret
+}
+
+// check returns a NotFound error for resources that were not added to the cluster, like the API server
+// does for unknown resources, and for calls to a cluster scoped resource in a namespace.
+func (c *clusterResourceClient) check(verb string) error {
+	if err := c.cluster.injectedError(verb, c.gvr); err != nil {
+		return err
+	}
+	res, ok := c.cluster.Resource(c.gvr)
+	if !ok || (!res.APIResource.Namespaced && c.namespace != "") {
+		return apierrors.NewNotFound(c.gvr.GroupResource(), "")
+	}
+	return nil
+}
+
+func (c *clusterResourceClient) prepare(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	obj = obj.DeepCopy()
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(c.namespace)
+	}
+	if obj.GetNamespace() != c.namespace {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("namespace %q does not match the namespace of the request %q", obj.GetNamespace(), c.namespace))
+	}
+	if obj.GetName() == "" {
+		return nil, apierrors.NewBadRequest("name is required")
+	}
+	return obj, nil
+}
+
+func (c *clusterResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if err := c.check("create"); err != nil {
+		return nil, err
+	}
+	obj, err := c.prepare(obj)
+	if err != nil {
+		return nil, err
+	}
+	return c.cluster.store.create(c.gvr, obj)
+}
+
+func (c *clusterResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if err := c.check("update"); err != nil {
+		return nil, err
+	}
+	obj, err := c.prepare(obj)
+	if err != nil {
+		return nil, err
+	}
+	return c.cluster.store.update(c.gvr, obj)
+}
+
+func (c *clusterResourceClient) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	return c.Update(ctx, obj, metav1.UpdateOptions{}, "status")
+}
+
+func (c *clusterResourceClient) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	if err := c.check("delete"); err != nil {
+		return err
+	}
+	return c.cluster.store.delete(c.gvr, c.namespace, name)
+}
+
+func (c *clusterResourceClient) DeleteCollection(ctx context.Context, options metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	if err := c.check("deletecollection"); err != nil {
+		return err
+	}
+	selector, err := labels.Parse(listOptions.LabelSelector)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	objs, _ := c.cluster.store.list(c.gvr, c.namespace, selector)
+	for _, obj := range objs {
+		if err := c.cluster.store.delete(c.gvr, obj.GetNamespace(), obj.GetName()); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *clusterResourceClient) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if err := c.check("get"); err != nil {
+		return nil, err
+	}
+	return c.cluster.store.get(c.gvr, c.namespace, name)
+}
+
+func (c *clusterResourceClient) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if err := c.check("list"); err != nil {
+		return nil, err
+	}
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	objs, rv := c.cluster.store.list(c.gvr, c.namespace, selector)
+	res, _ := c.cluster.Resource(c.gvr)
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(c.gvr.GroupVersion().String())
+	list.SetKind(res.GroupVersionKind.Kind + "List")
+	list.SetResourceVersion(rv)
+	for _, obj := range objs {
+		list.Items = append(list.Items, *obj)
+	}
+	return list, nil
+}
+
+func (c *clusterResourceClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	if err := c.check("watch"); err != nil {
+		return nil, err
+	}
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	return c.cluster.store.watch(c.gvr, c.namespace, selector, opts.ResourceVersion)
+}
+
+// Patch supports JSON patches and merge patches, strategic merge patches are applied as merge patches.
+func (c *clusterResourceClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if err := c.check("patch"); err != nil {
+		return nil, err
+	}
+	existing, err := c.cluster.store.get(c.gvr, c.namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	original, err := json.Marshal(existing.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch pt {
+	case types.JSONPatchType:
+		patch, err := jsonpatch.DecodePatch(data)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+		patched, err = patch.Apply(original)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	case types.MergePatchType, types.StrategicMergePatchType:
+		patched, err = jsonpatch.MergePatch(original, data)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	default:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("unsupported patch type %q", pt))
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(patched); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	obj.SetResourceVersion("")
+	return c.cluster.store.update(c.gvr, obj)
+}
@@ -0,0 +1,469 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	authv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	typedAuthv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// DefaultClusterResources are the resources served by a FakeCluster created without resources.
+var DefaultClusterResources = []resource.Resource{
+	NewClusterResource("", "v1", "Namespace", "namespaces", false),
+	NewClusterResource("", "v1", "Node", "nodes", false),
+	NewClusterResource("", "v1", "PersistentVolume", "persistentvolumes", false),
+	NewClusterResource("", "v1", "Pod", "pods", true),
+	NewClusterResource("", "v1", "Service", "services", true),
+	NewClusterResource("", "v1", "ConfigMap", "configmaps", true),
+	NewClusterResource("", "v1", "Secret", "secrets", true),
+	NewClusterResource("", "v1", "ServiceAccount", "serviceaccounts", true),
+	NewClusterResource("", "v1", "PersistentVolumeClaim", "persistentvolumeclaims", true),
+	NewClusterResource("apps", "v1", "Deployment", "deployments", true),
+	NewClusterResource("apps", "v1", "ReplicaSet", "replicasets", true),
+	NewClusterResource("apps", "v1", "StatefulSet", "statefulsets", true),
+	NewClusterResource("apps", "v1", "DaemonSet", "daemonsets", true),
+	NewClusterResource("batch", "v1", "Job", "jobs", true),
+	NewClusterResource("networking.k8s.io", "v1", "Ingress", "ingresses", true),
+	NewClusterResource("rbac.authorization.k8s.io", "v1", "Role", "roles", true),
+	NewClusterResource("rbac.authorization.k8s.io", "v1", "RoleBinding", "rolebindings", true),
+	NewClusterResource("rbac.authorization.k8s.io", "v1", "ClusterRole", "clusterroles", false),
+	NewClusterResource("rbac.authorization.k8s.io", "v1", "ClusterRoleBinding", "clusterrolebindings", false),
+}
+
+// ClusterResourceVerbs are the verbs of the resources created by NewClusterResource.
+var ClusterResourceVerbs = metav1.Verbs{"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch"}
+
+// NewClusterResource creates a Resource for a FakeCluster, use it to add custom resources.
+func NewClusterResource(group, version, kind, plural string, namespaced bool) resource.Resource {
+	return resource.Resource{
+		GroupVersionKind: schema.GroupVersionKind{Group: group, Version: version, Kind: kind},
+		APIResource: metav1.APIResource{
+			Name:       plural,
+			Namespaced: namespaced,
+			Kind:       kind,
+			Verbs:      ClusterResourceVerbs,
+		},
+	}
+}
+
+// AccessRule allows the verbs on the resources of the API groups in the namespaces, like an RBAC PolicyRule
+// bound in each namespace. "*" matches everything and no Namespaces matches every namespace and cluster
+// scoped requests.
+type AccessRule struct {
+	Namespaces []string
+	APIGroups  []string
+	Resources  []string
+	Verbs      []string
+}
+
+func (r AccessRule) allows(attrs *authv1.ResourceAttributes) bool {
+	return (len(r.Namespaces) == 0 || matches(r.Namespaces, attrs.Namespace)) &&
+		matches(r.APIGroups, attrs.Group) &&
+		matches(r.Resources, attrs.Resource) &&
+		matches(r.Verbs, attrs.Verb)
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FakeCluster is an in-memory cluster for testing code that uses the client. It serves discovery for its
+// resources, a dynamic client with working list, watch and mutations, and SelfSubjectAccessReviews answered
+// from AccessRules. Use ClientOptions or NewClient to wire it in to a client:
+//
+//	cluster := testing.NewFakeCluster()
+//	cluster.Allow(testing.AccessRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}})
+//	cluster.AddNamespaces("default")
+//	c, err := cluster.NewClient(ctx)
+type FakeCluster struct {
+	mu        sync.RWMutex
+	resources []resource.Resource
+	rules     []AccessRule
+	accessFn  func(*authv1.ResourceAttributes) (bool, error)
+	reviews   []authv1.ResourceAttributes
+	errors    map[string]error
+
+	// DiscoveryErr is returned with the resources from discovery, like a partial discovery failure.
+	DiscoveryErr error
+
+	store *clusterStore
+}
+
+// NewFakeCluster creates a FakeCluster serving the resources, or DefaultClusterResources when none are given.
+// No access is allowed until AccessRules are added.
+func NewFakeCluster(resources ...resource.Resource) *FakeCluster {
+	if len(resources) == 0 {
+		resources = DefaultClusterResources
+	}
+	c := &FakeCluster{
+		errors: map[string]error{},
+		store:  newClusterStore(),
+	}
+	c.AddResources(resources...)
+	return c
+}
+
+// AddResources adds resources to discovery, such as custom resources. Existing resources are replaced.
+func (c *FakeCluster) AddResources(resources ...resource.Resource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range resources {
+		replaced := false
+		for i, existing := range c.resources {
+			if existing.GroupVersionResource() == r.GroupVersionResource() {
+				c.resources[i] = r
+				replaced = true
+			}
+		}
+		if !replaced {
+			c.resources = append(c.resources, r)
+		}
+	}
+}
+
+// AddCRD adds a custom resource to discovery and returns it.
+func (c *FakeCluster) AddCRD(group, version, kind, plural string, namespaced bool) resource.Resource {
+	r := NewClusterResource(group, version, kind, plural, namespaced)
+	c.AddResources(r)
+	return r
+}
+
+// RemoveResource removes the resource from discovery, its objects are no longer served.
+func (c *FakeCluster) RemoveResource(gvr schema.GroupVersionResource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resources := []resource.Resource{}
+	for _, r := range c.resources {
+		if r.GroupVersionResource() != gvr {
+			resources = append(resources, r)
+		}
+	}
+	c.resources = resources
+}
+
+// Resources returns the resources served by the cluster.
+func (c *FakeCluster) Resources() []resource.Resource {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]resource.Resource{}, c.resources...)
+}
+
+// Resource returns the served resource for the GroupVersionResource.
+func (c *FakeCluster) Resource(gvr schema.GroupVersionResource) (resource.Resource, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, r := range c.resources {
+		if r.GroupVersionResource() == gvr {
+			return r, true
+		}
+	}
+	return resource.Resource{}, false
+}
+
+// ResourceForKind returns the served resource for the GroupVersionKind.
+func (c *FakeCluster) ResourceForKind(gvk schema.GroupVersionKind) (resource.Resource, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, r := range c.resources {
+		if r.GroupVersionKind == gvk {
+			return r, true
+		}
+	}
+	return resource.Resource{}, false
+}
+
+// InjectError makes every request with the verb for the resource fail with err, a nil err removes it.
+// Verbs are the dynamic client verbs: create, update, delete, deletecollection, get, list, watch and patch.
+func (c *FakeCluster) InjectError(verb string, gvr schema.GroupVersionResource, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := verb + ":" + gvr.String()
+	if err == nil {
+		delete(c.errors, key)
+		return
+	}
+	c.errors[key] = err
+}
+
+func (c *FakeCluster) injectedError(verb string, gvr schema.GroupVersionResource) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.errors[verb+":"+gvr.String()]
+}
+
+// Create creates the objects, the resource is found from their kind.
+func (c *FakeCluster) Create(ctx context.Context, objs ...*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		ri, err := c.resourceInterface(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := ri.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update replaces the objects, the resourceVersion is checked when it is set.
+func (c *FakeCluster) Update(ctx context.Context, objs ...*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		ri, err := c.resourceInterface(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := ri.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes the objects.
+func (c *FakeCluster) Delete(ctx context.Context, objs ...*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		ri, err := c.resourceInterface(obj)
+		if err != nil {
+			return err
+		}
+		if err := ri.Delete(ctx, obj.GetName(), metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddNamespaces creates a Namespace object for each name.
+func (c *FakeCluster) AddNamespaces(ctx context.Context, names ...string) error {
+	for _, name := range names {
+		ns := &unstructured.Unstructured{}
+		ns.SetAPIVersion("v1")
+		ns.SetKind("Namespace")
+		ns.SetName(name)
+		if err := c.Create(ctx, ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *FakeCluster) resourceInterface(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	r, ok := c.ResourceForKind(gvk)
+	if !ok {
+		return nil, fmt.Errorf("no resource for kind %s", gvk)
+	}
+	ri := c.Dynamic().Resource(r.GroupVersionResource())
+	if r.APIResource.Namespaced {
+		return ri.Namespace(obj.GetNamespace()), nil
+	}
+	return ri, nil
+}
+
+// Dynamic returns a dynamic client for the cluster.
+func (c *FakeCluster) Dynamic() dynamic.Interface {
+	return &clusterDynamicClient{cluster: c}
+}
+
+// Allow adds AccessRules, a SelfSubjectAccessReview is allowed when any rule allows it.
+func (c *FakeCluster) Allow(rules ...AccessRule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = append(c.rules, rules...)
+}
+
+// AllowAll allows every SelfSubjectAccessReview.
+func (c *FakeCluster) AllowAll() {
+	c.Allow(AccessRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}})
+}
+
+// ResetAccess removes the AccessRules and access function and forgets the recorded reviews.
+func (c *FakeCluster) ResetAccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = nil
+	c.accessFn = nil
+	c.reviews = nil
+}
+
+// SetAccessFn answers SelfSubjectAccessReviews with fn instead of the AccessRules, an error from fn is
+// returned from the review request.
+func (c *FakeCluster) SetAccessFn(fn func(*authv1.ResourceAttributes) (bool, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessFn = fn
+}
+
+// AccessReviews returns the ResourceAttributes of every SelfSubjectAccessReview the cluster received.
+func (c *FakeCluster) AccessReviews() []authv1.ResourceAttributes {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]authv1.ResourceAttributes{}, c.reviews...)
+}
+
+// SubjectAccess returns the SelfSubjectAccessReview client of the cluster.
+func (c *FakeCluster) SubjectAccess() typedAuthv1.SelfSubjectAccessReviewInterface {
+	return &clusterSubjectAccess{cluster: c}
+}
+
+// ServerResources returns the discovery client of the cluster.
+func (c *FakeCluster) ServerResources() discovery.ServerResourcesInterface {
+	return &clusterServerResources{cluster: c}
+}
+
+// ClientOptions returns the options wiring the cluster in to client.NewClient.
+func (c *FakeCluster) ClientOptions() []client.ClientOption {
+	return []client.ClientOption{
+		client.WithRESTConfig(FakeConfig),
+		client.WithClientsetFn(FakeClientset),
+		client.WithDynamicClientFn(func(context.Context, *rest.Config) (dynamic.Interface, error) {
+			return c.Dynamic(), nil
+		}),
+		client.WithServerResourcesFn(func(context.Context, kubernetes.Interface) (discovery.ServerResourcesInterface, error) {
+			return c.ServerResources(), nil
+		}),
+		client.WithSubjectAccessFn(func(context.Context, kubernetes.Interface) (typedAuthv1.SelfSubjectAccessReviewInterface, error) {
+			return c.SubjectAccess(), nil
+		}),
+	}
+}
+
+// NewClient creates a client for the cluster, options are applied after ClientOptions.
+func (c *FakeCluster) NewClient(ctx context.Context, options ...client.ClientOption) (*client.Client, error) {
+	return client.NewClient(ctx, append(c.ClientOptions(), options...)...)
+}
+
+type clusterSubjectAccess struct {
+	cluster *FakeCluster
+}
+
+var _ typedAuthv1.SelfSubjectAccessReviewInterface = (*clusterSubjectAccess)(nil)
+
+func (s *clusterSubjectAccess) Create(_ context.Context, review *authv1.SelfSubjectAccessReview, _ metav1.CreateOptions) (*authv1.SelfSubjectAccessReview, error) {
+	attrs := review.Spec.ResourceAttributes
+	if attrs == nil {
+		return nil, apierrors.NewBadRequest("resourceAttributes are required")
+	}
+
+	c := s.cluster
+	c.mu.Lock()
+	c.reviews = append(c.reviews, *attrs)
+	fn, rules := c.accessFn, c.rules
+	c.mu.Unlock()
+
+	result := review.DeepCopy()
+	if fn != nil {
+		allowed, err := fn(attrs)
+		if err != nil {
+			return nil, err
+		}
+		result.Status.Allowed = allowed
+		return result, nil
+	}
+
+	for _, rule := range rules {
+		if rule.allows(attrs) {
+			result.Status.Allowed = true
+			return result, nil
+		}
+	}
+	result.Status.Reason = "no access rule allows the request"
+	return result, nil
+}
+
+type clusterServerResources struct {
+	cluster *FakeCluster
+}
+
+var _ discovery.ServerResourcesInterface = (*clusterServerResources)(nil)
+
+// lists groups the resources by GroupVersion, sorted.
+func (s *clusterServerResources) lists(namespacedOnly bool) []*metav1.APIResourceList {
+	byGroupVersion := map[string]*metav1.APIResourceList{}
+	for _, r := range s.cluster.Resources() {
+		if namespacedOnly && !r.APIResource.Namespaced {
+			continue
+		}
+		gv := r.GroupVersionKind.GroupVersion().String()
+		list, ok := byGroupVersion[gv]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: gv}
+			byGroupVersion[gv] = list
+		}
+		list.APIResources = append(list.APIResources, r.APIResource)
+	}
+
+	lists := make([]*metav1.APIResourceList, 0, len(byGroupVersion))
+	for _, list := range byGroupVersion {
+		lists = append(lists, list)
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].GroupVersion < lists[j].GroupVersion })
+	return lists
+}
+
+func (s *clusterServerResources) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for _, list := range s.lists(false) {
+		if list.GroupVersion == groupVersion {
+			return list, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{}, groupVersion)
+}
+
+func (s *clusterServerResources) ServerResources() ([]*metav1.APIResourceList, error) {
+	return s.lists(false), s.cluster.DiscoveryErr
+}
+
+func (s *clusterServerResources) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	lists := s.lists(false)
+	byName := map[string]*metav1.APIGroup{}
+	groups := []*metav1.APIGroup{}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		group, ok := byName[gv.Group]
+		if !ok {
+			group = &metav1.APIGroup{Name: gv.Group}
+			byName[gv.Group] = group
+			groups = append(groups, group)
+		}
+		version := metav1.GroupVersionForDiscovery{GroupVersion: list.GroupVersion, Version: gv.Version}
+		group.Versions = append(group.Versions, version)
+		if group.PreferredVersion.Version == "" {
+			group.PreferredVersion = version
+		}
+	}
+	return groups, lists, s.cluster.DiscoveryErr
+}
+
+func (s *clusterServerResources) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return s.lists(false), s.cluster.DiscoveryErr
+}
+
+func (s *clusterServerResources) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	return s.lists(true), s.cluster.DiscoveryErr
+}
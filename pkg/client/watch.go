@@ -2,17 +2,26 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
 	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
 	"go.uber.org/zap"
 )
 
-// WatchResource creates a watch for the Resource in the provided namespaces.
+// WatchResource creates a watch for the Resource in the provided namespaces, the existing watches are reused.
 // To watch across all namespaces you can pass in metav1.NamespaceAll.
 func WatchResource(ctx context.Context, client *Client, res resource.Resource, queueEvents bool, namespaces []string) ([]cache.ResourceLister, error) {
+	client.mu.Lock()
+	watcher, closed := client.watcher, client.closed
+	client.mu.Unlock()
+	if closed {
+		return nil, fmt.Errorf("client closed")
+	}
+
 	if hasNamespaceAll(namespaces) {
-		w, err := client.watcher.Watch(ctx, "", res, queueEvents)
+		w, err := watcher.Watch(ctx, "", res, queueEvents)
 		if err != nil {
 			return nil, err
 		}
@@ -21,11 +30,7 @@ func WatchResource(ctx context.Context, client *Client, res resource.Resource, q
 
 	watchDetails := make([]cache.ResourceLister, len(namespaces))
 	for i, ns := range namespaces {
-		client.Logger.Info("creating watch",
-			zap.String("resource", res.Key()),
-			zap.String("namespace", ns),
-		)
-		w, err := client.watcher.Watch(ctx, ns, res, queueEvents)
+		w, err := watcher.Watch(ctx, ns, res, queueEvents)
 		if err != nil {
 			return nil, err
 		}
@@ -40,6 +45,29 @@ func WatchAllResources(ctx context.Context, client *Client, queueEvents bool, na
 	}
 }
 
+// WaitForWatchesSync waits up to timeout for the running watches of the client to complete their initial List.
+// When some watches have not synced in time a *errors.WatchNotSynced listing their keys is returned.
+func WaitForWatchesSync(ctx context.Context, client *Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client.mu.Lock()
+	watcher := client.watcher
+	client.mu.Unlock()
+	if watcher == nil {
+		return nil
+	}
+
+	err := watcher.WaitForSync(ctx)
+	if err != nil {
+		client.Logger.Warn("watches not synced",
+			zap.Duration("timeout", timeout),
+			zap.Error(err),
+		)
+	}
+	return err
+}
+
 func hasNamespaceAll(namespaces []string) bool {
 	for _, ns := range namespaces {
 		if ns == "" {
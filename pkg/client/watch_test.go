@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -72,7 +73,7 @@ func TestWatchResourceErr(t *testing.T) {
 
 }
 
-func TestWatchNamespaceAllErr(t *testing.T) {
+func TestWatchNamespaceAllIgnoresBadEntries(t *testing.T) {
 	cache.ResourceWatches = &sync.Map{}
 
 	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
@@ -99,10 +100,59 @@ func TestWatchNamespaceAllErr(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	cache.ResourceWatches.Store("Version.Kind", "bad-string-should-be-map")
+	cache.ResourceWatches.Store("Version.Kind", "bad-string-should-be-watch-detail")
 
 	wr, err := client.WatchResource(context.TODO(), c, resource.Resource{GroupVersionKind: schema.GroupVersionKind{Version: "Version", Kind: "Kind"}}, false, []string{""})
-	assert.EqualError(t, err, "append, found key: Version.Kind, unable to cast to []*WatchDetail")
-	assert.Nil(t, wr)
+	assert.Nil(t, err)
+	assert.Len(t, wr, 1)
+	assert.Equal(t, []string{""}, wr[0].Namespaces())
+	cache.ResourceWatches = &sync.Map{}
+}
+
+func TestWaitForWatchesSync(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	dsifFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	dsifFake.GenericInformer.SharedIndexInformer.HasSyncedFn = func() bool { return false }
+	dynFake := ctesting.FakeDynamicClient{}
+
+	w, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(dsifFake),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+	watcherFn := func(context.Context, *zap.Logger, dynamic.Interface) (*cache.Watcher, error) {
+		return w, nil
+	}
+
+	c, err := client.NewClient(context.TODO(),
+		client.WithRESTConfig(config),
+		client.WithLogger(zap.NewNop()),
+		client.WithWatcherFn(watcherFn),
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.WaitForWatchesSync(context.TODO(), c, time.Millisecond))
+
+	// the watches of other watchers are not waited for
+	otherFake := wtesting.NewFakeDynamicSharedInformerFactory()
+	otherFake.GenericInformer.SharedIndexInformer.HasSyncedFn = func() bool { return false }
+	other, err := cache.NewWatcher(context.TODO(),
+		cache.WithDynamicClient(dynFake),
+		cache.WithDynamicSharedInformerFactory(otherFake),
+		cache.WithCluster("other"),
+		cache.WithLogger(zap.NewNop()),
+	)
+	assert.Nil(t, err)
+	_, err = other.Watch(context.TODO(), "default", resource.Resource{}, false)
+	assert.Nil(t, err)
+	assert.Nil(t, client.WaitForWatchesSync(context.TODO(), c, time.Millisecond))
+
+	_, err = client.WatchResource(context.TODO(), c, resource.Resource{}, false, []string{"default"})
+	assert.Nil(t, err)
+
+	err = client.WaitForWatchesSync(context.TODO(), c, 100*time.Millisecond)
+	assert.EqualError(t, err, "WatchNotSynced - keys:[////default], context deadline exceeded")
 	cache.ResourceWatches = &sync.Map{}
 }
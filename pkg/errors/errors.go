@@ -1,7 +1,9 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -29,6 +31,21 @@ func (e *NilRESTConfig) Error() string {
 	return "NilRESTConfig - cannot create client, use WithRESTConfig option"
 }
 
+// KubeconfigError is returned when the rest.Config of a kubeconfig context or the in-cluster config can not be loaded.
+type KubeconfigError struct {
+	Kubeconfig string
+	Context    string
+	Err        error
+}
+
+func (e *KubeconfigError) Error() string {
+	return fmt.Sprintf("KubeconfigError - kubeconfig:%v, context:%v, %s", e.Kubeconfig, e.Context, e.Err)
+}
+
+func (e *KubeconfigError) Unwrap() error {
+	return e.Err
+}
+
 type K8SNewForConfig struct {
 	Err error
 }
@@ -59,3 +76,242 @@ func (e *ResourceDiscoveryError) Add(err error) {
 	defer e.mu.Unlock()
 	e.Err = append(e.Err, err)
 }
+
+type ObjectConversionError struct {
+	Name      string
+	Namespace string
+	Err       error
+}
+
+func (e *ObjectConversionError) Error() string {
+	return fmt.Sprintf("ObjectConversionError - namespace:%v, name:%v, %s", e.Namespace, e.Name, e.Err)
+}
+
+func (e *ObjectConversionError) Unwrap() error {
+	return e.Err
+}
+
+// TypedListErrors is returned by a TypedLister List when objects failed to convert, ListErr is the error
+// of the underlying List if any.
+type TypedListErrors struct {
+	ListErr error
+	Errs    []*ObjectConversionError
+}
+
+func (e *TypedListErrors) Error() string {
+	msgs := []string{}
+	if e.ListErr != nil {
+		msgs = append(msgs, e.ListErr.Error())
+	}
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, ",")
+}
+
+// Is reports whether the list error or any of the conversion errors matches target, for errors.Is before Go 1.20.
+func (e *TypedListErrors) Is(target error) bool {
+	if e.ListErr != nil && stderrors.Is(e.ListErr, target) {
+		return true
+	}
+	for _, err := range e.Errs {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the list error or the first conversion error that matches target, for errors.As before Go 1.20.
+func (e *TypedListErrors) As(target interface{}) bool {
+	if e.ListErr != nil && stderrors.As(e.ListErr, target) {
+		return true
+	}
+	for _, err := range e.Errs {
+		if stderrors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+type WatchNotSynced struct {
+	Keys []string
+	Err  error
+}
+
+func (e *WatchNotSynced) Error() string {
+	return fmt.Sprintf("WatchNotSynced - keys:%v, %s", e.Keys, e.Err)
+}
+
+func (e *WatchNotSynced) Unwrap() error {
+	return e.Err
+}
+
+// WatchNotStopped is returned when the Informer or Drain loops of watches did not exit in time.
+type WatchNotStopped struct {
+	Keys []string
+	Err  error
+}
+
+func (e *WatchNotStopped) Error() string {
+	return fmt.Sprintf("WatchNotStopped - keys:%v, %s", e.Keys, e.Err)
+}
+
+func (e *WatchNotStopped) Unwrap() error {
+	return e.Err
+}
+
+// ClientNotClosed is returned when the refreshers or watches of a client did not stop in time.
+type ClientNotClosed struct {
+	Refreshers []string
+	Watches    []string
+	Err        error
+}
+
+func (e *ClientNotClosed) Error() string {
+	return fmt.Sprintf("ClientNotClosed - refreshers:%v, watches:%v, %s", e.Refreshers, e.Watches, e.Err)
+}
+
+func (e *ClientNotClosed) Unwrap() error {
+	return e.Err
+}
+
+type AmbiguousObject struct {
+	Name       string
+	Namespaces []string
+}
+
+func (e *AmbiguousObject) Error() string {
+	return fmt.Sprintf("AmbiguousObject - name:%v, namespaces:%v", e.Name, e.Namespaces)
+}
+
+// ListerError is the error returned by a single ResourceLister when listing across multiple ResourceListers.
+type ListerError struct {
+	Key       string
+	Namespace string
+	Err       error
+}
+
+func (e *ListerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ListerError) Unwrap() error {
+	return e.Err
+}
+
+// ListerErrors collects the errors from each ResourceLister that failed when listing across multiple ResourceListers.
+type ListerErrors struct {
+	Errs []*ListerError
+}
+
+func (e *ListerErrors) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, ",")
+}
+
+// Is reports whether any of the collected errors matches target, for errors.Is before Go 1.20.
+func (e *ListerErrors) Is(target error) bool {
+	for _, err := range e.Errs {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the collected errors that matches target, for errors.As before Go 1.20.
+func (e *ListerErrors) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if stderrors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// InvalidQuery is returned when a query expression cannot be parsed, Position is the byte offset of the error.
+type InvalidQuery struct {
+	Query    string
+	Position int
+	Reason   string
+}
+
+func (e *InvalidQuery) Error() string {
+	return fmt.Sprintf("InvalidQuery - position:%v, reason:%v, query:%v", e.Position, e.Reason, e.Query)
+}
+
+// InvalidSnapshot is returned when a snapshot archive cannot be read, Location is the file and, for
+// JSON lines archives, the line of the error.
+type InvalidSnapshot struct {
+	Location string
+	Err      error
+}
+
+func (e *InvalidSnapshot) Error() string {
+	return fmt.Sprintf("InvalidSnapshot - location:%v, %s", e.Location, e.Err)
+}
+
+func (e *InvalidSnapshot) Unwrap() error {
+	return e.Err
+}
+
+// WatchExists is returned when loading objects under the key of a watch backed by an Informer.
+type WatchExists struct {
+	Key string
+}
+
+func (e *WatchExists) Error() string {
+	return fmt.Sprintf("WatchExists - key:%v", e.Key)
+}
+
+// ClusterError is the error returned by a single cluster when querying across multiple clusters.
+type ClusterError struct {
+	Cluster string
+	Err     error
+}
+
+func (e *ClusterError) Error() string {
+	return fmt.Sprintf("ClusterError - cluster:%v, %s", e.Cluster, e.Err)
+}
+
+func (e *ClusterError) Unwrap() error {
+	return e.Err
+}
+
+// ClusterErrors collects the errors from each cluster that failed when querying across multiple clusters.
+type ClusterErrors struct {
+	Errs []*ClusterError
+}
+
+func (e *ClusterErrors) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, ",")
+}
+
+// Is reports whether any of the collected errors matches target, for errors.Is before Go 1.20.
+func (e *ClusterErrors) Is(target error) bool {
+	for _, err := range e.Errs {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the collected errors that matches target, for errors.As before Go 1.20.
+func (e *ClusterErrors) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if stderrors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -59,3 +59,115 @@ func TestResourceDiscoveryError(t *testing.T) {
 	rdErr.Add(fmt.Errorf("test3"))
 	assert.Equal(t, rdErr.Error(), "ResourceDiscoveryError - [test test2 test3]")
 }
+
+func TestObjectConversionError(t *testing.T) {
+	err := &errors.ObjectConversionError{Namespace: "default", Name: "nginx", Err: fmt.Errorf("test")}
+
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "ObjectConversionError - namespace:default, name:nginx, test")
+	assert.EqualError(t, err.Unwrap(), "test")
+}
+
+func TestWatchNotSyncedError(t *testing.T) {
+	err := &errors.WatchNotSynced{Keys: []string{"default.v1.Pod"}, Err: fmt.Errorf("test")}
+
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "WatchNotSynced - keys:[default.v1.Pod], test")
+	assert.EqualError(t, err.Unwrap(), "test")
+}
+
+func TestWatchNotStoppedError(t *testing.T) {
+	err := &errors.WatchNotStopped{Keys: []string{"default.v1.Pod"}, Err: fmt.Errorf("test")}
+
+	assert.Equal(t, err.Error(), "WatchNotStopped - keys:[default.v1.Pod], test")
+	assert.EqualError(t, err.Unwrap(), "test")
+}
+
+func TestClientNotClosedError(t *testing.T) {
+	err := &errors.ClientNotClosed{Refreshers: []string{"access"}, Watches: []string{"default.v1.Pod"}, Err: fmt.Errorf("test")}
+
+	assert.Equal(t, err.Error(), "ClientNotClosed - refreshers:[access], watches:[default.v1.Pod], test")
+	assert.EqualError(t, err.Unwrap(), "test")
+}
+
+func TestAmbiguousObjectError(t *testing.T) {
+	err := &errors.AmbiguousObject{Name: "nginx", Namespaces: []string{"default", "test"}}
+
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "AmbiguousObject - name:nginx, namespaces:[default test]")
+}
+
+func TestListerErrors(t *testing.T) {
+	notFound := fmt.Errorf("not found")
+	err := &errors.ListerErrors{Errs: []*errors.ListerError{
+		{Key: "default.v1.Pod", Namespace: "default", Err: notFound},
+		{Key: "test.v1.Pod", Namespace: "test", Err: fmt.Errorf("test")},
+	}}
+
+	assert.Equal(t, err.Error(), "not found,test")
+	assert.ErrorIs(t, err, notFound)
+	// errors.Is and errors.As only follow Unwrap() []error from Go 1.20
+	assert.True(t, err.Is(notFound))
+	assert.False(t, err.Is(fmt.Errorf("not found")))
+
+	listerErr := &errors.ListerError{}
+	assert.ErrorAs(t, err, &listerErr)
+	assert.Equal(t, "default", listerErr.Namespace)
+	listerErr = nil
+	assert.True(t, err.As(&listerErr))
+	assert.Equal(t, "default", listerErr.Namespace)
+}
+
+func TestTypedListErrors(t *testing.T) {
+	unavailable := fmt.Errorf("unavailable")
+	err := &errors.TypedListErrors{ListErr: unavailable, Errs: []*errors.ObjectConversionError{
+		{Name: "nginx", Namespace: "default", Err: fmt.Errorf("object kind mismatch")},
+	}}
+
+	assert.Equal(t, err.Error(), "unavailable,ObjectConversionError - namespace:default, name:nginx, object kind mismatch")
+	assert.True(t, err.Is(unavailable))
+	convErr := &errors.ObjectConversionError{}
+	assert.True(t, err.As(&convErr))
+	assert.Equal(t, "nginx", convErr.Name)
+}
+
+func TestInvalidQueryError(t *testing.T) {
+	err := &errors.InvalidQuery{Query: "spec.replicas >", Position: 15, Reason: "expected value"}
+
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "InvalidQuery - position:15, reason:expected value, query:spec.replicas >")
+}
+
+func TestInvalidSnapshotError(t *testing.T) {
+	eof := fmt.Errorf("unexpected end of JSON input")
+	err := &errors.InvalidSnapshot{Location: "snapshot.jsonl:3", Err: eof}
+
+	assert.Equal(t, err.Error(), "InvalidSnapshot - location:snapshot.jsonl:3, unexpected end of JSON input")
+	assert.ErrorIs(t, err, eof)
+}
+
+func TestWatchExistsError(t *testing.T) {
+	err := &errors.WatchExists{Key: "snapshot//v1/pods/default"}
+
+	assert.Equal(t, err.Error(), "WatchExists - key:snapshot//v1/pods/default")
+}
+
+func TestClusterErrors(t *testing.T) {
+	unreachable := fmt.Errorf("connection refused")
+	err := &errors.ClusterErrors{Errs: []*errors.ClusterError{
+		{Cluster: "alpha", Err: unreachable},
+		{Cluster: "beta", Err: fmt.Errorf("forbidden")},
+	}}
+
+	assert.Equal(t, err.Error(), "ClusterError - cluster:alpha, connection refused,ClusterError - cluster:beta, forbidden")
+	assert.ErrorIs(t, err, unreachable)
+	// errors.Is and errors.As only follow Unwrap() []error from Go 1.20
+	assert.True(t, err.Is(unreachable))
+
+	clusterErr := &errors.ClusterError{}
+	assert.ErrorAs(t, err, &clusterErr)
+	assert.Equal(t, "alpha", clusterErr.Cluster)
+	clusterErr = nil
+	assert.True(t, err.As(&clusterErr))
+	assert.Equal(t, "alpha", clusterErr.Cluster)
+}
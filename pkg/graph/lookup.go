@@ -0,0 +1,83 @@
+package graph
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+// Lookup finds cached objects by group and kind for Resolvers.
+type Lookup interface {
+	// KindWatched returns true when objects of the kind are cached.
+	KindWatched(gk schema.GroupKind) bool
+	// Get returns the object and true when it is cached. An empty namespace is used for cluster scoped objects.
+	Get(gk schema.GroupKind, namespace, name string) (*unstructured.Unstructured, bool, error)
+	// List returns the cached objects of the kind matching the selector, NamespaceAll lists every namespace.
+	List(gk schema.GroupKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error)
+}
+
+// CacheLookup returns a Lookup over the unfiltered watches of the named cluster.
+func CacheLookup(cluster string) Lookup {
+	return &cacheLookup{cluster: cluster}
+}
+
+type cacheLookup struct {
+	cluster string
+}
+
+func (l *cacheLookup) KindWatched(gk schema.GroupKind) bool {
+	return cache.IsKindWatched(l.cluster, gk)
+}
+
+func (l *cacheLookup) Get(gk schema.GroupKind, namespace, name string) (*unstructured.Unstructured, bool, error) {
+	if !l.KindWatched(gk) {
+		return nil, false, nil
+	}
+	lister, err := cache.WatchForKind(l.cluster, gk, namespace)
+	if err != nil {
+		// the namespace is not watched
+		return nil, false, nil
+	}
+
+	var obj runtime.Object
+	if namespace == metav1.NamespaceAll {
+		obj, err = lister.Get(name)
+	} else {
+		obj, err = lister.GetNamespaced(namespace, name)
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	u, err := cache.ToUnstructured(obj)
+	return u, err == nil, err
+}
+
+func (l *cacheLookup) List(gk schema.GroupKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	if !l.KindWatched(gk) {
+		return nil, nil
+	}
+	lister, err := cache.WatchForKind(l.cluster, gk, namespace)
+	if err != nil {
+		return nil, nil
+	}
+
+	objs, err := lister.List(selector)
+	list := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		u, convErr := cache.ToUnstructured(obj)
+		if convErr != nil {
+			return nil, convErr
+		}
+		list = append(list, u)
+	}
+	return list, err
+}
@@ -0,0 +1,21 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Option func(*OwnerGraph)
+
+// WithCluster limits the OwnerGraph to the events of the watches of the named cluster.
+func WithCluster(cluster string) Option {
+	return func(g *OwnerGraph) {
+		g.cluster = cluster
+	}
+}
+
+// WithKindWatchedFn overrides how Node.KindWatched is determined, by default the running watches are checked.
+func WithKindWatchedFn(fn func(schema.GroupKind) bool) Option {
+	return func(g *OwnerGraph) {
+		g.kindWatchedFn = fn
+	}
+}
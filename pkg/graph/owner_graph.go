@@ -0,0 +1,366 @@
+// Package graph indexes the relationships between the objects in the watch cache.
+package graph
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+// ObjectReference identifies an object in the graph.
+type ObjectReference struct {
+	UID        types.UID
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+// GroupKind returns the group and kind of the referenced object.
+func (r ObjectReference) GroupKind() schema.GroupKind {
+	return schema.FromAPIVersionAndKind(r.APIVersion, r.Kind).GroupKind()
+}
+
+// Node is an object in the graph.
+type Node struct {
+	ObjectReference
+	// Cached is false when the object has not been seen by a watch and is only known from the
+	// ownerReferences of another object. The Namespace of an object that is not cached is a guess.
+	Cached bool
+	// KindWatched is false when no running watch exists for the kind of the object, for example when
+	// the kind was not discovered or access to it was denied.
+	KindWatched bool
+}
+
+type ownerNode struct {
+	ref    ObjectReference
+	cached bool
+	// watches are the watches caching the object, an object cached by both a namespace watch and a
+	// NamespaceAll watch stays cached until both have deleted it
+	watches  map[cache.WatchKey]struct{}
+	owners   map[types.UID]struct{}
+	children map[types.UID]struct{}
+}
+
+// OwnerGraph indexes the ownerReferences of the objects in the watch cache by UID. Register the OwnerGraph
+// with cache.AddEventHandler to keep it up to date with the watches:
+//
+//	g := graph.NewOwnerGraph()
+//	remove := cache.AddEventHandler(g)
+//	defer remove()
+type OwnerGraph struct {
+	mu    sync.RWMutex
+	nodes map[types.UID]*ownerNode
+
+	cluster       string
+	kindWatchedFn func(schema.GroupKind) bool
+}
+
+var _ cache.EventHandler = (*OwnerGraph)(nil)
+
+// NewOwnerGraph creates an empty OwnerGraph.
+func NewOwnerGraph(options ...Option) *OwnerGraph {
+	g := &OwnerGraph{nodes: map[types.UID]*ownerNode{}}
+	for _, opt := range options {
+		opt(g)
+	}
+	if g.kindWatchedFn == nil {
+		g.kindWatchedFn = func(gk schema.GroupKind) bool {
+			return cache.IsKindWatched(g.cluster, gk)
+		}
+	}
+	return g
+}
+
+// OnAdd adds the object and the edges to its owners.
+func (g *OwnerGraph) OnAdd(key cache.WatchKey, obj runtime.Object) {
+	if key.Cluster != g.cluster {
+		return
+	}
+	g.add(key, obj)
+}
+
+// OnUpdate replaces the edges to the owners of the object.
+func (g *OwnerGraph) OnUpdate(key cache.WatchKey, _, newObj runtime.Object) {
+	if key.Cluster != g.cluster {
+		return
+	}
+	g.add(key, newObj)
+}
+
+// OnDelete removes the object and the edges to its owners once no other watch caches it.
+func (g *OwnerGraph) OnDelete(key cache.WatchKey, obj runtime.Object) {
+	if key.Cluster != g.cluster {
+		return
+	}
+	g.delete(&key, obj)
+}
+
+// Add adds or updates the object and replaces the edges to its owners.
+func (g *OwnerGraph) Add(obj runtime.Object) {
+	g.add(cache.WatchKey{Cluster: g.cluster}, obj)
+}
+
+func (g *OwnerGraph) add(key cache.WatchKey, obj runtime.Object) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil || accessor.GetUID() == "" {
+		return
+	}
+	apiVersion, kind := obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	n := g.node(accessor.GetUID())
+	n.cached = true
+	n.watches[key] = struct{}{}
+	n.ref = ObjectReference{
+		UID:        accessor.GetUID(),
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  accessor.GetNamespace(),
+		Name:       accessor.GetName(),
+	}
+
+	g.removeOwners(n)
+	for _, ref := range accessor.GetOwnerReferences() {
+		owner := g.node(ref.UID)
+		if !owner.cached {
+			owner.ref = ownerReference(ref, accessor.GetNamespace())
+		}
+		owner.children[n.ref.UID] = struct{}{}
+		n.owners[ref.UID] = struct{}{}
+	}
+}
+
+// Delete removes the object and the edges to its owners, regardless of the watches caching it. The
+// object remains in the graph as a Node that is not cached while other objects still reference it as
+// an owner.
+func (g *OwnerGraph) Delete(obj runtime.Object) {
+	g.delete(nil, obj)
+}
+
+// delete removes the watch from the object, a nil key removes every watch.
+func (g *OwnerGraph) delete(key *cache.WatchKey, obj runtime.Object) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	n, ok := g.nodes[accessor.GetUID()]
+	if !ok {
+		return
+	}
+	if key != nil {
+		delete(n.watches, *key)
+	} else {
+		n.watches = map[cache.WatchKey]struct{}{}
+	}
+	if len(n.watches) > 0 {
+		return
+	}
+	n.cached = false
+	g.removeOwners(n)
+	g.prune(n)
+}
+
+// Get returns the Node for the UID.
+func (g *OwnerGraph) Get(uid types.UID) (Node, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	n, ok := g.nodes[uid]
+	if !ok {
+		return Node{}, false
+	}
+	return g.toNode(n), true
+}
+
+// Children returns the objects owned by the UID sorted by kind, namespace and name.
+func (g *OwnerGraph) Children(uid types.UID) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	n, ok := g.nodes[uid]
+	if !ok {
+		return []Node{}
+	}
+	return g.toNodes(n.children)
+}
+
+// Owners returns the owners of the UID sorted by kind, namespace and name.
+func (g *OwnerGraph) Owners(uid types.UID) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	n, ok := g.nodes[uid]
+	if !ok {
+		return []Node{}
+	}
+	return g.toNodes(n.owners)
+}
+
+// Direction is the direction of a walk through the graph.
+type Direction int
+
+const (
+	// Down walks from owners to the objects they own.
+	Down Direction = iota
+	// Up walks from objects to their owners.
+	Up
+)
+
+// WalkFunc is called for each Node visited by Walk with its distance from the starting UID.
+// Returning false stops the walk from visiting the neighbours of the Node.
+type WalkFunc func(node Node, depth int) bool
+
+// Walk visits every Node reachable from the UID in the Direction, breadth first. The starting Node is
+// visited with a depth of 0 and each Node is visited once, even when the references form a cycle.
+func (g *OwnerGraph) Walk(uid types.UID, direction Direction, fn WalkFunc) {
+	type step struct {
+		uid   types.UID
+		depth int
+	}
+
+	visited := map[types.UID]struct{}{uid: {}}
+	queue := []step{{uid: uid}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		node, ok := g.Get(current.uid)
+		if !ok || !fn(node, current.depth) {
+			continue
+		}
+
+		var next []Node
+		if direction == Up {
+			next = g.Owners(current.uid)
+		} else {
+			next = g.Children(current.uid)
+		}
+		for _, n := range next {
+			if _, ok := visited[n.UID]; ok {
+				continue
+			}
+			visited[n.UID] = struct{}{}
+			queue = append(queue, step{uid: n.UID, depth: current.depth + 1})
+		}
+	}
+}
+
+// Descendants returns every object directly or indirectly owned by the UID.
+func (g *OwnerGraph) Descendants(uid types.UID) []Node {
+	return g.collect(uid, Down)
+}
+
+// Ancestors returns every direct or indirect owner of the UID.
+func (g *OwnerGraph) Ancestors(uid types.UID) []Node {
+	return g.collect(uid, Up)
+}
+
+// Roots returns the Nodes without owners, the starting points of the ownership trees.
+func (g *OwnerGraph) Roots() []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	uids := map[types.UID]struct{}{}
+	for uid, n := range g.nodes {
+		if len(n.owners) == 0 {
+			uids[uid] = struct{}{}
+		}
+	}
+	return g.toNodes(uids)
+}
+
+func (g *OwnerGraph) collect(uid types.UID, direction Direction) []Node {
+	nodes := []Node{}
+	g.Walk(uid, direction, func(node Node, depth int) bool {
+		if depth > 0 {
+			nodes = append(nodes, node)
+		}
+		return true
+	})
+	return nodes
+}
+
+// node returns the ownerNode for the UID, creating it when needed. The caller must hold the write lock.
+func (g *OwnerGraph) node(uid types.UID) *ownerNode {
+	n, ok := g.nodes[uid]
+	if !ok {
+		n = &ownerNode{
+			ref:      ObjectReference{UID: uid},
+			watches:  map[cache.WatchKey]struct{}{},
+			owners:   map[types.UID]struct{}{},
+			children: map[types.UID]struct{}{},
+		}
+		g.nodes[uid] = n
+	}
+	return n
+}
+
+// removeOwners removes the edges from the node to its owners, pruning owners that are no longer needed.
+func (g *OwnerGraph) removeOwners(n *ownerNode) {
+	for uid := range n.owners {
+		delete(n.owners, uid)
+		if owner, ok := g.nodes[uid]; ok {
+			delete(owner.children, n.ref.UID)
+			g.prune(owner)
+		}
+	}
+}
+
+// prune removes a node that is not cached and no longer referenced as an owner.
+func (g *OwnerGraph) prune(n *ownerNode) {
+	if !n.cached && len(n.children) == 0 {
+		delete(g.nodes, n.ref.UID)
+	}
+}
+
+func (g *OwnerGraph) toNode(n *ownerNode) Node {
+	return Node{
+		ObjectReference: n.ref,
+		Cached:          n.cached,
+		KindWatched:     g.kindWatchedFn(n.ref.GroupKind()),
+	}
+}
+
+func (g *OwnerGraph) toNodes(uids map[types.UID]struct{}) []Node {
+	nodes := make([]Node, 0, len(uids))
+	for uid := range uids {
+		if n, ok := g.nodes[uid]; ok {
+			nodes = append(nodes, g.toNode(n))
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return nodes
+}
+
+func ownerReference(ref metav1.OwnerReference, namespace string) ObjectReference {
+	return ObjectReference{
+		UID:        ref.UID,
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Namespace:  namespace,
+		Name:       ref.Name,
+	}
+}
@@ -0,0 +1,144 @@
+package graph_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/graph"
+)
+
+func newObject(apiVersion, kind, name, uid string, owners ...*unstructured.Unstructured) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetUID(types.UID(uid))
+
+	refs := []metav1.OwnerReference{}
+	for _, owner := range owners {
+		refs = append(refs, metav1.OwnerReference{
+			APIVersion: owner.GetAPIVersion(),
+			Kind:       owner.GetKind(),
+			Name:       owner.GetName(),
+			UID:        owner.GetUID(),
+		})
+	}
+	u.SetOwnerReferences(refs)
+	return u
+}
+
+func nodeNames(nodes []graph.Node) []string {
+	names := []string{}
+	for _, n := range nodes {
+		names = append(names, n.Name)
+	}
+	return names
+}
+
+func TestOwnerGraph(t *testing.T) {
+	g := graph.NewOwnerGraph(graph.WithKindWatchedFn(func(gk schema.GroupKind) bool {
+		return gk.Kind != "Deployment"
+	}))
+
+	deployment := newObject("apps/v1", "Deployment", "nginx", "d1")
+	replicaSet := newObject("apps/v1", "ReplicaSet", "nginx-abc", "rs1", deployment)
+	pod1 := newObject("v1", "Pod", "nginx-abc-1", "p1", replicaSet)
+	pod2 := newObject("v1", "Pod", "nginx-abc-2", "p2", replicaSet)
+
+	key := cache.WatchKey{}
+	g.OnAdd(key, pod1)
+	g.OnAdd(key, pod2)
+	g.OnAdd(key, replicaSet)
+
+	assert.Equal(t, []string{"nginx-abc-1", "nginx-abc-2"}, nodeNames(g.Children("rs1")))
+	assert.Equal(t, []string{"nginx-abc"}, nodeNames(g.Owners("p1")))
+
+	// the deployment is not watched, it is only known from the ownerReferences of the replicaset
+	owners := g.Owners("rs1")
+	assert.Len(t, owners, 1)
+	assert.Equal(t, "nginx", owners[0].Name)
+	assert.Equal(t, "Deployment", owners[0].Kind)
+	assert.False(t, owners[0].Cached)
+	assert.False(t, owners[0].KindWatched)
+
+	assert.Equal(t, []string{"nginx-abc", "nginx-abc-1", "nginx-abc-2"}, nodeNames(g.Descendants("d1")))
+	assert.Equal(t, []string{"nginx-abc", "nginx"}, nodeNames(g.Ancestors("p2")))
+	assert.Equal(t, []string{"nginx"}, nodeNames(g.Roots()))
+
+	depths := map[string]int{}
+	g.Walk("d1", graph.Down, func(node graph.Node, depth int) bool {
+		depths[node.Name] = depth
+		return node.Kind != "ReplicaSet"
+	})
+	assert.Equal(t, map[string]int{"nginx": 0, "nginx-abc": 1}, depths)
+
+	// events from other clusters are ignored
+	g.OnAdd(cache.WatchKey{Cluster: "other"}, newObject("v1", "Pod", "other", "o1", replicaSet))
+	assert.Len(t, g.Children("rs1"), 2)
+
+	// removing the ownerReference on update removes the edge
+	pod2Orphan := newObject("v1", "Pod", "nginx-abc-2", "p2")
+	g.OnUpdate(key, pod2, pod2Orphan)
+	assert.Equal(t, []string{"nginx-abc-1"}, nodeNames(g.Children("rs1")))
+	assert.Empty(t, g.Owners("p2"))
+
+	// a deleted owner stays in the graph while it is still referenced
+	g.OnDelete(key, replicaSet)
+	n, ok := g.Get("rs1")
+	assert.True(t, ok)
+	assert.False(t, n.Cached)
+	assert.Equal(t, "nginx-abc", n.Name)
+	_, ok = g.Get("d1")
+	assert.False(t, ok)
+
+	g.OnDelete(key, pod1)
+	_, ok = g.Get("rs1")
+	assert.False(t, ok)
+	assert.Empty(t, g.Children("rs1"))
+}
+
+func TestOwnerGraphCycle(t *testing.T) {
+	g := graph.NewOwnerGraph(graph.WithKindWatchedFn(func(schema.GroupKind) bool { return true }))
+
+	a := newObject("v1", "ConfigMap", "a", "a")
+	b := newObject("v1", "ConfigMap", "b", "b", a)
+	a = newObject("v1", "ConfigMap", "a", "a", b)
+	g.Add(a)
+	g.Add(b)
+
+	assert.Equal(t, []string{"b"}, nodeNames(g.Descendants("a")))
+	assert.Equal(t, []string{"b"}, nodeNames(g.Ancestors("a")))
+	assert.Empty(t, g.Roots())
+}
+
+func TestOwnerGraphOverlappingWatches(t *testing.T) {
+	g := graph.NewOwnerGraph(graph.WithKindWatchedFn(func(schema.GroupKind) bool { return true }))
+
+	replicaSet := newObject("apps/v1", "ReplicaSet", "nginx-abc", "rs1")
+	pod := newObject("v1", "Pod", "nginx-abc-1", "p1", replicaSet)
+
+	namespaced := cache.WatchKey{Namespace: "default"}
+	all := cache.WatchKey{}
+	g.OnAdd(namespaced, pod)
+	g.OnAdd(all, pod)
+	g.OnAdd(all, replicaSet)
+
+	// stopping the namespace watch keeps the pod cached by the NamespaceAll watch
+	g.OnDelete(namespaced, pod)
+	n, ok := g.Get("p1")
+	assert.True(t, ok)
+	assert.True(t, n.Cached)
+	assert.Equal(t, []string{"nginx-abc-1"}, nodeNames(g.Children("rs1")))
+
+	g.OnDelete(all, pod)
+	_, ok = g.Get("p1")
+	assert.False(t, ok)
+	assert.Empty(t, g.Children("rs1"))
+}
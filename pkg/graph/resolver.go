@@ -0,0 +1,251 @@
+package graph
+
+import (
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+// Relationship is a link between two objects that is not expressed by an ownerReference,
+// such as a Service selecting Pods or a Pod mounting a ConfigMap.
+type Relationship struct {
+	// Type describes the link from the From object to the To object, for example RelationshipSelects.
+	Type string
+	From ObjectReference
+	// To is the related object. When To.Cached is false the object was not found in the cache and
+	// only the kind, namespace and name from the From object are known.
+	To Node
+}
+
+const (
+	RelationshipSelects    = "selects"
+	RelationshipUses       = "uses"
+	RelationshipRoutesTo   = "routes-to"
+	RelationshipBoundTo    = "bound-to"
+	RelationshipScheduled  = "scheduled-on"
+	RelationshipRunsAs     = "runs-as"
+	RelationshipBinds      = "binds"
+	RelationshipReferences = "references"
+)
+
+// Resolver returns the Relationships from the object to other objects, using the Lookup to find them.
+type Resolver func(lookup Lookup, obj *unstructured.Unstructured) ([]Relationship, error)
+
+// Targets are the kinds a Resolver links objects to. ResolveIncoming only runs the Resolver on the objects
+// that can link to the object it is resolving for.
+type Targets struct {
+	Kinds []schema.GroupKind
+	// SameNamespace is set when the namespaced objects linked to are always in the namespace of the object.
+	SameNamespace bool
+}
+
+func (t *Targets) includes(gk schema.GroupKind) bool {
+	for _, kind := range t.Kinds {
+		if kind == gk {
+			return true
+		}
+	}
+	return false
+}
+
+// registeredResolver is a Resolver and its Targets, nil when the kinds it links to are unknown.
+type registeredResolver struct {
+	resolve Resolver
+	targets *Targets
+}
+
+// ResolverRegistry holds the Resolvers for each kind. Use NewResolverRegistry to create a
+// ResolverRegistry with the built-in Resolvers for the core kinds.
+type ResolverRegistry struct {
+	mu        sync.RWMutex
+	lookup    Lookup
+	resolvers map[schema.GroupKind][]registeredResolver
+}
+
+// NewResolverRegistry creates a ResolverRegistry with the built-in Resolvers using the Lookup to find objects.
+func NewResolverRegistry(lookup Lookup) *ResolverRegistry {
+	r := &ResolverRegistry{
+		lookup:    lookup,
+		resolvers: map[schema.GroupKind][]registeredResolver{},
+	}
+	for gk, builtin := range builtinResolvers {
+		r.RegisterTargets(gk, builtin.resolve, *builtin.targets)
+	}
+	return r
+}
+
+// Register adds a Resolver for the group and kind, such as a custom resource. Every Resolver registered
+// for a kind is used. ResolveIncoming runs the Resolver on every object of the kind, use RegisterTargets
+// when the kinds it links to are known.
+func (r *ResolverRegistry) Register(gk schema.GroupKind, resolver Resolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolvers[gk] = append(r.resolvers[gk], registeredResolver{resolve: resolver})
+}
+
+// RegisterTargets adds a Resolver for the group and kind that only links to the Targets.
+func (r *ResolverRegistry) RegisterTargets(gk schema.GroupKind, resolver Resolver, targets Targets) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolvers[gk] = append(r.resolvers[gk], registeredResolver{resolve: resolver, targets: &targets})
+}
+
+// Kinds returns the kinds with a registered Resolver.
+func (r *ResolverRegistry) Kinds() []schema.GroupKind {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kinds := make([]schema.GroupKind, 0, len(r.resolvers))
+	for gk := range r.resolvers {
+		kinds = append(kinds, gk)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].String() < kinds[j].String()
+	})
+	return kinds
+}
+
+// Resolve returns the Relationships from the object to other objects. Relationships found before a
+// Resolver fails are returned along with the error.
+func (r *ResolverRegistry) Resolve(obj runtime.Object) ([]Relationship, error) {
+	u, err := cache.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	resolvers := r.resolvers[u.GroupVersionKind().GroupKind()]
+	r.mu.RUnlock()
+
+	return r.resolve(resolvers, u)
+}
+
+func (r *ResolverRegistry) resolve(resolvers []registeredResolver, obj *unstructured.Unstructured) ([]Relationship, error) {
+	relationships := []Relationship{}
+	errs := []error{}
+	for _, resolver := range resolvers {
+		found, err := resolver.resolve(r.lookup, obj)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		relationships = append(relationships, found...)
+	}
+	return relationships, utilerrors.NewAggregate(errs)
+}
+
+// ResolveIncoming returns the Relationships from other cached objects to the object, for example the
+// Services selecting a Pod. The cached objects of the kinds with a Resolver that can link to the kind of
+// the object are resolved, only those in the namespace of the object when the Resolvers link within
+// their namespace.
+func (r *ResolverRegistry) ResolveIncoming(obj runtime.Object) ([]Relationship, error) {
+	target, err := cache.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	targetGK := target.GroupVersionKind().GroupKind()
+
+	relationships := []Relationship{}
+	errs := []error{}
+	for _, gk := range r.Kinds() {
+		resolvers, namespace := r.incomingResolvers(gk, targetGK, target.GetNamespace())
+		if len(resolvers) == 0 {
+			continue
+		}
+
+		sources, err := r.lookup.List(gk, namespace, labels.Everything())
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, source := range sources {
+			found, err := r.resolve(resolvers, source)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			for _, rel := range found {
+				if rel.To.GroupKind() == targetGK && rel.To.Namespace == target.GetNamespace() && rel.To.Name == target.GetName() {
+					relationships = append(relationships, rel)
+				}
+			}
+		}
+	}
+	return relationships, utilerrors.NewAggregate(errs)
+}
+
+// incomingResolvers returns the Resolvers of the kind that can link to the target kind and the namespace
+// to search for objects of the kind, NamespaceAll unless every Resolver links within its namespace.
+func (r *ResolverRegistry) incomingResolvers(gk, targetGK schema.GroupKind, targetNamespace string) ([]registeredResolver, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resolvers := []registeredResolver{}
+	namespace := targetNamespace
+	for _, resolver := range r.resolvers[gk] {
+		if resolver.targets != nil && !resolver.targets.includes(targetGK) {
+			continue
+		}
+		if resolver.targets == nil || !resolver.targets.SameNamespace {
+			namespace = metav1.NamespaceAll
+		}
+		resolvers = append(resolvers, resolver)
+	}
+	return resolvers, namespace
+}
+
+// NewRelationship creates a Relationship from the object to the named object of the kind, looking it up
+// in the cache. An empty namespace is used for cluster scoped objects.
+func NewRelationship(lookup Lookup, relType string, from *unstructured.Unstructured, to schema.GroupVersionKind, namespace, name string) (Relationship, error) {
+	rel := Relationship{
+		Type: relType,
+		From: objectReference(from),
+		To: Node{
+			ObjectReference: ObjectReference{
+				APIVersion: to.GroupVersion().String(),
+				Kind:       to.Kind,
+				Namespace:  namespace,
+				Name:       name,
+			},
+			KindWatched: lookup.KindWatched(to.GroupKind()),
+		},
+	}
+
+	obj, found, err := lookup.Get(to.GroupKind(), namespace, name)
+	if err != nil || !found {
+		return rel, err
+	}
+	rel.To.ObjectReference = objectReference(obj)
+	rel.To.Cached = true
+	return rel, nil
+}
+
+// SelectRelationships creates a Relationship from the object to each cached object of the kind in the
+// namespace matching the selector.
+func SelectRelationships(lookup Lookup, relType string, from *unstructured.Unstructured, to schema.GroupKind, namespace string, selector labels.Selector) ([]Relationship, error) {
+	objs, err := lookup.List(to, namespace, selector)
+	relationships := make([]Relationship, 0, len(objs))
+	for _, obj := range objs {
+		relationships = append(relationships, Relationship{
+			Type: relType,
+			From: objectReference(from),
+			To:   Node{ObjectReference: objectReference(obj), Cached: true, KindWatched: true},
+		})
+	}
+	return relationships, err
+}
+
+func objectReference(u *unstructured.Unstructured) ObjectReference {
+	return ObjectReference{
+		UID:        u.GetUID(),
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+	}
+}
@@ -0,0 +1,240 @@
+package graph_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/graph"
+)
+
+// fakeLookup serves the objects of the watched kinds.
+type fakeLookup struct {
+	objects []*unstructured.Unstructured
+	watched map[schema.GroupKind]bool
+}
+
+func (l *fakeLookup) KindWatched(gk schema.GroupKind) bool {
+	return l.watched[gk]
+}
+
+func (l *fakeLookup) Get(gk schema.GroupKind, namespace, name string) (*unstructured.Unstructured, bool, error) {
+	for _, obj := range l.objects {
+		if obj.GroupVersionKind().GroupKind() == gk && obj.GetNamespace() == namespace && obj.GetName() == name && l.watched[gk] {
+			return obj, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (l *fakeLookup) List(gk schema.GroupKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	list := []*unstructured.Unstructured{}
+	for _, obj := range l.objects {
+		if obj.GroupVersionKind().GroupKind() == gk && (namespace == "" || obj.GetNamespace() == namespace) &&
+			selector.Matches(labels.Set(obj.GetLabels())) && l.watched[gk] {
+			list = append(list, obj)
+		}
+	}
+	return list, nil
+}
+
+func newResolverObject(apiVersion, kind, namespace, name string, content map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: content}
+	if u.Object == nil {
+		u.Object = map[string]interface{}{}
+	}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func relationships(rels []graph.Relationship) []string {
+	list := []string{}
+	for _, rel := range rels {
+		list = append(list, fmt.Sprintf("%s %s/%s cached:%v watched:%v", rel.Type, rel.To.Kind, rel.To.Name, rel.To.Cached, rel.To.KindWatched))
+	}
+	return list
+}
+
+func TestResolverRegistryBuiltin(t *testing.T) {
+	pod := newResolverObject("v1", "Pod", "default", "nginx", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"nodeName":           "node-1",
+			"serviceAccountName": "nginx",
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "nginx-config"}},
+				map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "nginx-data"}},
+			},
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":    "nginx",
+					"envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "nginx-secret"}}},
+					"env": []interface{}{
+						map[string]interface{}{"name": "A", "valueFrom": map[string]interface{}{"configMapKeyRef": map[string]interface{}{"name": "nginx-config"}}},
+					},
+				},
+			},
+		},
+	})
+	pod.SetLabels(map[string]string{"app": "nginx"})
+
+	service := newResolverObject("v1", "Service", "default", "nginx", map[string]interface{}{
+		"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "nginx"}},
+	})
+	ingress := newResolverObject("networking.k8s.io/v1", "Ingress", "default", "nginx", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"rules": []interface{}{map[string]interface{}{"http": map[string]interface{}{"paths": []interface{}{
+				map[string]interface{}{"backend": map[string]interface{}{"service": map[string]interface{}{"name": "nginx"}}},
+			}}}},
+		},
+	})
+	pvc := newResolverObject("v1", "PersistentVolumeClaim", "default", "nginx-data", map[string]interface{}{
+		"spec": map[string]interface{}{"volumeName": "pv-1"},
+	})
+	binding := newResolverObject("rbac.authorization.k8s.io/v1", "RoleBinding", "default", "nginx", map[string]interface{}{
+		"roleRef": map[string]interface{}{"kind": "ClusterRole", "name": "view"},
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "nginx"},
+			map[string]interface{}{"kind": "User", "name": "jane"},
+		},
+	})
+	configMap := newResolverObject("v1", "ConfigMap", "default", "nginx-config", nil)
+
+	lookup := &fakeLookup{
+		objects: []*unstructured.Unstructured{pod, service, ingress, pvc, binding, configMap},
+		watched: map[schema.GroupKind]bool{
+			{Kind: "Pod"}:                                             true,
+			{Kind: "Service"}:                                         true,
+			{Kind: "ConfigMap"}:                                       true,
+			{Kind: "PersistentVolumeClaim"}:                           true,
+			{Group: "networking.k8s.io", Kind: "Ingress"}:             true,
+			{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}: true,
+		},
+	}
+	registry := graph.NewResolverRegistry(lookup)
+
+	tests := []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		expected []string
+	}{
+		{
+			name: "pod",
+			obj:  pod,
+			expected: []string{
+				"uses ConfigMap/nginx-config cached:true watched:true",
+				"uses PersistentVolumeClaim/nginx-data cached:true watched:true",
+				"uses Secret/nginx-secret cached:false watched:false",
+				"runs-as ServiceAccount/nginx cached:false watched:false",
+				"scheduled-on Node/node-1 cached:false watched:false",
+			},
+		},
+		{name: "service", obj: service, expected: []string{"selects Pod/nginx cached:true watched:true"}},
+		{name: "ingress", obj: ingress, expected: []string{"routes-to Service/nginx cached:true watched:true"}},
+		{name: "pvc", obj: pvc, expected: []string{"bound-to PersistentVolume/pv-1 cached:false watched:false"}},
+		{
+			name: "rolebinding",
+			obj:  binding,
+			expected: []string{
+				"binds ServiceAccount/nginx cached:false watched:false",
+				"references ClusterRole/view cached:false watched:false",
+			},
+		},
+		{name: "no resolver", obj: configMap, expected: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rels, err := registry.Resolve(tc.obj)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, relationships(rels))
+		})
+	}
+
+	incoming, err := registry.ResolveIncoming(pod)
+	assert.Nil(t, err)
+	assert.Len(t, incoming, 1)
+	assert.Equal(t, "Service", incoming[0].From.Kind)
+
+	incoming, err = registry.ResolveIncoming(configMap)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"uses ConfigMap/nginx-config cached:true watched:true"}, relationships(incoming))
+}
+
+func TestResolverRegistryRegister(t *testing.T) {
+	widgetGK := schema.GroupKind{Group: "example.com", Kind: "Widget"}
+	widget := newResolverObject("example.com/v1", "Widget", "default", "w", map[string]interface{}{
+		"spec": map[string]interface{}{"configMap": "settings"},
+	})
+	configMap := newResolverObject("v1", "ConfigMap", "default", "settings", nil)
+
+	lookup := &fakeLookup{
+		objects: []*unstructured.Unstructured{widget, configMap},
+		watched: map[schema.GroupKind]bool{widgetGK: true, {Kind: "ConfigMap"}: true},
+	}
+	registry := graph.NewResolverRegistry(lookup)
+	registry.Register(widgetGK, func(lookup graph.Lookup, obj *unstructured.Unstructured) ([]graph.Relationship, error) {
+		name, _, _ := unstructured.NestedString(obj.Object, "spec", "configMap")
+		rel, err := graph.NewRelationship(lookup, graph.RelationshipUses, obj, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, obj.GetNamespace(), name)
+		return []graph.Relationship{rel}, err
+	})
+	assert.Contains(t, registry.Kinds(), widgetGK)
+
+	rels, err := registry.Resolve(widget)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"uses ConfigMap/settings cached:true watched:true"}, relationships(rels))
+	assert.Equal(t, "Widget", rels[0].From.Kind)
+}
+
+// listRecorder records the kinds and namespaces listed from the Lookup.
+type listRecorder struct {
+	*fakeLookup
+	lists []string
+}
+
+func (l *listRecorder) List(gk schema.GroupKind, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	l.lists = append(l.lists, gk.String()+"/"+namespace)
+	return l.fakeLookup.List(gk, namespace, selector)
+}
+
+func TestResolveIncomingTargets(t *testing.T) {
+	configMap := newResolverObject("v1", "ConfigMap", "default", "settings", nil)
+	pod := newResolverObject("v1", "Pod", "default", "nginx", map[string]interface{}{
+		"spec": map[string]interface{}{"volumes": []interface{}{
+			map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "settings"}},
+		}},
+	})
+	other := newResolverObject("v1", "Pod", "test", "nginx", map[string]interface{}{
+		"spec": map[string]interface{}{"volumes": []interface{}{
+			map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "settings"}},
+		}},
+	})
+	lookup := &listRecorder{fakeLookup: &fakeLookup{
+		objects: []*unstructured.Unstructured{configMap, pod, other},
+		watched: map[schema.GroupKind]bool{{Kind: "Pod"}: true, {Kind: "ConfigMap"}: true},
+	}}
+	registry := graph.NewResolverRegistry(lookup)
+
+	// only the Pods of the namespace can use the ConfigMap
+	incoming, err := registry.ResolveIncoming(configMap)
+	assert.Nil(t, err)
+	assert.Len(t, incoming, 1)
+	assert.Equal(t, "default", incoming[0].From.Namespace)
+	assert.Equal(t, []string{"Pod/default"}, lookup.lists)
+
+	// the kinds linked to by a Resolver without Targets are unknown, all of its objects are resolved
+	widgetGK := schema.GroupKind{Group: "example.com", Kind: "Widget"}
+	registry.Register(widgetGK, func(graph.Lookup, *unstructured.Unstructured) ([]graph.Relationship, error) {
+		return nil, nil
+	})
+	lookup.lists = nil
+	_, err = registry.ResolveIncoming(configMap)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Pod/default", "Widget.example.com/"}, lookup.lists)
+}
@@ -0,0 +1,229 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var (
+	podGVK                = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	serviceGVK            = schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+	configMapGVK          = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	secretGVK             = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	pvcGVK                = schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}
+	pvGVK                 = schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolume"}
+	nodeGVK               = schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+	serviceAccountGVK     = schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}
+	ingressGVK            = schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+	extensionsIngressGVK  = schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}
+	roleGVK               = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}
+	clusterRoleGVK        = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	roleBindingGVK        = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}
+	clusterRoleBindingGVK = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}
+)
+
+var builtinResolvers = map[schema.GroupKind]registeredResolver{
+	serviceGVK.GroupKind(): {
+		resolve: resolveService,
+		targets: &Targets{Kinds: kinds(podGVK), SameNamespace: true},
+	},
+	podGVK.GroupKind(): {
+		resolve: resolvePod,
+		targets: &Targets{Kinds: kinds(configMapGVK, secretGVK, pvcGVK, serviceAccountGVK, nodeGVK), SameNamespace: true},
+	},
+	ingressGVK.GroupKind(): {
+		resolve: resolveIngress,
+		targets: &Targets{Kinds: kinds(serviceGVK), SameNamespace: true},
+	},
+	extensionsIngressGVK.GroupKind(): {
+		resolve: resolveIngress,
+		targets: &Targets{Kinds: kinds(serviceGVK), SameNamespace: true},
+	},
+	pvcGVK.GroupKind(): {
+		resolve: resolvePersistentVolumeClaim,
+		targets: &Targets{Kinds: kinds(pvGVK), SameNamespace: true},
+	},
+	// subjects can be ServiceAccounts of other namespaces
+	roleBindingGVK.GroupKind(): {
+		resolve: resolveRoleBinding,
+		targets: &Targets{Kinds: kinds(serviceAccountGVK, roleGVK, clusterRoleGVK)},
+	},
+	clusterRoleBindingGVK.GroupKind(): {
+		resolve: resolveRoleBinding,
+		targets: &Targets{Kinds: kinds(serviceAccountGVK, clusterRoleGVK)},
+	},
+}
+
+func kinds(gvks ...schema.GroupVersionKind) []schema.GroupKind {
+	list := make([]schema.GroupKind, 0, len(gvks))
+	for _, gvk := range gvks {
+		list = append(list, gvk.GroupKind())
+	}
+	return list
+}
+
+// reference is a named object found in a field of another object.
+type reference struct {
+	relType   string
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+// resolveReferences creates a Relationship for each unique reference with a name.
+func resolveReferences(lookup Lookup, from *unstructured.Unstructured, refs []reference) ([]Relationship, error) {
+	seen := map[reference]struct{}{}
+	relationships := []Relationship{}
+	errs := []error{}
+	for _, ref := range refs {
+		if _, ok := seen[ref]; ok || ref.name == "" {
+			continue
+		}
+		seen[ref] = struct{}{}
+
+		rel, err := NewRelationship(lookup, ref.relType, from, ref.gvk, ref.namespace, ref.name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		relationships = append(relationships, rel)
+	}
+	return relationships, utilerrors.NewAggregate(errs)
+}
+
+// resolveService links a Service to the Pods matching its selector.
+func resolveService(lookup Lookup, obj *unstructured.Unstructured) ([]Relationship, error) {
+	selector, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector")
+	if len(selector) == 0 {
+		return nil, nil
+	}
+	return SelectRelationships(lookup, RelationshipSelects, obj, podGVK.GroupKind(), obj.GetNamespace(), labels.SelectorFromSet(selector))
+}
+
+// resolvePod links a Pod to the ConfigMaps, Secrets and PersistentVolumeClaims used by its volumes and
+// containers, its ServiceAccount and the Node it is scheduled on.
+func resolvePod(lookup Lookup, obj *unstructured.Unstructured) ([]Relationship, error) {
+	ns := obj.GetNamespace()
+	refs := []reference{}
+	add := func(relType string, gvk schema.GroupVersionKind, namespace string, name string) {
+		refs = append(refs, reference{relType: relType, gvk: gvk, namespace: namespace, name: name})
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumes")
+	for _, volume := range maps(volumes) {
+		add(RelationshipUses, configMapGVK, ns, nestedString(volume, "configMap", "name"))
+		add(RelationshipUses, secretGVK, ns, nestedString(volume, "secret", "secretName"))
+		add(RelationshipUses, pvcGVK, ns, nestedString(volume, "persistentVolumeClaim", "claimName"))
+
+		sources, _, _ := unstructured.NestedSlice(volume, "projected", "sources")
+		for _, source := range maps(sources) {
+			add(RelationshipUses, configMapGVK, ns, nestedString(source, "configMap", "name"))
+			add(RelationshipUses, secretGVK, ns, nestedString(source, "secret", "name"))
+		}
+	}
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", field)
+		for _, container := range maps(containers) {
+			envFrom, _, _ := unstructured.NestedSlice(container, "envFrom")
+			for _, source := range maps(envFrom) {
+				add(RelationshipUses, configMapGVK, ns, nestedString(source, "configMapRef", "name"))
+				add(RelationshipUses, secretGVK, ns, nestedString(source, "secretRef", "name"))
+			}
+			env, _, _ := unstructured.NestedSlice(container, "env")
+			for _, e := range maps(env) {
+				add(RelationshipUses, configMapGVK, ns, nestedString(e, "valueFrom", "configMapKeyRef", "name"))
+				add(RelationshipUses, secretGVK, ns, nestedString(e, "valueFrom", "secretKeyRef", "name"))
+			}
+		}
+	}
+
+	pullSecrets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "imagePullSecrets")
+	for _, secret := range maps(pullSecrets) {
+		add(RelationshipUses, secretGVK, ns, nestedString(secret, "name"))
+	}
+
+	add(RelationshipRunsAs, serviceAccountGVK, ns, nestedString(obj.Object, "spec", "serviceAccountName"))
+	add(RelationshipScheduled, nodeGVK, "", nestedString(obj.Object, "spec", "nodeName"))
+
+	return resolveReferences(lookup, obj, refs)
+}
+
+// resolveIngress links an Ingress to the Services of its backends, supporting both the
+// networking.k8s.io/v1 and the older serviceName backend fields.
+func resolveIngress(lookup Lookup, obj *unstructured.Unstructured) ([]Relationship, error) {
+	backends := []map[string]interface{}{}
+	for _, field := range []string{"defaultBackend", "backend"} {
+		if backend, ok, _ := unstructured.NestedMap(obj.Object, "spec", field); ok {
+			backends = append(backends, backend)
+		}
+	}
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	for _, rule := range maps(rules) {
+		paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+		for _, path := range maps(paths) {
+			if backend, ok, _ := unstructured.NestedMap(path, "backend"); ok {
+				backends = append(backends, backend)
+			}
+		}
+	}
+
+	refs := []reference{}
+	for _, backend := range backends {
+		name := nestedString(backend, "service", "name")
+		if name == "" {
+			name = nestedString(backend, "serviceName")
+		}
+		refs = append(refs, reference{relType: RelationshipRoutesTo, gvk: serviceGVK, namespace: obj.GetNamespace(), name: name})
+	}
+	return resolveReferences(lookup, obj, refs)
+}
+
+// resolvePersistentVolumeClaim links a PersistentVolumeClaim to its bound PersistentVolume.
+func resolvePersistentVolumeClaim(lookup Lookup, obj *unstructured.Unstructured) ([]Relationship, error) {
+	return resolveReferences(lookup, obj, []reference{
+		{relType: RelationshipBoundTo, gvk: pvGVK, name: nestedString(obj.Object, "spec", "volumeName")},
+	})
+}
+
+// resolveRoleBinding links a RoleBinding or ClusterRoleBinding to its ServiceAccount subjects and its role.
+func resolveRoleBinding(lookup Lookup, obj *unstructured.Unstructured) ([]Relationship, error) {
+	refs := []reference{}
+
+	subjects, _, _ := unstructured.NestedSlice(obj.Object, "subjects")
+	for _, subject := range maps(subjects) {
+		if nestedString(subject, "kind") != serviceAccountGVK.Kind {
+			continue
+		}
+		namespace := nestedString(subject, "namespace")
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		refs = append(refs, reference{relType: RelationshipBinds, gvk: serviceAccountGVK, namespace: namespace, name: nestedString(subject, "name")})
+	}
+
+	switch nestedString(obj.Object, "roleRef", "kind") {
+	case roleGVK.Kind:
+		refs = append(refs, reference{relType: RelationshipReferences, gvk: roleGVK, namespace: obj.GetNamespace(), name: nestedString(obj.Object, "roleRef", "name")})
+	case clusterRoleGVK.Kind:
+		refs = append(refs, reference{relType: RelationshipReferences, gvk: clusterRoleGVK, name: nestedString(obj.Object, "roleRef", "name")})
+	}
+
+	return resolveReferences(lookup, obj, refs)
+}
+
+func maps(list []interface{}) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func nestedString(obj map[string]interface{}, fields ...string) string {
+	s, _, _ := unstructured.NestedString(obj, fields...)
+	return s
+}
@@ -0,0 +1,190 @@
+package health
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var builtinEvaluators = map[schema.GroupKind]Evaluator{
+	{Kind: "Pod"}:                        evaluatePod,
+	{Group: "apps", Kind: "Deployment"}:  evaluateDeployment,
+	{Group: "apps", Kind: "StatefulSet"}: evaluateStatefulSet,
+	{Group: "apps", Kind: "DaemonSet"}:   evaluateDaemonSet,
+	{Group: "batch", Kind: "Job"}:        evaluateJob,
+	{Kind: "PersistentVolumeClaim"}:      evaluatePersistentVolumeClaim,
+}
+
+// degradedWaitingReasons are container waiting reasons that will not resolve without intervention.
+var degradedWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+func evaluatePod(obj *unstructured.Unstructured) Health {
+	if obj.GetDeletionTimestamp() != nil {
+		return progressing("pod is terminating")
+	}
+
+	phase := nestedString(obj.Object, "status", "phase")
+	switch phase {
+	case "Succeeded":
+		return healthy("pod completed")
+	case "Failed":
+		reason := nestedString(obj.Object, "status", "reason")
+		if reason == "" {
+			reason = "pod failed"
+		}
+		return degraded("%s", reason)
+	}
+
+	notReady := 0
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(obj.Object, "status", field)
+		for _, item := range statuses {
+			status, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name := nestedString(status, "name")
+			if reason := nestedString(status, "state", "waiting", "reason"); degradedWaitingReasons[reason] {
+				return degraded("container %s is waiting: %s", name, reason)
+			}
+			if reason := nestedString(status, "state", "terminated", "reason"); field == "containerStatuses" && reason == "Error" {
+				return degraded("container %s terminated with an error", name)
+			}
+			if ready, _, _ := unstructured.NestedBool(status, "ready"); !ready && field == "containerStatuses" {
+				notReady++
+			}
+		}
+	}
+
+	switch {
+	case phase == "Pending":
+		return progressing("pod is pending")
+	case phase != "Running":
+		return Health{State: Unknown, Reasons: []string{"pod phase is " + phase}}
+	case notReady > 0:
+		return progressing("%d containers are not ready", notReady)
+	}
+	return healthy()
+}
+
+// specReplicas returns spec.replicas, which defaults to 1 when not set.
+func specReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !ok {
+		return 1
+	}
+	return replicas
+}
+
+// generationObserved returns false while the controller has not processed the latest spec.
+func generationObserved(obj *unstructured.Unstructured) bool {
+	return nestedInt(obj.Object, "status", "observedGeneration") >= obj.GetGeneration()
+}
+
+func evaluateDeployment(obj *unstructured.Unstructured) Health {
+	if c, ok := statusConditions(obj)["Progressing"]; ok && c.reason == "ProgressDeadlineExceeded" {
+		return degraded("%s", c.describe())
+	}
+	if paused, _, _ := unstructured.NestedBool(obj.Object, "spec", "paused"); paused {
+		return healthy("deployment is paused")
+	}
+	if !generationObserved(obj) {
+		return progressing("waiting for the deployment spec to be observed")
+	}
+
+	replicas := specReplicas(obj)
+	updated := nestedInt(obj.Object, "status", "updatedReplicas")
+	total := nestedInt(obj.Object, "status", "replicas")
+	available := nestedInt(obj.Object, "status", "availableReplicas")
+	switch {
+	case updated < replicas:
+		return progressing("%d of %d replicas updated", updated, replicas)
+	case total > updated:
+		return progressing("%d old replicas pending termination", total-updated)
+	case available < updated:
+		if c, ok := statusConditions(obj)["Available"]; ok && c.status == "False" {
+			return degraded("%d of %d updated replicas available", available, updated)
+		}
+		return progressing("%d of %d updated replicas available", available, updated)
+	}
+	return healthy()
+}
+
+func evaluateStatefulSet(obj *unstructured.Unstructured) Health {
+	if !generationObserved(obj) {
+		return progressing("waiting for the statefulset spec to be observed")
+	}
+
+	replicas := specReplicas(obj)
+	ready := nestedInt(obj.Object, "status", "readyReplicas")
+	if ready < replicas {
+		return progressing("%d of %d replicas ready", ready, replicas)
+	}
+
+	if strategy := nestedString(obj.Object, "spec", "updateStrategy", "type"); strategy == "" || strategy == "RollingUpdate" {
+		partition := nestedInt(obj.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+		updated := nestedInt(obj.Object, "status", "updatedReplicas")
+		if updated < replicas-partition {
+			return progressing("%d of %d replicas updated", updated, replicas-partition)
+		}
+		current := nestedString(obj.Object, "status", "currentRevision")
+		update := nestedString(obj.Object, "status", "updateRevision")
+		if partition == 0 && current != update {
+			return progressing("waiting for the rolling update to complete")
+		}
+	}
+	return healthy()
+}
+
+func evaluateDaemonSet(obj *unstructured.Unstructured) Health {
+	if !generationObserved(obj) {
+		return progressing("waiting for the daemonset spec to be observed")
+	}
+
+	desired := nestedInt(obj.Object, "status", "desiredNumberScheduled")
+	updated := nestedInt(obj.Object, "status", "updatedNumberScheduled")
+	available := nestedInt(obj.Object, "status", "numberAvailable")
+	misscheduled := nestedInt(obj.Object, "status", "numberMisscheduled")
+	switch {
+	case misscheduled > 0:
+		return degraded("%d pods are running on nodes they should not", misscheduled)
+	case updated < desired:
+		return progressing("%d of %d pods updated", updated, desired)
+	case available < desired:
+		return progressing("%d of %d pods available", available, desired)
+	}
+	return healthy()
+}
+
+func evaluateJob(obj *unstructured.Unstructured) Health {
+	conditions := statusConditions(obj)
+	if c, ok := conditions["Failed"]; ok && c.status == "True" {
+		return degraded("%s", c.describe())
+	}
+	if c, ok := conditions["Complete"]; ok && c.status == "True" {
+		return healthy("job completed")
+	}
+	if suspended, _, _ := unstructured.NestedBool(obj.Object, "spec", "suspend"); suspended {
+		return healthy("job is suspended")
+	}
+	return progressing("%d pods active", nestedInt(obj.Object, "status", "active"))
+}
+
+func evaluatePersistentVolumeClaim(obj *unstructured.Unstructured) Health {
+	switch phase := nestedString(obj.Object, "status", "phase"); phase {
+	case "Bound":
+		return healthy()
+	case "Pending":
+		return progressing("claim is pending")
+	case "Lost":
+		return degraded("claim lost its volume")
+	default:
+		return Health{State: Unknown, Reasons: []string{"claim phase is " + phase}}
+	}
+}
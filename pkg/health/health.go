@@ -0,0 +1,167 @@
+// Package health computes a normalized health for cached objects.
+package health
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+type State string
+
+const (
+	Healthy     State = "healthy"
+	Progressing State = "progressing"
+	Degraded    State = "degraded"
+	Unknown     State = "unknown"
+)
+
+// Health is the normalized health of an object with the reasons it is not healthy.
+type Health struct {
+	State   State
+	Reasons []string
+}
+
+func healthy(reasons ...string) Health {
+	return Health{State: Healthy, Reasons: reasons}
+}
+
+func progressing(format string, args ...interface{}) Health {
+	return Health{State: Progressing, Reasons: []string{fmt.Sprintf(format, args...)}}
+}
+
+func degraded(format string, args ...interface{}) Health {
+	return Health{State: Degraded, Reasons: []string{fmt.Sprintf(format, args...)}}
+}
+
+// Evaluator computes the Health of an object of a single kind.
+type Evaluator func(obj *unstructured.Unstructured) Health
+
+// Registry holds the Evaluators for each kind. Objects of kinds without an Evaluator are evaluated
+// from their status.conditions. Use NewRegistry to create a Registry with the built-in Evaluators.
+type Registry struct {
+	mu         sync.RWMutex
+	evaluators map[schema.GroupKind]Evaluator
+}
+
+// NewRegistry creates a Registry with the built-in Evaluators for Pods, Deployments, StatefulSets,
+// DaemonSets, Jobs and PersistentVolumeClaims.
+func NewRegistry() *Registry {
+	r := &Registry{evaluators: map[schema.GroupKind]Evaluator{}}
+	for gk, evaluator := range builtinEvaluators {
+		r.Register(gk, evaluator)
+	}
+	return r
+}
+
+// Register sets the Evaluator for the group and kind, replacing any existing Evaluator.
+func (r *Registry) Register(gk schema.GroupKind, evaluator Evaluator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evaluators[gk] = evaluator
+}
+
+// Evaluate returns the Health of the object.
+func (r *Registry) Evaluate(obj runtime.Object) Health {
+	u, err := cache.ToUnstructured(obj)
+	if err != nil {
+		return Health{State: Unknown, Reasons: []string{err.Error()}}
+	}
+
+	r.mu.RLock()
+	evaluator, ok := r.evaluators[u.GroupVersionKind().GroupKind()]
+	r.mu.RUnlock()
+	if !ok {
+		evaluator = EvaluateConditions
+	}
+	return evaluator(u)
+}
+
+// EvaluateConditions computes the Health from the status.conditions of an object, it is used for kinds
+// without an Evaluator. A Ready or Available condition decides the Health, otherwise a true Failed,
+// Degraded or Stalled condition is degraded and a true Progressing or Reconciling condition is progressing.
+func EvaluateConditions(obj *unstructured.Unstructured) Health {
+	conditions := statusConditions(obj)
+	if len(conditions) == 0 {
+		return Health{State: Unknown, Reasons: []string{"no status conditions"}}
+	}
+
+	for _, conditionType := range []string{"Ready", "Available"} {
+		c, ok := conditions[conditionType]
+		if !ok {
+			continue
+		}
+		switch c.status {
+		case "True":
+			return healthy()
+		case "False":
+			return degraded("%s", c.describe())
+		default:
+			return progressing("%s", c.describe())
+		}
+	}
+
+	for _, conditionType := range []string{"Failed", "Degraded", "Stalled"} {
+		if c, ok := conditions[conditionType]; ok && c.status == "True" {
+			return degraded("%s", c.describe())
+		}
+	}
+	for _, conditionType := range []string{"Progressing", "Reconciling"} {
+		if c, ok := conditions[conditionType]; ok && c.status == "True" {
+			return progressing("%s", c.describe())
+		}
+	}
+	return Health{State: Unknown, Reasons: []string{"no Ready or Available condition"}}
+}
+
+type condition struct {
+	conditionType string
+	status        string
+	reason        string
+	message       string
+}
+
+func (c condition) describe() string {
+	s := fmt.Sprintf("%s is %s", c.conditionType, c.status)
+	if c.reason != "" {
+		s += ", " + c.reason
+	}
+	if c.message != "" {
+		s += ": " + c.message
+	}
+	return s
+}
+
+func statusConditions(obj *unstructured.Unstructured) map[string]condition {
+	list, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	conditions := map[string]condition{}
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		c := condition{
+			conditionType: nestedString(m, "type"),
+			status:        nestedString(m, "status"),
+			reason:        nestedString(m, "reason"),
+			message:       nestedString(m, "message"),
+		}
+		conditions[c.conditionType] = c
+	}
+	return conditions
+}
+
+func nestedString(obj map[string]interface{}, fields ...string) string {
+	s, _, _ := unstructured.NestedString(obj, fields...)
+	return s
+}
+
+func nestedInt(obj map[string]interface{}, fields ...string) int64 {
+	i, _, _ := unstructured.NestedInt64(obj, fields...)
+	return i
+}
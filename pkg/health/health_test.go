@@ -0,0 +1,219 @@
+package health_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/health"
+)
+
+func newObject(apiVersion, kind string, content map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: content}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace("default")
+	u.SetName("test")
+	return u
+}
+
+func conditions(items ...map[string]interface{}) []interface{} {
+	list := []interface{}{}
+	for _, item := range items {
+		list = append(list, item)
+	}
+	return list
+}
+
+func TestEvaluate(t *testing.T) {
+	registry := health.NewRegistry()
+
+	tests := []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		expected health.State
+	}{
+		{
+			name: "pod running and ready",
+			obj: newObject("v1", "Pod", map[string]interface{}{"status": map[string]interface{}{
+				"phase":             "Running",
+				"containerStatuses": []interface{}{map[string]interface{}{"name": "nginx", "ready": true}},
+			}}),
+			expected: health.Healthy,
+		},
+		{
+			name: "pod running not ready",
+			obj: newObject("v1", "Pod", map[string]interface{}{"status": map[string]interface{}{
+				"phase":             "Running",
+				"containerStatuses": []interface{}{map[string]interface{}{"name": "nginx", "ready": false}},
+			}}),
+			expected: health.Progressing,
+		},
+		{
+			name: "pod crash looping",
+			obj: newObject("v1", "Pod", map[string]interface{}{"status": map[string]interface{}{
+				"phase": "Running",
+				"containerStatuses": []interface{}{map[string]interface{}{
+					"name":  "nginx",
+					"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}},
+				}},
+			}}),
+			expected: health.Degraded,
+		},
+		{
+			name:     "pod succeeded",
+			obj:      newObject("v1", "Pod", map[string]interface{}{"status": map[string]interface{}{"phase": "Succeeded"}}),
+			expected: health.Healthy,
+		},
+		{
+			name:     "pod failed",
+			obj:      newObject("v1", "Pod", map[string]interface{}{"status": map[string]interface{}{"phase": "Failed"}}),
+			expected: health.Degraded,
+		},
+		{
+			name: "deployment available",
+			obj: newObject("apps/v1", "Deployment", map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"replicas": int64(3), "updatedReplicas": int64(3), "availableReplicas": int64(3)},
+			}),
+			expected: health.Healthy,
+		},
+		{
+			name: "deployment rolling out",
+			obj: newObject("apps/v1", "Deployment", map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"replicas": int64(4), "updatedReplicas": int64(2), "availableReplicas": int64(3)},
+			}),
+			expected: health.Progressing,
+		},
+		{
+			name: "deployment deadline exceeded",
+			obj: newObject("apps/v1", "Deployment", map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"conditions": conditions(
+					map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+				)},
+			}),
+			expected: health.Degraded,
+		},
+		{
+			name: "statefulset not ready",
+			obj: newObject("apps/v1", "StatefulSet", map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"readyReplicas": int64(1)},
+			}),
+			expected: health.Progressing,
+		},
+		{
+			name: "statefulset ready",
+			obj: newObject("apps/v1", "StatefulSet", map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{"readyReplicas": int64(2), "updatedReplicas": int64(2),
+					"currentRevision": "a", "updateRevision": "a"},
+			}),
+			expected: health.Healthy,
+		},
+		{
+			name: "daemonset available",
+			obj: newObject("apps/v1", "DaemonSet", map[string]interface{}{"status": map[string]interface{}{
+				"desiredNumberScheduled": int64(2), "updatedNumberScheduled": int64(2), "numberAvailable": int64(2),
+			}}),
+			expected: health.Healthy,
+		},
+		{
+			name: "daemonset updating",
+			obj: newObject("apps/v1", "DaemonSet", map[string]interface{}{"status": map[string]interface{}{
+				"desiredNumberScheduled": int64(2), "updatedNumberScheduled": int64(1), "numberAvailable": int64(2),
+			}}),
+			expected: health.Progressing,
+		},
+		{
+			name: "job complete",
+			obj: newObject("batch/v1", "Job", map[string]interface{}{"status": map[string]interface{}{"conditions": conditions(
+				map[string]interface{}{"type": "Complete", "status": "True"},
+			)}}),
+			expected: health.Healthy,
+		},
+		{
+			name: "job failed",
+			obj: newObject("batch/v1", "Job", map[string]interface{}{"status": map[string]interface{}{"conditions": conditions(
+				map[string]interface{}{"type": "Failed", "status": "True", "reason": "BackoffLimitExceeded"},
+			)}}),
+			expected: health.Degraded,
+		},
+		{
+			name:     "job running",
+			obj:      newObject("batch/v1", "Job", map[string]interface{}{"status": map[string]interface{}{"active": int64(1)}}),
+			expected: health.Progressing,
+		},
+		{
+			name:     "pvc bound",
+			obj:      newObject("v1", "PersistentVolumeClaim", map[string]interface{}{"status": map[string]interface{}{"phase": "Bound"}}),
+			expected: health.Healthy,
+		},
+		{
+			name:     "pvc lost",
+			obj:      newObject("v1", "PersistentVolumeClaim", map[string]interface{}{"status": map[string]interface{}{"phase": "Lost"}}),
+			expected: health.Degraded,
+		},
+		{
+			name: "crd ready",
+			obj: newObject("example.com/v1", "Widget", map[string]interface{}{"status": map[string]interface{}{"conditions": conditions(
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			)}}),
+			expected: health.Healthy,
+		},
+		{
+			name: "crd not ready",
+			obj: newObject("example.com/v1", "Widget", map[string]interface{}{"status": map[string]interface{}{"conditions": conditions(
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "Broken"},
+			)}}),
+			expected: health.Degraded,
+		},
+		{
+			name: "crd reconciling",
+			obj: newObject("example.com/v1", "Widget", map[string]interface{}{"status": map[string]interface{}{"conditions": conditions(
+				map[string]interface{}{"type": "Reconciling", "status": "True"},
+			)}}),
+			expected: health.Progressing,
+		},
+		{
+			name:     "crd without conditions",
+			obj:      newObject("example.com/v1", "Widget", map[string]interface{}{}),
+			expected: health.Unknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := registry.Evaluate(tc.obj)
+			assert.Equal(t, tc.expected, h.State, h.Reasons)
+			if tc.expected != health.Healthy {
+				assert.NotEmpty(t, h.Reasons)
+			}
+		})
+	}
+}
+
+func TestEvaluateTyped(t *testing.T) {
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	assert.Equal(t, health.Progressing, health.NewRegistry().Evaluate(pod).State)
+}
+
+func TestRegister(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register(schema.GroupKind{Group: "example.com", Kind: "Widget"}, func(obj *unstructured.Unstructured) health.Health {
+		return health.Health{State: health.Degraded, Reasons: []string{"always broken"}}
+	})
+
+	h := registry.Evaluate(newObject("example.com/v1", "Widget", map[string]interface{}{}))
+	assert.Equal(t, health.Health{State: health.Degraded, Reasons: []string{"always broken"}}, h)
+}
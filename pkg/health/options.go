@@ -0,0 +1,18 @@
+package health
+
+type TrackerOption func(*Tracker)
+
+// WithCluster limits the Tracker to the events of the watches of the named cluster.
+func WithCluster(cluster string) TrackerOption {
+	return func(t *Tracker) {
+		t.cluster = cluster
+	}
+}
+
+// WithOnChange sets a function called when the Health of an object changes. The previous ObjectHealth
+// is the zero value for a new object and the current ObjectHealth is the zero value for a deleted object.
+func WithOnChange(fn func(previous, current ObjectHealth)) TrackerOption {
+	return func(t *Tracker) {
+		t.onChange = fn
+	}
+}
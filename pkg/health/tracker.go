@@ -0,0 +1,177 @@
+package health
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+// ObjectHealth is the Health of a cached object.
+type ObjectHealth struct {
+	Health
+	UID              types.UID
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+}
+
+// Tracker keeps the Health of every cached object up to date, recomputing it on each watch event.
+// Register the Tracker with cache.AddEventHandler:
+//
+//	tracker := health.NewTracker(health.NewRegistry())
+//	remove := cache.AddEventHandler(tracker)
+//	defer remove()
+type Tracker struct {
+	mu       sync.RWMutex
+	registry *Registry
+	objects  map[types.UID]ObjectHealth
+	// watches are the watches caching each object, an object cached by both a namespace watch and a
+	// NamespaceAll watch is tracked until both have deleted it
+	watches map[types.UID]map[cache.WatchKey]struct{}
+
+	cluster  string
+	onChange func(previous, current ObjectHealth)
+}
+
+var _ cache.EventHandler = (*Tracker)(nil)
+
+// NewTracker creates a Tracker evaluating objects with the Registry.
+func NewTracker(registry *Registry, options ...TrackerOption) *Tracker {
+	t := &Tracker{
+		registry: registry,
+		objects:  map[types.UID]ObjectHealth{},
+		watches:  map[types.UID]map[cache.WatchKey]struct{}{},
+	}
+	for _, opt := range options {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Tracker) OnAdd(key cache.WatchKey, obj runtime.Object) {
+	if key.Cluster == t.cluster {
+		t.update(key, obj)
+	}
+}
+
+func (t *Tracker) OnUpdate(key cache.WatchKey, _, newObj runtime.Object) {
+	if key.Cluster == t.cluster {
+		t.update(key, newObj)
+	}
+}
+
+func (t *Tracker) OnDelete(key cache.WatchKey, obj runtime.Object) {
+	if key.Cluster != t.cluster {
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+
+	uid := accessor.GetUID()
+
+	t.mu.Lock()
+	delete(t.watches[uid], key)
+	if len(t.watches[uid]) > 0 {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.watches, uid)
+	previous, ok := t.objects[uid]
+	delete(t.objects, uid)
+	t.mu.Unlock()
+
+	if ok && t.onChange != nil {
+		t.onChange(previous, ObjectHealth{})
+	}
+}
+
+func (t *Tracker) update(key cache.WatchKey, obj runtime.Object) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	current := ObjectHealth{
+		Health:           t.registry.Evaluate(obj),
+		UID:              accessor.GetUID(),
+		GroupVersionKind: obj.GetObjectKind().GroupVersionKind(),
+		Namespace:        accessor.GetNamespace(),
+		Name:             accessor.GetName(),
+	}
+
+	t.mu.Lock()
+	if t.watches[current.UID] == nil {
+		t.watches[current.UID] = map[cache.WatchKey]struct{}{}
+	}
+	t.watches[current.UID][key] = struct{}{}
+	previous := t.objects[current.UID]
+	t.objects[current.UID] = current
+	t.mu.Unlock()
+
+	if t.onChange != nil && !reflect.DeepEqual(previous, current) {
+		t.onChange(previous, current)
+	}
+}
+
+// Get returns the Health of the object with the UID.
+func (t *Tracker) Get(uid types.UID) (ObjectHealth, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	h, ok := t.objects[uid]
+	return h, ok
+}
+
+// List returns the Health of the tracked objects sorted by kind, namespace and name.
+// If states are provided, only objects in one of those states are returned.
+func (t *Tracker) List(states ...State) []ObjectHealth {
+	t.mu.RLock()
+	list := []ObjectHealth{}
+	for _, h := range t.objects {
+		if len(states) > 0 && !hasState(states, h.State) {
+			continue
+		}
+		list = append(list, h)
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.GroupVersionKind.Kind != b.GroupVersionKind.Kind {
+			return a.GroupVersionKind.Kind < b.GroupVersionKind.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return list
+}
+
+// Summary returns the number of tracked objects in each State.
+func (t *Tracker) Summary() map[State]int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	summary := map[State]int{}
+	for _, h := range t.objects {
+		summary[h.State]++
+	}
+	return summary
+}
+
+func hasState(states []State, state State) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
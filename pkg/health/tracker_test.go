@@ -0,0 +1,68 @@
+package health_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/health"
+)
+
+func TestTracker(t *testing.T) {
+	changes := []string{}
+	tracker := health.NewTracker(health.NewRegistry(), health.WithOnChange(func(previous, current health.ObjectHealth) {
+		changes = append(changes, string(previous.State)+"->"+string(current.State))
+	}))
+
+	pending := newObject("v1", "PersistentVolumeClaim", map[string]interface{}{"status": map[string]interface{}{"phase": "Pending"}})
+	pending.SetUID(types.UID("1"))
+	bound := newObject("v1", "PersistentVolumeClaim", map[string]interface{}{"status": map[string]interface{}{"phase": "Bound"}})
+	bound.SetUID(types.UID("1"))
+
+	key := cache.WatchKey{}
+	tracker.OnAdd(key, pending)
+	h, ok := tracker.Get("1")
+	assert.True(t, ok)
+	assert.Equal(t, health.Progressing, h.State)
+	assert.Equal(t, "PersistentVolumeClaim", h.GroupVersionKind.Kind)
+
+	// an update that does not change the health is not reported
+	tracker.OnUpdate(key, pending, pending)
+	tracker.OnUpdate(key, pending, bound)
+	assert.Equal(t, map[health.State]int{health.Healthy: 1}, tracker.Summary())
+	assert.Len(t, tracker.List(health.Healthy), 1)
+	assert.Empty(t, tracker.List(health.Degraded))
+
+	// events from other clusters are ignored
+	tracker.OnDelete(cache.WatchKey{Cluster: "other"}, bound)
+	assert.Len(t, tracker.List(), 1)
+
+	tracker.OnDelete(key, bound)
+	_, ok = tracker.Get("1")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"->progressing", "progressing->healthy", "healthy->"}, changes)
+}
+
+func TestTrackerOverlappingWatches(t *testing.T) {
+	tracker := health.NewTracker(health.NewRegistry())
+
+	bound := newObject("v1", "PersistentVolumeClaim", map[string]interface{}{"status": map[string]interface{}{"phase": "Bound"}})
+	bound.SetUID(types.UID("1"))
+
+	namespaced := cache.WatchKey{Namespace: "default"}
+	all := cache.WatchKey{}
+	tracker.OnAdd(namespaced, bound)
+	tracker.OnAdd(all, bound)
+
+	// stopping the namespace watch keeps the object tracked by the NamespaceAll watch
+	tracker.OnDelete(namespaced, bound)
+	_, ok := tracker.Get("1")
+	assert.True(t, ok)
+
+	tracker.OnDelete(all, bound)
+	_, ok = tracker.Get("1")
+	assert.False(t, ok)
+}
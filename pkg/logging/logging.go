@@ -1,11 +1,11 @@
+// Package logging adapts the loggers of applications to the *zap.Logger used by the library.
 package logging
 
 import (
 	"go.uber.org/zap"
 )
 
-var Logger *zap.Logger
-
-func init() {
-	Logger, _ = zap.NewProduction()
-}
+// Logger is a no-op logger kept for compatibility, the library does not log through it.
+//
+// Deprecated: configure the logger with client.WithLogger, client.WithLogr or cache.WithLogger.
+var Logger = zap.NewNop()
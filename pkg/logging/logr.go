@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"sort"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FromLogr returns a *zap.Logger writing to the logr.Logger. Debug entries are written at V(1), Info and
+// Warn entries at V(0) and Error entries and above with Error. The error of a zap.Error field is passed
+// to Error, the other fields are passed as key and value pairs sorted by key.
+func FromLogr(logger logr.Logger) *zap.Logger {
+	return zap.New(&logrCore{logger: logger})
+}
+
+type logrCore struct {
+	logger logr.Logger
+	fields []zapcore.Field
+}
+
+var _ zapcore.Core = (*logrCore)(nil)
+
+func (c *logrCore) leveled(level zapcore.Level) logr.Logger {
+	if level < zapcore.InfoLevel {
+		return c.logger.V(1)
+	}
+	return c.logger
+}
+
+func (c *logrCore) Enabled(level zapcore.Level) bool {
+	if level >= zapcore.ErrorLevel {
+		return true
+	}
+	return c.leveled(level).Enabled()
+}
+
+func (c *logrCore) With(fields []zapcore.Field) zapcore.Core {
+	return &logrCore{logger: c.logger, fields: append(append([]zapcore.Field{}, c.fields...), fields...)}
+}
+
+func (c *logrCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *logrCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	var err error
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range append(append([]zapcore.Field{}, c.fields...), fields...) {
+		if e, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType && err == nil {
+			err = e
+			continue
+		}
+		field.AddTo(enc)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	keysAndValues := make([]interface{}, 0, 2*len(keys))
+	for _, key := range keys {
+		keysAndValues = append(keysAndValues, key, enc.Fields[key])
+	}
+
+	logger := c.leveled(entry.Level)
+	if entry.LoggerName != "" {
+		logger = logger.WithName(entry.LoggerName)
+	}
+	if entry.Level >= zapcore.ErrorLevel {
+		logger.Error(err, entry.Message, keysAndValues...)
+		return nil
+	}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err)
+	}
+	logger.Info(entry.Message, keysAndValues...)
+	return nil
+}
+
+func (c *logrCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,71 @@
+package logging_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/logging"
+)
+
+type entry struct {
+	name          string
+	level         int
+	err           error
+	msg           string
+	keysAndValues []interface{}
+}
+
+// recordingLogr records the entries enabled up to verbosity.
+type recordingLogr struct {
+	entries   *[]entry
+	name      string
+	level     int
+	verbosity int
+}
+
+func (l recordingLogr) Enabled() bool { return l.level <= l.verbosity }
+
+func (l recordingLogr) Info(msg string, keysAndValues ...interface{}) {
+	*l.entries = append(*l.entries, entry{name: l.name, level: l.level, msg: msg, keysAndValues: keysAndValues})
+}
+
+func (l recordingLogr) Error(err error, msg string, keysAndValues ...interface{}) {
+	*l.entries = append(*l.entries, entry{name: l.name, level: l.level, err: err, msg: msg, keysAndValues: keysAndValues})
+}
+
+func (l recordingLogr) V(level int) logr.Logger {
+	l.level += level
+	return l
+}
+
+func (l recordingLogr) WithValues(...interface{}) logr.Logger { return l }
+
+func (l recordingLogr) WithName(name string) logr.Logger {
+	l.name = name
+	return l
+}
+
+func TestFromLogr(t *testing.T) {
+	entries := []entry{}
+	logger := logging.FromLogr(recordingLogr{entries: &entries}).With(zap.String("cluster", "alpha"))
+
+	logger.Debug("skipped")
+	logger.Info("info", zap.Int("count", 2))
+	logger.Named("watch").Warn("warn", zap.Error(fmt.Errorf("slow")))
+	logger.Error("error", zap.String("resource", "pods"), zap.Error(fmt.Errorf("failed")))
+
+	assert.Equal(t, []entry{
+		{msg: "info", keysAndValues: []interface{}{"cluster", "alpha", "count", int64(2)}},
+		{name: "watch", msg: "warn", keysAndValues: []interface{}{"cluster", "alpha", "error", fmt.Errorf("slow")}},
+		{err: fmt.Errorf("failed"), msg: "error", keysAndValues: []interface{}{"cluster", "alpha", "resource", "pods"}},
+	}, entries)
+
+	entries = entries[:0]
+	verbose := logging.FromLogr(recordingLogr{entries: &entries, verbosity: 1})
+	verbose.Debug("debug")
+	assert.Equal(t, []entry{{level: 1, msg: "debug", keysAndValues: []interface{}{}}}, entries)
+}
@@ -0,0 +1,175 @@
+// Package metrics provides Prometheus metrics for discovery, access reviews and watches.
+// Metrics are always recorded but are only exposed once Register has been called.
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "k8s_resource_client"
+
+var (
+	DiscoveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "discovery_duration_seconds",
+		Help:      "Duration of discovery requests by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	DiscoveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discovery_failures_total",
+		Help:      "Number of failed discovery requests by operation.",
+	}, []string{"operation"})
+
+	AccessReviews = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "access_reviews_total",
+		Help:      "Number of SelfSubjectAccessReviews by result.",
+	}, []string{"result"})
+
+	AccessReviewDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "access_review_duration_seconds",
+		Help:      "Duration of SelfSubjectAccessReview requests by result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	WatchEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "watch_events_total",
+		Help:      "Number of watch events by cluster, resource, namespace and type.",
+	}, []string{"cluster", "resource", "namespace", "type"})
+
+	WatchRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "watch_restarts_total",
+		Help:      "Number of times a watch was restarted after an error by cluster, resource and namespace.",
+	}, []string{"cluster", "resource", "namespace"})
+
+	activeWatchesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "active_watches"),
+		"Number of running watches by cluster, resource and namespace.",
+		[]string{"cluster", "resource", "namespace"}, nil,
+	)
+
+	cachedObjectsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "cached_objects"),
+		"Number of objects in the watch cache by cluster, resource and namespace of the objects.",
+		[]string{"cluster", "resource", "namespace"}, nil,
+	)
+
+	queueDepthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "drain_queue_depth"),
+		"Number of events waiting in the watch queue to be drained by cluster, resource and namespace.",
+		[]string{"cluster", "resource", "namespace"}, nil,
+	)
+)
+
+// WatchStat is a point-in-time summary of a watch used to produce the watch gauges.
+type WatchStat struct {
+	Cluster   string
+	Resource  string
+	Namespace string
+	Running   bool
+	// Objects is the number of cached objects by the namespace of the objects, a NamespaceAll watch
+	// reports the objects of every namespace.
+	Objects    map[string]int
+	QueueDepth int
+}
+
+// WatchStatsFn is called at collection time to produce the watch gauges, it is set by the cache package.
+var WatchStatsFn func() []WatchStat
+
+var _ prometheus.Collector = (*watchCollector)(nil)
+
+type watchCollector struct{}
+
+func (c watchCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeWatchesDesc
+	ch <- cachedObjectsDesc
+	ch <- queueDepthDesc
+}
+
+func (c watchCollector) Collect(ch chan<- prometheus.Metric) {
+	if WatchStatsFn == nil {
+		return
+	}
+
+	type labelKey struct{ cluster, resource, namespace string }
+	active := map[labelKey]float64{}
+	objects := map[labelKey]float64{}
+	depth := map[labelKey]float64{}
+	for _, stat := range WatchStatsFn() {
+		key := labelKey{stat.Cluster, stat.Resource, stat.Namespace}
+		if stat.Running {
+			active[key] += 1
+		}
+		// overlapping watches, such as a namespace watch and a NamespaceAll watch, cache the same objects
+		// so the objects of a namespace are counted from the watch caching the most of them
+		for namespace, n := range stat.Objects {
+			objectsKey := labelKey{stat.Cluster, stat.Resource, namespace}
+			if float64(n) > objects[objectsKey] {
+				objects[objectsKey] = float64(n)
+			}
+		}
+		depth[key] += float64(stat.QueueDepth)
+	}
+
+	for key, v := range active {
+		ch <- prometheus.MustNewConstMetric(activeWatchesDesc, prometheus.GaugeValue, v, key.cluster, key.resource, key.namespace)
+	}
+	for key, v := range objects {
+		ch <- prometheus.MustNewConstMetric(cachedObjectsDesc, prometheus.GaugeValue, v, key.cluster, key.resource, key.namespace)
+	}
+	for key, v := range depth {
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, v, key.cluster, key.resource, key.namespace)
+	}
+}
+
+var registerMu sync.Mutex
+
+// Register adds all of the metrics to the given Registerer. Registering the same metrics
+// more than once with a Registerer is not an error.
+func Register(registerer prometheus.Registerer) error {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	collectors := []prometheus.Collector{
+		DiscoveryDuration,
+		DiscoveryFailures,
+		AccessReviews,
+		AccessReviewDuration,
+		WatchEvents,
+		WatchRestarts,
+		watchCollector{},
+	}
+	for _, c := range collectors {
+		if err := registerer.Register(c); err != nil {
+			are := prometheus.AlreadyRegisteredError{}
+			if errors.As(err, &are) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ObserveDiscovery records the duration and outcome of a discovery operation started at start.
+func ObserveDiscovery(operation string, start time.Time, err error) {
+	DiscoveryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		DiscoveryFailures.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveAccessReview records the duration and result of a SelfSubjectAccessReview started at start.
+func ObserveAccessReview(result string, start time.Time) {
+	AccessReviews.WithLabelValues(result).Inc()
+	AccessReviewDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,69 @@
+package metrics_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
+)
+
+func TestRegister(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, metrics.Register(registry))
+	// registering again is not an error
+	assert.Nil(t, metrics.Register(registry))
+}
+
+func TestObserve(t *testing.T) {
+	metrics.ObserveDiscovery("resources", time.Now(), nil)
+	metrics.ObserveDiscovery("resources", time.Now(), fmt.Errorf("discovery error"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.DiscoveryFailures.WithLabelValues("resources")))
+
+	metrics.ObserveAccessReview("allowed", time.Now())
+	metrics.ObserveAccessReview("allowed", time.Now())
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.AccessReviews.WithLabelValues("allowed")))
+}
+
+func TestWatchCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, metrics.Register(registry))
+
+	defer func(fn func() []metrics.WatchStat) { metrics.WatchStatsFn = fn }(metrics.WatchStatsFn)
+	metrics.WatchStatsFn = func() []metrics.WatchStat {
+		return []metrics.WatchStat{
+			{Resource: "v1.Pod", Namespace: "default", Running: true, Objects: map[string]int{"default": 2}, QueueDepth: 1},
+			{Resource: "v1.Pod", Namespace: "", Running: false, Objects: map[string]int{"default": 3, "test": 1}},
+			{Cluster: "prod", Resource: "v1.Pod", Namespace: "default", Running: true, Objects: map[string]int{"default": 4}},
+		}
+	}
+
+	// the objects of overlapping watches are counted once
+	expected := `
+# HELP k8s_resource_client_active_watches Number of running watches by cluster, resource and namespace.
+# TYPE k8s_resource_client_active_watches gauge
+k8s_resource_client_active_watches{cluster="",namespace="default",resource="v1.Pod"} 1
+k8s_resource_client_active_watches{cluster="prod",namespace="default",resource="v1.Pod"} 1
+# HELP k8s_resource_client_cached_objects Number of objects in the watch cache by cluster, resource and namespace of the objects.
+# TYPE k8s_resource_client_cached_objects gauge
+k8s_resource_client_cached_objects{cluster="",namespace="default",resource="v1.Pod"} 3
+k8s_resource_client_cached_objects{cluster="",namespace="test",resource="v1.Pod"} 1
+k8s_resource_client_cached_objects{cluster="prod",namespace="default",resource="v1.Pod"} 4
+# HELP k8s_resource_client_drain_queue_depth Number of events waiting in the watch queue to be drained by cluster, resource and namespace.
+# TYPE k8s_resource_client_drain_queue_depth gauge
+k8s_resource_client_drain_queue_depth{cluster="",namespace="",resource="v1.Pod"} 0
+k8s_resource_client_drain_queue_depth{cluster="",namespace="default",resource="v1.Pod"} 1
+k8s_resource_client_drain_queue_depth{cluster="prod",namespace="default",resource="v1.Pod"} 0
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"k8s_resource_client_active_watches",
+		"k8s_resource_client_cached_objects",
+		"k8s_resource_client_drain_queue_depth",
+	)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,97 @@
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// Result is the value returned by a cluster in a fan-out.
+type Result[T any] struct {
+	Cluster string
+	Value   T
+}
+
+// Do calls fn for every healthy cluster concurrently and returns the results sorted by cluster. Unhealthy
+// clusters are not called, they are returned in an *errors.ClusterErrors with the clusters fn failed for.
+func Do[T any](ctx context.Context, m *Manager, fn func(context.Context, *Cluster) (T, error)) ([]Result[T], error) {
+	clusters := m.Clusters()
+	results := make([]Result[T], len(clusters))
+	errs := make([]error, len(clusters))
+
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		if status := c.Status(); !status.Healthy() {
+			errs[i] = fmt.Errorf("unhealthy, %w", status.Err)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, c *Cluster) {
+			defer wg.Done()
+			results[i].Cluster = c.Name
+			results[i].Value, errs[i] = fn(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	succeeded := []Result[T]{}
+	clusterErrs := &errors.ClusterErrors{}
+	for i, c := range clusters {
+		if errs[i] != nil {
+			clusterErrs.Errs = append(clusterErrs.Errs, &errors.ClusterError{Cluster: c.Name, Err: errs[i]})
+			continue
+		}
+		succeeded = append(succeeded, results[i])
+	}
+	if len(clusterErrs.Errs) > 0 {
+		return succeeded, clusterErrs
+	}
+	return succeeded, nil
+}
+
+// Object is an object tagged with the cluster it was listed from.
+type Object struct {
+	Cluster string
+	Object  runtime.Object
+}
+
+// List lists the objects of the resource in the namespace of every healthy cluster from their watches,
+// "" lists across all namespaces. Clusters without a watch of the resource start one and wait for it to
+// sync. The objects are sorted by cluster, the clusters that failed are returned in an *errors.ClusterErrors.
+func (m *Manager) List(ctx context.Context, res resource.Resource, namespace string, selector labels.Selector) ([]Object, error) {
+	if selector == nil {
+		selector = labels.Everything()
+	}
+	results, err := Do(ctx, m, func(ctx context.Context, c *Cluster) ([]runtime.Object, error) {
+		lister, err := cache.WatchForClusterResource(c.Name, res, namespace)
+		if err != nil {
+			listers, err := client.WatchResource(ctx, c.Client, res, false, []string{namespace})
+			if err != nil {
+				return nil, err
+			}
+			lister = listers[0]
+		}
+		if err := lister.WaitForSync(ctx); err != nil {
+			return nil, err
+		}
+		return lister.List(selector)
+	})
+
+	objects := []Object{}
+	for _, result := range results {
+		for _, obj := range result.Value {
+			objects = append(objects, Object{Cluster: result.Cluster, Object: obj})
+		}
+	}
+	sort.SliceStable(objects, func(i, j int) bool { return objects[i].Cluster < objects[j].Cluster })
+	return objects, err
+}
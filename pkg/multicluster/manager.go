@@ -0,0 +1,325 @@
+// Package multicluster manages a client.Client for each of many clusters, checks their health and fans
+// queries out to every cluster.
+//
+// The watches of each client are created with the cluster name in their cache.WatchKey so they can share
+// cache.ResourceWatches, use cache.WatchForClusterResource to read the watches of a single cluster. Results
+// of a fan-out are tagged with their cluster and the clusters that failed are reported in an
+// *errors.ClusterErrors alongside the results of the clusters that succeeded.
+//
+// cache.Namespaces, cache.Resources and cache.Access hold the discovery of a single cluster and are shared
+// by every client of the process. The clients of a Manager must not update them with the client package
+// AutoDiscover functions, RefreshAccess, SwitchContext or RefreshCredentials with access checks, use
+// Manager.Discover which keeps the discovery of each cluster in its Cluster.
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// DefaultHealthTimeout bounds a single health check of a cluster.
+const DefaultHealthTimeout = 10 * time.Second
+
+// Cluster is a named cluster of a Manager and its client.
+type Cluster struct {
+	Name   string
+	Client *client.Client
+
+	mu        sync.Mutex
+	status    Status
+	discovery Discovery
+}
+
+// Discovery is the namespaces, resources and access of a Cluster found by Manager.Discover.
+type Discovery struct {
+	Namespaces []string
+	Resources  *cache.ResourceCache
+	// Access is nil when the client skips subject access checks.
+	Access resource.ResourceAccess
+}
+
+// Status is the result of the last health check of a Cluster.
+type Status struct {
+	// Err is the error of the last health check, nil when it succeeded or no check ran yet.
+	Err       error
+	CheckedAt time.Time
+}
+
+// Healthy returns false when the last health check failed.
+func (s Status) Healthy() bool {
+	return s.Err == nil
+}
+
+// Status returns the result of the last health check.
+func (c *Cluster) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// Discovery returns the result of the last Manager.Discover of the cluster, its Resources are nil when
+// the cluster was not discovered yet.
+func (c *Cluster) Discovery() Discovery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.discovery
+}
+
+// discover discovers the namespaces, resources and unless skipped the access of the cluster.
+func (c *Cluster) discover(ctx context.Context) error {
+	namespaces, err := client.DiscoverNamespaces(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+	resources, err := client.DiscoverResources(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+	discovery := Discovery{Namespaces: namespaces, Resources: resources}
+	if !c.Client.SkipSubjectAccessChecks {
+		if discovery.Access, err = client.DiscoverAccess(ctx, c.Client, namespaces, resources); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.discovery = discovery
+	return nil
+}
+
+func (c *Cluster) setStatus(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = Status{Err: err, CheckedAt: time.Now()}
+}
+
+// Manager holds the clusters, create it with NewManager. Clusters can be added and removed while queries run.
+type Manager struct {
+	mu       sync.RWMutex
+	clusters map[string]*Cluster
+
+	clientOptions []client.ClientOption
+	clientFn      func(context.Context, ...client.ClientOption) (*client.Client, error)
+	healthCheck   func(context.Context, *client.Client) error
+	healthTimeout time.Duration
+	logger        *zap.Logger
+}
+
+func NewManager(options ...ManagerOption) *Manager {
+	m := &Manager{
+		clusters:      map[string]*Cluster{},
+		clientFn:      client.NewClient,
+		healthCheck:   client.Ping,
+		healthTimeout: DefaultHealthTimeout,
+		logger:        zap.NewNop(),
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+// LoadKubeconfig adds a cluster for every context of the kubeconfig files, named after the context. The
+// files are merged like KUBECONFIG, when none are given the default loading rules are used. Contexts that
+// already have a cluster are skipped, the contexts that could not be added are returned in an
+// *errors.ClusterErrors.
+func (m *Manager) LoadKubeconfig(ctx context.Context, paths ...string) error {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(paths) > 0 {
+		rules = &clientcmd.ClientConfigLoadingRules{Precedence: paths}
+	}
+	config, err := rules.Load()
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	clusterErrs := &errors.ClusterErrors{}
+	for _, name := range names {
+		if _, ok := m.Cluster(name); ok {
+			continue
+		}
+		restConfig, err := clientcmd.NewNonInteractiveClientConfig(*config, name, &clientcmd.ConfigOverrides{}, rules).ClientConfig()
+		if err == nil {
+			// a cluster added since the check above is skipped as well
+			_, err = m.add(ctx, name, restConfig)
+		}
+		if err != nil {
+			clusterErrs.Errs = append(clusterErrs.Errs, &errors.ClusterError{Cluster: name, Err: err})
+		}
+	}
+	if len(clusterErrs.Errs) > 0 {
+		return clusterErrs
+	}
+	return nil
+}
+
+// Add creates a client for the cluster and checks its health. A failed health check does not fail Add, the
+// cluster is skipped by fan-outs until a later check succeeds.
+func (m *Manager) Add(ctx context.Context, name string, config *rest.Config) error {
+	added, err := m.add(ctx, name, config)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return fmt.Errorf("cluster %s already exists", name)
+	}
+	return nil
+}
+
+// add adds the cluster unless it exists, returning false when it does. A client created for a cluster that
+// was added concurrently is closed.
+func (m *Manager) add(ctx context.Context, name string, config *rest.Config) (bool, error) {
+	if _, ok := m.Cluster(name); ok {
+		return false, nil
+	}
+
+	options := append([]client.ClientOption{}, m.clientOptions...)
+	options = append(options, client.WithRESTConfig(config), client.WithWatcherFn(clusterWatcherFn(name)))
+	c, err := m.clientFn(ctx, options...)
+	if err != nil {
+		return false, err
+	}
+
+	cluster := &Cluster{Name: name, Client: c}
+	m.check(ctx, cluster)
+
+	m.mu.Lock()
+	if _, ok := m.clusters[name]; ok {
+		m.mu.Unlock()
+		if err := c.Close(ctx); err != nil {
+			m.logger.Debug("unable to close the client of an existing cluster", zap.String("cluster", name), zap.Error(err))
+		}
+		return false, nil
+	}
+	m.clusters[name] = cluster
+	m.mu.Unlock()
+	return true, nil
+}
+
+// clusterWatcherFn creates Watchers tagging their watches with the cluster name.
+func clusterWatcherFn(name string) func(context.Context, *zap.Logger, dynamic.Interface) (*cache.Watcher, error) {
+	return func(ctx context.Context, logger *zap.Logger, d dynamic.Interface) (*cache.Watcher, error) {
+		return cache.NewWatcher(ctx,
+			cache.WithLogger(logger),
+			cache.WithDynamicClient(d),
+			cache.WithCluster(name),
+		)
+	}
+}
+
+// Remove removes the cluster and closes its client, see client.Client.Close. The cluster is removed even
+// when its watches or refreshers did not exit before the context was done, they are returned in an
+// *errors.ClientNotClosed.
+func (m *Manager) Remove(ctx context.Context, name string) error {
+	m.mu.Lock()
+	cluster, ok := m.clusters[name]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("cluster %s not found", name)
+	}
+	delete(m.clusters, name)
+	m.mu.Unlock()
+
+	return cluster.Client.Close(ctx)
+}
+
+// Discover discovers the namespaces, resources and access of every healthy cluster concurrently into its
+// Cluster, see Cluster.Discovery. The global cache.Namespaces, cache.Resources and cache.Access are not
+// changed. The clusters that failed are returned in an *errors.ClusterErrors and keep their last discovery.
+func (m *Manager) Discover(ctx context.Context) error {
+	_, err := Do(ctx, m, func(ctx context.Context, c *Cluster) (struct{}, error) {
+		return struct{}{}, c.discover(ctx)
+	})
+	return err
+}
+
+// Cluster returns the named cluster.
+func (m *Manager) Cluster(name string) (*Cluster, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.clusters[name]
+	return c, ok
+}
+
+// Clusters returns the clusters sorted by name.
+func (m *Manager) Clusters() []*Cluster {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	clusters := make([]*Cluster, 0, len(m.clusters))
+	for _, c := range m.clusters {
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters
+}
+
+// CheckHealth checks every cluster concurrently, the unhealthy clusters are returned in an
+// *errors.ClusterErrors.
+func (m *Manager) CheckHealth(ctx context.Context) error {
+	clusters := m.Clusters()
+	var wg sync.WaitGroup
+	for _, c := range clusters {
+		wg.Add(1)
+		go func(c *Cluster) {
+			defer wg.Done()
+			m.check(ctx, c)
+		}(c)
+	}
+	wg.Wait()
+
+	clusterErrs := &errors.ClusterErrors{}
+	for _, c := range clusters {
+		if status := c.Status(); !status.Healthy() {
+			clusterErrs.Errs = append(clusterErrs.Errs, &errors.ClusterError{Cluster: c.Name, Err: status.Err})
+		}
+	}
+	if len(clusterErrs.Errs) > 0 {
+		return clusterErrs
+	}
+	return nil
+}
+
+func (m *Manager) check(ctx context.Context, c *Cluster) {
+	ctx, cancel := context.WithTimeout(ctx, m.healthTimeout)
+	defer cancel()
+
+	err := m.healthCheck(ctx, c.Client)
+	if err != nil && c.Status().Healthy() {
+		m.logger.Warn("cluster unhealthy", zap.String("cluster", c.Name), zap.Error(err))
+	}
+	c.setStatus(err)
+}
+
+// Run checks the health of every cluster each interval until the context is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = m.CheckHealth(ctx)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package multicluster_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/multicluster"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: alpha
+  cluster:
+    server: https://alpha.example.com
+- name: beta
+  cluster:
+    server: https://beta.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: alpha
+  context:
+    cluster: alpha
+    user: admin
+- name: beta
+  context:
+    cluster: beta
+    user: admin
+current-context: alpha
+`
+
+var podResource = ctesting.NewClusterResource("", "v1", "Pod", "pods", true)
+
+func newPod(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	u := wtesting.NewPod(namespace, name)
+	u.SetLabels(labels)
+	return u
+}
+
+// newManager loads the kubeconfig with a FakeCluster serving each of its servers.
+func newManager(t *testing.T, options ...multicluster.ManagerOption) (*multicluster.Manager, map[string]*ctesting.FakeCluster) {
+	cache.ResourceWatches = &sync.Map{}
+	t.Cleanup(func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+	})
+
+	fakes := map[string]*ctesting.FakeCluster{
+		"https://alpha.example.com": ctesting.NewFakeCluster(),
+		"https://beta.example.com":  ctesting.NewFakeCluster(),
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	assert.Nil(t, os.WriteFile(path, []byte(kubeconfig), 0o600))
+
+	options = append([]multicluster.ManagerOption{
+		multicluster.WithClientOptions(client.WithLogger(zap.NewNop())),
+		multicluster.WithClientFn(func(ctx context.Context, options ...client.ClientOption) (*client.Client, error) {
+			probe := &client.Client{}
+			for _, opt := range options {
+				opt(probe)
+			}
+			return fakes[probe.RESTConfig.Host].NewClient(ctx, options...)
+		}),
+	}, options...)
+	m := multicluster.NewManager(options...)
+	assert.Nil(t, m.LoadKubeconfig(context.TODO(), path))
+	return m, fakes
+}
+
+func TestManagerClusters(t *testing.T) {
+	m, _ := newManager(t)
+
+	names := []string{}
+	for _, c := range m.Clusters() {
+		names = append(names, c.Name)
+		assert.True(t, c.Status().Healthy())
+		assert.False(t, c.Status().CheckedAt.IsZero())
+	}
+	assert.Equal(t, []string{"alpha", "beta"}, names)
+
+	// loading again skips the existing clusters
+	assert.Nil(t, m.LoadKubeconfig(context.TODO(), filepath.Join(t.TempDir(), "missing")))
+	assert.NotNil(t, m.Add(context.TODO(), "alpha", ctesting.FakeConfig))
+	assert.NotNil(t, m.Remove(context.TODO(), "gamma"))
+	assert.Nil(t, m.Remove(context.TODO(), "alpha"))
+	assert.Len(t, m.Clusters(), 1)
+}
+
+func TestManagerAddConcurrent(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	t.Cleanup(func() {
+		cache.WatcherStop()
+		cache.ResourceWatches = &sync.Map{}
+	})
+
+	fake := ctesting.NewFakeCluster()
+	clients := []*client.Client{}
+	adding := false
+	var m *multicluster.Manager
+	m = multicluster.NewManager(
+		multicluster.WithClientOptions(client.WithLogger(zap.NewNop())),
+		multicluster.WithClientFn(func(ctx context.Context, options ...client.ClientOption) (*client.Client, error) {
+			if !adding {
+				adding = true
+				// the cluster is added while the first client is created
+				assert.Nil(t, m.Add(ctx, "alpha", ctesting.FakeConfig))
+			}
+			c, err := fake.NewClient(ctx, options...)
+			clients = append(clients, c)
+			return c, err
+		}),
+	)
+
+	assert.EqualError(t, m.Add(context.TODO(), "alpha", ctesting.FakeConfig), "cluster alpha already exists")
+	assert.Len(t, clients, 2)
+	cluster, ok := m.Cluster("alpha")
+	assert.True(t, ok)
+	assert.Same(t, clients[0], cluster.Client)
+
+	// the client created for the existing cluster is closed
+	refresh := func(context.Context, *client.Client) error { return nil }
+	assert.EqualError(t, clients[1].StartRefresh("test", time.Minute, refresh), "client closed")
+	assert.Nil(t, cluster.Client.StartRefresh("test", time.Minute, refresh))
+	assert.Nil(t, m.Remove(context.TODO(), "alpha"))
+}
+
+func TestManagerList(t *testing.T) {
+	m, fakes := newManager(t)
+	assert.Nil(t, fakes["https://alpha.example.com"].Create(context.TODO(), newPod("default", "nginx", map[string]string{"app": "web"})))
+	assert.Nil(t, fakes["https://beta.example.com"].Create(context.TODO(),
+		newPod("default", "redis", map[string]string{"app": "cache"}),
+		newPod("test", "nginx", map[string]string{"app": "web"}),
+	))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	objects, err := m.List(ctx, podResource, "", labels.SelectorFromSet(labels.Set{"app": "web"}))
+	assert.Nil(t, err)
+	listed := []string{}
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj.Object)
+		assert.Nil(t, err)
+		listed = append(listed, obj.Cluster+":"+accessor.GetNamespace()+"/"+accessor.GetName())
+	}
+	assert.Equal(t, []string{"alpha:default/nginx", "beta:test/nginx"}, listed)
+
+	// the watches are tagged with their cluster and stopped when the cluster is removed
+	_, err = cache.WatchForClusterResource("beta", podResource)
+	assert.Nil(t, err)
+	beta, _ := m.Cluster("beta")
+	assert.Nil(t, m.Remove(context.TODO(), "beta"))
+	_, err = cache.WatchForClusterResource("beta", podResource)
+	assert.NotNil(t, err)
+	assert.EqualError(t, beta.Client.StartRefresh("access", time.Minute, client.RefreshAccess), "client closed")
+}
+
+func TestManagerDiscover(t *testing.T) {
+	m, fakes := newManager(t)
+	alpha, beta := fakes["https://alpha.example.com"], fakes["https://beta.example.com"]
+	widget := beta.AddCRD("example.com", "v1alpha1", "Widget", "widgets", true)
+	assert.Nil(t, alpha.AddNamespaces(context.TODO(), "default"))
+	assert.Nil(t, beta.AddNamespaces(context.TODO(), "default", "test"))
+	beta.AllowAll()
+
+	assert.Nil(t, m.Discover(context.TODO()))
+
+	// each cluster keeps its own discovery and the globals are not changed
+	a, _ := m.Cluster("alpha")
+	b, _ := m.Cluster("beta")
+	assert.Equal(t, []string{"default"}, a.Discovery().Namespaces)
+	assert.Equal(t, []string{"default", "test"}, b.Discovery().Namespaces)
+	assert.NotContains(t, a.Discovery().Resources.Get("namespace"), widget)
+	assert.Contains(t, b.Discovery().Resources.Get("namespace"), widget)
+	assert.False(t, a.Discovery().Access.Allowed("default", podResource, "list"))
+	assert.True(t, b.Discovery().Access.AllowedAll("test", widget, client.AutoAccessVerbs))
+	assert.Empty(t, cache.Namespaces)
+	assert.Nil(t, cache.Access)
+
+	// a failed cluster keeps its last discovery
+	alpha.InjectError("list", schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, fmt.Errorf("unavailable"))
+	err := m.Discover(context.TODO())
+	clusterErr := &errors.ClusterError{}
+	assert.ErrorAs(t, err, &clusterErr)
+	assert.Equal(t, "alpha", clusterErr.Cluster)
+	assert.Equal(t, []string{"default"}, a.Discovery().Namespaces)
+}
+
+func TestManagerHealth(t *testing.T) {
+	unreachable := fmt.Errorf("connection refused")
+	var down *client.Client
+	m, _ := newManager(t, multicluster.WithHealthCheck(func(ctx context.Context, c *client.Client) error {
+		if c == down {
+			return unreachable
+		}
+		return client.Ping(ctx, c)
+	}))
+
+	beta, ok := m.Cluster("beta")
+	assert.True(t, ok)
+	down = beta.Client
+	err := m.CheckHealth(context.TODO())
+	clusterErr := &errors.ClusterError{}
+	assert.ErrorAs(t, err, &clusterErr)
+	assert.Equal(t, "beta", clusterErr.Cluster)
+	assert.False(t, beta.Status().Healthy())
+
+	// unhealthy clusters are skipped by fan-outs
+	calls := sync.Map{}
+	results, err := multicluster.Do(context.TODO(), m, func(_ context.Context, c *multicluster.Cluster) (string, error) {
+		calls.Store(c.Name, true)
+		return c.Name, nil
+	})
+	assert.ErrorIs(t, err, unreachable)
+	assert.Equal(t, []multicluster.Result[string]{{Cluster: "alpha", Value: "alpha"}}, results)
+	_, called := calls.Load("beta")
+	assert.False(t, called)
+
+	down = nil
+	assert.Nil(t, m.CheckHealth(context.TODO()))
+	results, err = multicluster.Do(context.TODO(), m, func(_ context.Context, c *multicluster.Cluster) (string, error) {
+		if c.Name == "alpha" {
+			return "", fmt.Errorf("forbidden")
+		}
+		return c.Name, nil
+	})
+	assert.EqualError(t, err, "ClusterError - cluster:alpha, forbidden")
+	assert.Equal(t, []multicluster.Result[string]{{Cluster: "beta", Value: "beta"}}, results)
+}
@@ -0,0 +1,47 @@
+package multicluster
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+)
+
+type ManagerOption func(*Manager)
+
+// WithClientOptions are applied to the client of every cluster before its REST config. The WatcherFn is
+// replaced to tag the watches with the cluster name.
+func WithClientOptions(options ...client.ClientOption) ManagerOption {
+	return func(m *Manager) {
+		m.clientOptions = append(m.clientOptions, options...)
+	}
+}
+
+// WithClientFn creates the clients with fn instead of client.NewClient.
+func WithClientFn(fn func(context.Context, ...client.ClientOption) (*client.Client, error)) ManagerOption {
+	return func(m *Manager) {
+		m.clientFn = fn
+	}
+}
+
+// WithHealthCheck checks the health of the clusters with fn instead of client.Ping.
+func WithHealthCheck(fn func(context.Context, *client.Client) error) ManagerOption {
+	return func(m *Manager) {
+		m.healthCheck = fn
+	}
+}
+
+// WithHealthTimeout bounds a single health check, the default is DefaultHealthTimeout.
+func WithHealthTimeout(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.healthTimeout = d
+	}
+}
+
+func WithLogger(logger *zap.Logger) ManagerOption {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+// JSONPath is a compiled JSONPath expression evaluated against unstructured object content. It is safe
+// for concurrent use.
+type JSONPath struct {
+	path string
+	// expr is the validated template, the client-go JSONPath rewrites the parsed range templates while it
+	// evaluates them so every evaluation parses its own
+	expr string
+}
+
+// ParseJSONPath compiles a JSONPath, accepting the kubectl {.spec.replicas} form as well as .spec.replicas
+// and spec.replicas.
+func ParseJSONPath(path string) (*JSONPath, error) {
+	expr := strings.TrimSpace(path)
+	if !strings.HasPrefix(expr, "{") {
+		if !strings.HasPrefix(expr, ".") {
+			expr = "." + expr
+		}
+		expr = "{" + expr + "}"
+	}
+
+	j := &JSONPath{path: path, expr: expr}
+	if _, err := j.parse(); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q, %w", path, err)
+	}
+	return j, nil
+}
+
+func (j *JSONPath) parse() (*jsonpath.JSONPath, error) {
+	jp := jsonpath.New(j.path).AllowMissingKeys(true)
+	if err := jp.Parse(j.expr); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
+
+// String returns the JSONPath as it was provided to ParseJSONPath.
+func (j *JSONPath) String() string {
+	return j.path
+}
+
+// Value returns the first value found at the JSONPath and whether it exists.
+func (j *JSONPath) Value(content map[string]interface{}) (interface{}, bool, error) {
+	values, err := j.Values(content)
+	if err != nil || len(values) == 0 {
+		return nil, false, err
+	}
+	return values[0], true, nil
+}
+
+// Values returns every value found at the JSONPath, a path with wildcards such as
+// .spec.containers[*].image can return more than one value.
+func (j *JSONPath) Values(content map[string]interface{}) ([]interface{}, error) {
+	jp, err := j.parse()
+	if err != nil {
+		return nil, fmt.Errorf("JSONPath %q, %w", j.path, err)
+	}
+	results, err := jp.FindResults(content)
+	if err != nil {
+		return nil, fmt.Errorf("JSONPath %q, %w", j.path, err)
+	}
+	values := []interface{}{}
+	for _, result := range results {
+		for _, v := range result {
+			if !v.IsValid() || !v.CanInterface() {
+				continue
+			}
+			values = append(values, v.Interface())
+		}
+	}
+	return values, nil
+}
+
+// ObjectContent returns the unstructured content of the object, converting typed objects.
+func ObjectContent(obj runtime.Object) (map[string]interface{}, error) {
+	u, err := cache.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	return u.UnstructuredContent(), nil
+}
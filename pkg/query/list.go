@@ -0,0 +1,314 @@
+// Package query provides sorting, pagination and filtering of the objects cached by a ResourceLister.
+package query
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+const (
+	SortByName              = "name"
+	SortByNamespace         = "namespace"
+	SortByCreationTimestamp = "creationTimestamp"
+)
+
+// ListOptions controls the order and the page of objects returned by List.
+type ListOptions struct {
+	// Selector filters the objects, defaults to labels.Everything.
+	Selector labels.Selector
+	// Query filters the objects matching the Selector, use it to filter on fields across every namespace
+	// covered by the lister.
+	Query *Query
+	// SortBy is one of SortByName (default), SortByNamespace, SortByCreationTimestamp or a JSONPath
+	// such as .status.phase or {.spec.replicas}. Ties are broken by namespace and name.
+	SortBy string
+	// Descending reverses the sort order.
+	Descending bool
+	// Limit is the maximum number of objects returned, 0 returns all remaining objects.
+	Limit int
+	// Continue is the token returned by a previous call to resume listing after its last object.
+	Continue string
+}
+
+// ListResult is a page of objects.
+type ListResult struct {
+	Items []runtime.Object
+	// Continue is set when more objects are available, pass it in ListOptions.Continue to get the next page.
+	Continue string
+	// Remaining is the number of matching objects after this page.
+	Remaining int
+}
+
+// continueToken records the position of the last object of a page. Pages resume after this position
+// rather than at an offset so objects added or removed from the cache do not shift the following pages.
+type continueToken struct {
+	SortBy     string      `json:"s"`
+	Descending bool        `json:"d,omitempty"`
+	Filter     string      `json:"f"`
+	Value      interface{} `json:"v"`
+	Namespace  string      `json:"ns"`
+	Name       string      `json:"n"`
+}
+
+type sortableObject struct {
+	object    runtime.Object
+	value     interface{}
+	namespace string
+	name      string
+}
+
+// List returns a sorted page of the objects from the lister. When the lister returns partial results
+// along with an error, the page is built from the partial results and the error is returned.
+func List(lister cache.ResourceLister, opts ListOptions) (*ListResult, error) {
+	if opts.Selector == nil {
+		opts.Selector = labels.Everything()
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = SortByName
+	}
+
+	sortValue, err := sortValueFn(opts.SortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *continueToken
+	if opts.Continue != "" {
+		after, err = decodeContinue(opts.Continue)
+		if err != nil {
+			return nil, err
+		}
+		if after.SortBy != opts.SortBy || after.Descending != opts.Descending {
+			return nil, fmt.Errorf("continue token was created for a different sort order")
+		}
+		if after.Filter != filterHash(opts) {
+			return nil, fmt.Errorf("continue token was created for a different selector or query")
+		}
+	}
+
+	objs, listErr := lister.List(opts.Selector)
+	if opts.Query != nil {
+		if objs, err = opts.Query.Filter(objs); err != nil {
+			return nil, err
+		}
+	}
+
+	sortable := make([]sortableObject, 0, len(objs))
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, fmt.Errorf("sort, %w", err)
+		}
+		value, err := sortValue(obj, accessor)
+		if err != nil {
+			return nil, err
+		}
+		sortable = append(sortable, sortableObject{
+			object:    obj,
+			value:     value,
+			namespace: accessor.GetNamespace(),
+			name:      accessor.GetName(),
+		})
+	}
+
+	less := func(a, b sortableObject) bool {
+		c := compareSortable(a, b)
+		if opts.Descending {
+			return c > 0
+		}
+		return c < 0
+	}
+	sort.SliceStable(sortable, func(i, j int) bool {
+		return less(sortable[i], sortable[j])
+	})
+
+	start := 0
+	if after != nil {
+		last := sortableObject{value: after.Value, namespace: after.Namespace, name: after.Name}
+		start = sort.Search(len(sortable), func(i int) bool {
+			return less(last, sortable[i])
+		})
+	}
+
+	end := len(sortable)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+
+	result := &ListResult{Items: make([]runtime.Object, 0, end-start), Remaining: len(sortable) - end}
+	for _, s := range sortable[start:end] {
+		result.Items = append(result.Items, s.object)
+	}
+
+	if result.Remaining > 0 {
+		last := sortable[end-1]
+		result.Continue, err = encodeContinue(&continueToken{
+			SortBy:     opts.SortBy,
+			Descending: opts.Descending,
+			Filter:     filterHash(opts),
+			Value:      normalizeValue(last.value),
+			Namespace:  last.namespace,
+			Name:       last.name,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, listErr
+}
+
+func sortValueFn(sortBy string) (func(runtime.Object, metav1.Object) (interface{}, error), error) {
+	switch sortBy {
+	case SortByName:
+		return func(_ runtime.Object, a metav1.Object) (interface{}, error) { return a.GetName(), nil }, nil
+	case SortByNamespace:
+		return func(_ runtime.Object, a metav1.Object) (interface{}, error) { return a.GetNamespace(), nil }, nil
+	case SortByCreationTimestamp:
+		return func(_ runtime.Object, a metav1.Object) (interface{}, error) {
+			return a.GetCreationTimestamp().UTC().Format(time.RFC3339), nil
+		}, nil
+	}
+
+	jp, err := ParseJSONPath(sortBy)
+	if err != nil {
+		return nil, err
+	}
+	return func(obj runtime.Object, _ metav1.Object) (interface{}, error) {
+		content, err := ObjectContent(obj)
+		if err != nil {
+			return nil, err
+		}
+		value, _, err := jp.Value(content)
+		return value, err
+	}, nil
+}
+
+func compareSortable(a, b sortableObject) int {
+	if c := compareValues(a.value, b.value); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.namespace, b.namespace); c != 0 {
+		return c
+	}
+	return strings.Compare(a.name, b.name)
+}
+
+// compareValues orders values by type first, missing values, booleans, numbers, strings and then anything
+// else, and then by value within a type so the order is the same across values of mixed types.
+func compareValues(a, b interface{}) int {
+	a, b = normalizeValue(a), normalizeValue(b)
+	if ra, rb := valueRank(a), valueRank(b); ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+
+	switch av := a.(type) {
+	case nil:
+		return 0
+	case bool:
+		bv := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		default:
+			return 1
+		}
+	case float64:
+		bv := b.(float64)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		default:
+			return 0
+		}
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// valueRank is the position of the type of a normalized value in the order of compareValues.
+func valueRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// normalizeValue converts numbers to float64 so values compare the same before and after a JSON round trip.
+func normalizeValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case json.Number:
+		if f, err := strconv.ParseFloat(n.String(), 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// filterHash identifies the Selector and Query of the options in a continue token.
+func filterHash(opts ListOptions) string {
+	query := ""
+	if opts.Query != nil {
+		query = opts.Query.String()
+	}
+	sum := sha256.Sum256([]byte(opts.Selector.String() + "\x00" + query))
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeContinue(token *continueToken) (string, error) {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("encode continue token, %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeContinue(s string) (*continueToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid continue token, %w", err)
+	}
+	token := &continueToken{}
+	if err := json.Unmarshal(b, token); err != nil {
+		return nil, fmt.Errorf("invalid continue token, %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,174 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+)
+
+func newPod(namespace, name string, replicas int64, created time.Time) *unstructured.Unstructured {
+	u := wtesting.NewPod(namespace, name)
+	u.SetCreationTimestamp(metav1.NewTime(created))
+	u.SetLabels(map[string]string{"app": name})
+	_ = unstructured.SetNestedField(u.Object, replicas, "spec", "replicas")
+	return u
+}
+
+func names(objs []runtime.Object) []string {
+	list := []string{}
+	for _, obj := range objs {
+		list = append(list, obj.(*unstructured.Unstructured).GetName())
+	}
+	return list
+}
+
+func TestListSort(t *testing.T) {
+	now := time.Now()
+	lister := wtesting.NewFakeResourceLister(
+		newPod("b", "nginx", 10, now.Add(-time.Hour)),
+		newPod("a", "redis", 2, now),
+		newPod("c", "etcd", 3, now.Add(-2*time.Hour)),
+	)
+
+	tests := []struct {
+		name       string
+		sortBy     string
+		descending bool
+		expected   []string
+	}{
+		{name: "default", expected: []string{"etcd", "nginx", "redis"}},
+		{name: "namespace", sortBy: query.SortByNamespace, expected: []string{"redis", "nginx", "etcd"}},
+		{name: "creationTimestamp", sortBy: query.SortByCreationTimestamp, expected: []string{"etcd", "nginx", "redis"}},
+		{name: "descending", sortBy: query.SortByName, descending: true, expected: []string{"redis", "nginx", "etcd"}},
+		{name: "jsonpath numeric", sortBy: ".spec.replicas", expected: []string{"redis", "etcd", "nginx"}},
+		{name: "jsonpath braces", sortBy: "{.spec.replicas}", descending: true, expected: []string{"nginx", "etcd", "redis"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := query.List(lister, query.ListOptions{SortBy: tc.sortBy, Descending: tc.descending})
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, names(result.Items))
+			assert.Empty(t, result.Continue)
+		})
+	}
+}
+
+func TestListSortMixedTypes(t *testing.T) {
+	pods := []runtime.Object{}
+	for name, replicas := range map[string]interface{}{"string": "9", "large": int64(10), "small": int64(2), "bool": true, "missing": nil} {
+		pod := newPod("a", name, 0, time.Now())
+		unstructured.RemoveNestedField(pod.Object, "spec", "replicas")
+		if replicas != nil {
+			assert.Nil(t, unstructured.SetNestedField(pod.Object, replicas, "spec", "replicas"))
+		}
+		pods = append(pods, pod)
+	}
+	lister := wtesting.NewFakeResourceLister(pods...)
+
+	// values are ordered by type first, then by value
+	result, err := query.List(lister, query.ListOptions{SortBy: ".spec.replicas"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"missing", "bool", "small", "large", "string"}, names(result.Items))
+
+	// pages continue from the same position in the order
+	page, err := query.List(lister, query.ListOptions{SortBy: ".spec.replicas", Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"missing", "bool"}, names(page.Items))
+	page, err = query.List(lister, query.ListOptions{SortBy: ".spec.replicas", Limit: 2, Continue: page.Continue})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"small", "large"}, names(page.Items))
+}
+
+func TestListSelector(t *testing.T) {
+	lister := wtesting.NewFakeResourceLister(
+		newPod("a", "nginx", 1, time.Now()),
+		newPod("a", "redis", 1, time.Now()),
+	)
+
+	result, err := query.List(lister, query.ListOptions{Selector: labels.SelectorFromSet(labels.Set{"app": "redis"})})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"redis"}, names(result.Items))
+}
+
+func TestListPagination(t *testing.T) {
+	lister := wtesting.NewFakeResourceLister(
+		newPod("default", "a", 1, time.Now()),
+		newPod("default", "b", 1, time.Now()),
+		newPod("default", "c", 1, time.Now()),
+		newPod("default", "d", 1, time.Now()),
+	)
+
+	result, err := query.List(lister, query.ListOptions{Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, names(result.Items))
+	assert.Equal(t, 2, result.Remaining)
+	assert.NotEmpty(t, result.Continue)
+
+	// objects added before and removed from the current position do not shift the next page
+	lister.Objects = append(lister.Objects[1:], newPod("default", "aa", 1, time.Now()), newPod("default", "bb", 1, time.Now()))
+
+	result, err = query.List(lister, query.ListOptions{Limit: 2, Continue: result.Continue})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bb", "c"}, names(result.Items))
+	assert.Equal(t, 1, result.Remaining)
+
+	result, err = query.List(lister, query.ListOptions{Limit: 2, Continue: result.Continue})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"d"}, names(result.Items))
+	assert.Empty(t, result.Continue)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func TestListPaginationJSONPath(t *testing.T) {
+	lister := wtesting.NewFakeResourceLister(
+		newPod("default", "a", 3, time.Now()),
+		newPod("default", "b", 1, time.Now()),
+		newPod("default", "c", 2, time.Now()),
+	)
+
+	opts := query.ListOptions{SortBy: ".spec.replicas", Limit: 1}
+	result, err := query.List(lister, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, names(result.Items))
+
+	opts.Continue = result.Continue
+	result, err = query.List(lister, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"c"}, names(result.Items))
+}
+
+func TestListContinueErrors(t *testing.T) {
+	lister := wtesting.NewFakeResourceLister(
+		newPod("default", "a", 1, time.Now()),
+		newPod("default", "b", 1, time.Now()),
+	)
+
+	_, err := query.List(lister, query.ListOptions{Continue: "%%%"})
+	assert.NotNil(t, err)
+
+	result, err := query.List(lister, query.ListOptions{Limit: 1})
+	assert.Nil(t, err)
+
+	_, err = query.List(lister, query.ListOptions{Limit: 1, Continue: result.Continue, SortBy: query.SortByNamespace})
+	assert.NotNil(t, err)
+
+	// the token is bound to the selector and query of the first page
+	_, err = query.List(lister, query.ListOptions{Limit: 1, Continue: result.Continue, Selector: labels.SelectorFromSet(labels.Set{"app": "web"})})
+	assert.EqualError(t, err, "continue token was created for a different selector or query")
+	_, err = query.List(lister, query.ListOptions{Limit: 1, Continue: result.Continue, Query: query.MustCompile(`metadata.name == "b"`)})
+	assert.EqualError(t, err, "continue token was created for a different selector or query")
+	_, err = query.List(lister, query.ListOptions{Limit: 1, Continue: result.Continue, Selector: labels.Everything()})
+	assert.Nil(t, err)
+
+	_, err = query.List(lister, query.ListOptions{SortBy: "{.spec["})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,291 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+var operators = []string{"==", "!=", ">=", "<=", "&&", "||", "=", ">", "<", "!"}
+
+// lex splits a query into tokens. Words are JSONPaths, keywords and unquoted values, brackets within a
+// word are kept with it so paths such as spec.containers[?(@.name=="nginx")].image are a single token.
+func lex(query string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(query); {
+		c := rune(query[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		case c == '\'' || c == '"':
+			// a backslash escapes the following character
+			var value strings.Builder
+			end := i + 1
+			for ; end < len(query) && query[end] != query[i]; end++ {
+				if query[end] == '\\' && end+1 < len(query) {
+					end++
+				}
+				value.WriteByte(query[end])
+			}
+			if end >= len(query) {
+				return nil, &errors.InvalidQuery{Query: query, Position: i, Reason: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value.String(), pos: i})
+			i = end + 1
+		default:
+			if op := operatorAt(query, i); op != "" {
+				tokens = append(tokens, token{kind: tokenOperator, value: op, pos: i})
+				i += len(op)
+				continue
+			}
+			start, depth := i, 0
+			for i < len(query) {
+				c := rune(query[i])
+				if c == '[' || c == '{' {
+					depth++
+				} else if (c == ']' || c == '}') && depth > 0 {
+					depth--
+				} else if depth == 0 && (unicode.IsSpace(c) || c == '(' || c == ')' || c == ',' || operatorAt(query, i) != "") {
+					break
+				}
+				i++
+			}
+			if depth != 0 {
+				return nil, &errors.InvalidQuery{Query: query, Position: start, Reason: "unbalanced brackets"}
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: query[start:i], pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
+
+func operatorAt(query string, i int) string {
+	for _, op := range operators {
+		if strings.HasPrefix(query[i:], op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parser is a recursive descent parser for the grammar
+//
+//	expr       = and { ("or" | "||") and }
+//	and        = unary { ("and" | "&&") unary }
+//	unary      = ("not" | "!") unary | primary
+//	primary    = "(" expr ")" | "exists" path | path operator value | path ["not"] "in" "(" value { "," value } ")"
+//	operator   = "=" | "==" | "!=" | ">" | ">=" | "<" | "<="
+type parser struct {
+	query  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &errors.InvalidQuery{Query: p.query, Position: t.pos, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isKeyword(t token, keywords ...string) bool {
+	if t.kind != tokenWord && t.kind != tokenOperator {
+		return false
+	}
+	for _, k := range keywords {
+		if strings.EqualFold(t.value, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(p.peek(), "and", "&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isKeyword(p.peek(), "not", "!") {
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node: n}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokenLParen:
+		n, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected )")
+		}
+		return n, nil
+	case p.isKeyword(t, "exists"):
+		path, err := p.parsePath(p.next())
+		if err != nil {
+			return nil, err
+		}
+		return &existsNode{path: path}, nil
+	case t.kind != tokenWord:
+		return nil, p.errorf(t, "expected a JSONPath")
+	}
+
+	path, err := p.parsePath(t)
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == tokenOperator && op.value != "!" && op.value != "&&" && op.value != "||":
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{path: path, op: op.value, value: value}, nil
+	case p.isKeyword(op, "in"):
+		return p.parseIn(path, false)
+	case p.isKeyword(op, "not") && p.isKeyword(p.peek(), "in"):
+		p.next()
+		return p.parseIn(path, true)
+	default:
+		return nil, p.errorf(op, "expected an operator after %s", path)
+	}
+}
+
+func (p *parser) parsePath(t token) (*JSONPath, error) {
+	if t.kind != tokenWord {
+		return nil, p.errorf(t, "expected a JSONPath")
+	}
+	path, err := ParseJSONPath(t.value)
+	if err != nil {
+		return nil, p.errorf(t, "%s", err)
+	}
+	return path, nil
+}
+
+func (p *parser) parseIn(path *JSONPath, negate bool) (node, error) {
+	if t := p.next(); t.kind != tokenLParen {
+		return nil, p.errorf(t, "expected ( after in")
+	}
+	values := []interface{}{}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		t := p.next()
+		if t.kind == tokenRParen {
+			break
+		}
+		if t.kind != tokenComma {
+			return nil, p.errorf(t, "expected , or )")
+		}
+	}
+	var n node = &inNode{path: path, values: values}
+	if negate {
+		n = &notNode{node: n}
+	}
+	return n, nil
+}
+
+// parseValue returns a quoted string as is and converts unquoted numbers, booleans and null.
+func (p *parser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.value, nil
+	case tokenWord:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if f, err := strconv.ParseFloat(t.value, 64); err == nil {
+			return f, nil
+		}
+		return t.value, nil
+	default:
+		return nil, p.errorf(t, "expected a value")
+	}
+}
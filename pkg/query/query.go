@@ -0,0 +1,225 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+)
+
+// Query is a compiled filter expression evaluated against cached objects. Use Compile to create a Query.
+//
+// A query compares the values found at JSONPaths with literal values and combines the comparisons
+// with and, or, not and parentheses:
+//
+//	status.phase != Running and spec.replicas > 3
+//	metadata.namespace in (default, kube-system) or not exists metadata.ownerReferences
+//	metadata.labels.app\.kubernetes\.io/name = "nginx"
+//
+// Unquoted values are converted to numbers, true, false and null when possible. A JSONPath that returns
+// several values, such as spec.containers[*].image, matches when any value matches. Comparisons on a
+// missing JSONPath are false, except != and not in which are true.
+type Query struct {
+	expr string
+	root node
+}
+
+// Compile parses the expression, returning an *errors.InvalidQuery when it is not valid.
+func Compile(expr string) (*Query, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, &errors.InvalidQuery{Query: expr, Position: 0, Reason: "empty query"}
+	}
+
+	p := &parser{query: expr, tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %q", t.value)
+	}
+	return &Query{expr: expr, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the expression cannot be parsed.
+func MustCompile(expr string) *Query {
+	q, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the expression the Query was compiled from.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Matches returns true when the object satisfies the Query.
+func (q *Query) Matches(obj runtime.Object) (bool, error) {
+	content, err := ObjectContent(obj)
+	if err != nil {
+		return false, err
+	}
+	return q.root.eval(content)
+}
+
+// Filter returns the objects that satisfy the Query.
+func (q *Query) Filter(objs []runtime.Object) ([]runtime.Object, error) {
+	matched := []runtime.Object{}
+	for _, obj := range objs {
+		ok, err := q.Matches(obj)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, obj)
+		}
+	}
+	return matched, nil
+}
+
+type node interface {
+	eval(content map[string]interface{}) (bool, error)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(content map[string]interface{}) (bool, error) {
+	ok, err := n.left.eval(content)
+	if err != nil || !ok {
+		return false, err
+	}
+	return n.right.eval(content)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(content map[string]interface{}) (bool, error) {
+	ok, err := n.left.eval(content)
+	if err != nil || ok {
+		return ok, err
+	}
+	return n.right.eval(content)
+}
+
+type notNode struct {
+	node node
+}
+
+func (n *notNode) eval(content map[string]interface{}) (bool, error) {
+	ok, err := n.node.eval(content)
+	return !ok, err
+}
+
+type existsNode struct {
+	path *JSONPath
+}
+
+func (n *existsNode) eval(content map[string]interface{}) (bool, error) {
+	values, err := n.path.Values(content)
+	return len(values) > 0, err
+}
+
+type inNode struct {
+	path   *JSONPath
+	values []interface{}
+}
+
+func (n *inNode) eval(content map[string]interface{}) (bool, error) {
+	found, err := n.path.Values(content)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range found {
+		for _, v := range n.values {
+			if valuesEqual(f, v) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+type compareNode struct {
+	path  *JSONPath
+	op    string
+	value interface{}
+}
+
+func (n *compareNode) eval(content map[string]interface{}) (bool, error) {
+	found, err := n.path.Values(content)
+	if err != nil {
+		return false, err
+	}
+
+	if n.op == "!=" {
+		for _, f := range found {
+			if valuesEqual(f, n.value) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	for _, f := range found {
+		var ok bool
+		switch n.op {
+		case "=", "==":
+			ok = valuesEqual(f, n.value)
+		case ">":
+			ok = f != nil && compareQueryValues(f, n.value) > 0
+		case ">=":
+			ok = f != nil && compareQueryValues(f, n.value) >= 0
+		case "<":
+			ok = f != nil && compareQueryValues(f, n.value) < 0
+		case "<=":
+			ok = f != nil && compareQueryValues(f, n.value) <= 0
+		default:
+			return false, fmt.Errorf("unsupported operator %s", n.op)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func valuesEqual(found, value interface{}) bool {
+	if found == nil || value == nil {
+		return found == nil && value == nil
+	}
+	return compareQueryValues(found, value) == 0
+}
+
+// compareQueryValues compares numerically when both values are numbers or numeric strings and as strings otherwise.
+func compareQueryValues(found, value interface{}) int {
+	ff, fok := toFloat(found)
+	vf, vok := toFloat(value)
+	if fok && vok {
+		return compareValues(ff, vf)
+	}
+	return strings.Compare(fmt.Sprint(found), fmt.Sprint(value))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := normalizeValue(v).(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
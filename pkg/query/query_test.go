@@ -0,0 +1,143 @@
+package query_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+)
+
+func newQueryPod(namespace, name, phase string, replicas int64, images ...string) *unstructured.Unstructured {
+	u := newPod(namespace, name, replicas, time.Now())
+	if phase != "" {
+		_ = unstructured.SetNestedField(u.Object, phase, "status", "phase")
+	}
+	containers := []interface{}{}
+	for _, image := range images {
+		containers = append(containers, map[string]interface{}{"image": image})
+	}
+	_ = unstructured.SetNestedSlice(u.Object, containers, "spec", "containers")
+	u.SetLabels(map[string]string{"app.kubernetes.io/name": name})
+	return u
+}
+
+func TestQueryMatches(t *testing.T) {
+	pod := newQueryPod("default", "nginx", "Pending", 5, "nginx:1.21", "envoy:1.19")
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{query: "status.phase != Running", expected: true},
+		{query: "status.phase = Pending", expected: true},
+		{query: "status.phase == 'Running'", expected: false},
+		{query: "spec.replicas > 3", expected: true},
+		{query: "spec.replicas >= 5 && spec.replicas <= 5", expected: true},
+		{query: "spec.replicas < 3", expected: false},
+		{query: "{.spec.replicas} > 3 and status.phase != Running", expected: true},
+		{query: "spec.replicas < 3 or status.phase = Pending", expected: true},
+		{query: "not (spec.replicas < 3 or status.phase = Pending)", expected: false},
+		{query: "!exists status.phase", expected: false},
+		{query: "exists metadata.ownerReferences", expected: false},
+		{query: "metadata.namespace in (default, kube-system)", expected: true},
+		{query: "metadata.namespace not in ('default')", expected: false},
+		{query: "spec.containers[*].image = envoy:1.19", expected: true},
+		{query: "spec.containers[*].image != envoy:1.19", expected: false},
+		{query: `metadata.labels.app\.kubernetes\.io/name = "nginx"`, expected: true},
+		{query: `exists spec.containers[?(@.image=="nginx:1.21")].image`, expected: true},
+		{query: "status.missing != x", expected: true},
+		{query: "status.missing > 1", expected: false},
+		{query: "status.missing = null", expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := query.Compile(tc.query)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.query, q.String())
+
+			ok, err := q.Matches(pod)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, ok)
+		})
+	}
+}
+
+func TestJSONPathConcurrent(t *testing.T) {
+	path, err := query.ParseJSONPath("{range .spec.containers[*]}{.image}{end}")
+	assert.Nil(t, err)
+	q, err := query.Compile("spec.containers[*].image = 'envoy:1.19'")
+	assert.Nil(t, err)
+
+	// a compiled JSONPath and Query are shared by the informer handlers of every watch
+	var group sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			pod := newQueryPod("default", "nginx", "Pending", 5, "nginx:1.21", "envoy:1.19")
+			for j := 0; j < 100; j++ {
+				values, err := path.Values(pod.Object)
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{"nginx:1.21", "envoy:1.19"}, values)
+				matches, err := q.Matches(pod)
+				assert.Nil(t, err)
+				assert.True(t, matches)
+			}
+		}()
+	}
+	group.Wait()
+}
+
+func TestQueryCompileErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		position int
+	}{
+		{query: "", position: 0},
+		{query: "spec.replicas >", position: 15},
+		{query: "spec.replicas 3", position: 14},
+		{query: "(status.phase = Running", position: 23},
+		{query: "status.phase = 'Running", position: 15},
+		{query: "metadata.namespace in (a b)", position: 25},
+		{query: "status.phase = Running)", position: 22},
+		{query: "metadata.labels['app = x", position: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := query.Compile(tc.query)
+			invalid := &errors.InvalidQuery{}
+			assert.ErrorAs(t, err, &invalid)
+			assert.Equal(t, tc.position, invalid.Position)
+		})
+	}
+
+	assert.Panics(t, func() { query.MustCompile("status.phase =") })
+}
+
+func TestListQuery(t *testing.T) {
+	lister := wtesting.NewFakeResourceLister(
+		newQueryPod("default", "nginx", "Running", 1),
+		newQueryPod("test", "redis", "Pending", 4),
+		newQueryPod("kube-system", "etcd", "Failed", 2),
+	)
+
+	result, err := query.List(lister, query.ListOptions{
+		Query:  query.MustCompile("status.phase != Running"),
+		SortBy: ".spec.replicas",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"etcd", "redis"}, names(result.Items))
+
+	filtered, err := query.MustCompile("metadata.namespace in (default, test)").Filter([]runtime.Object(lister.Objects))
+	assert.Nil(t, err)
+	assert.Len(t, filtered, 2)
+}
@@ -0,0 +1,78 @@
+// Package replay records the watch events of the cache to a file and replays them in to fake watches,
+// so the consumers of ResourceListers and EventHandlers can be run against a captured event stream.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// Event types.
+const (
+	Added   = "add"
+	Updated = "update"
+	Deleted = "delete"
+)
+
+// Event is a single recorded watch event. Key is the WatchKey of the watch that received the event
+// encoded with WatchKey.String and Object is the new object, or the last known state of a deleted object.
+// Resource is the watched resource, it is nil when the watch was no longer registered in ResourceWatches.
+type Event struct {
+	Type     string                     `json:"type"`
+	Key      string                     `json:"key"`
+	Time     time.Time                  `json:"time"`
+	Resource *resource.Resource         `json:"resource,omitempty"`
+	Object   *unstructured.Unstructured `json:"object"`
+}
+
+// WatchKey parses the Key of the Event.
+func (e Event) WatchKey() (cache.WatchKey, error) {
+	return cache.ParseWatchKey(e.Key)
+}
+
+// ReadEvents reads the events written by a Recorder, one JSON object per line.
+func ReadEvents(r io.Reader) ([]Event, error) {
+	events := []Event{}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			event := Event{}
+			if uerr := json.Unmarshal(data, &event); uerr != nil {
+				return nil, fmt.Errorf("line %d: %w", line, uerr)
+			}
+			if verr := validate(event); verr != nil {
+				return nil, fmt.Errorf("line %d: %w", line, verr)
+			}
+			events = append(events, event)
+		}
+		if err == io.EOF {
+			return events, nil
+		}
+	}
+}
+
+func validate(event Event) error {
+	switch event.Type {
+	case Added, Updated, Deleted:
+	default:
+		return fmt.Errorf("unknown event type %q", event.Type)
+	}
+	if event.Object == nil {
+		return fmt.Errorf("event missing object")
+	}
+	_, err := event.WatchKey()
+	return err
+}
@@ -0,0 +1,48 @@
+package replay
+
+import (
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+type RecorderOption func(*Recorder)
+
+// WithResources only records the events of watches for the resources.
+func WithResources(gvrs ...schema.GroupVersionResource) RecorderOption {
+	return func(r *Recorder) {
+		r.filter = func(key cache.WatchKey) bool {
+			for _, gvr := range gvrs {
+				if key.GroupVersionResource == gvr {
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
+
+// WithWatchKeyFilter only records the events of watches whose WatchKey the filter returns true for.
+func WithWatchKeyFilter(filter func(cache.WatchKey) bool) RecorderOption {
+	return func(r *Recorder) {
+		r.filter = filter
+	}
+}
+
+type PlayerOption func(*Player)
+
+// WithSpeed sets how fast events are replayed, 1 is the recorded speed, 10 is ten times faster and
+// 0 delivers the events without waiting.
+func WithSpeed(speed float64) PlayerOption {
+	return func(p *Player) {
+		p.speed = speed
+	}
+}
+
+// WithLogger sets the logger of the replayed watches.
+func WithLogger(logger *zap.Logger) PlayerOption {
+	return func(p *Player) {
+		p.logger = logger
+	}
+}
@@ -0,0 +1,205 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// Player replays recorded events in to fake watches. Each WatchKey of the events gets a watch created
+// with Watcher.Watch and added to the ResourceWatches registry, so the events reach Drain, the registered
+// EventHandlers and the Listers exactly as they would from a running Informer.
+type Player struct {
+	events  []Event
+	keys    []cache.WatchKey
+	watches map[cache.WatchKey]*playerWatch
+
+	speed  float64
+	logger *zap.Logger
+}
+
+type playerWatch struct {
+	informer *wtesting.FakeIndexerInformer
+	lister   cache.ResourceLister
+}
+
+// NewPlayer creates the watches for the events. An error is returned if a watch with one of the
+// WatchKeys already exists, stop it or reset the ResourceWatches registry first.
+func NewPlayer(ctx context.Context, events []Event, options ...PlayerOption) (*Player, error) {
+	p := &Player{
+		events:  events,
+		watches: map[cache.WatchKey]*playerWatch{},
+		speed:   1,
+		logger:  zap.NewNop(),
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+
+	for _, event := range events {
+		key, err := event.WatchKey()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.watches[key]; ok {
+			continue
+		}
+
+		watch, err := p.watch(ctx, key, event)
+		if err != nil {
+			p.Stop()
+			return nil, err
+		}
+		p.keys = append(p.keys, key)
+		p.watches[key] = watch
+	}
+	return p, nil
+}
+
+func (p *Player) watch(ctx context.Context, key cache.WatchKey, event Event) (*playerWatch, error) {
+	factory := wtesting.NewFakeIndexerInformerFactory()
+	watcher, err := cache.NewWatcher(ctx,
+		cache.WithDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())),
+		cache.WithDynamicSharedInformerFactory(factory),
+		cache.WithCluster(key.Cluster),
+		cache.WithLabelSelector(key.Selector),
+		cache.WithLogger(p.logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	gvr := key.GroupVersionResource
+	res := eventResource(gvr, event)
+	lister, err := watcher.Watch(ctx, key.Namespace, res, true)
+	if err != nil {
+		return nil, err
+	}
+	informer := factory.Informer(gvr)
+	if len(informer.Handlers()) == 0 {
+		return nil, fmt.Errorf("unable to replay, a watch for %s already exists", key)
+	}
+	return &playerWatch{informer: informer, lister: lister}, nil
+}
+
+// eventResource returns the recorded resource of the event. Events recorded without a resource are
+// replayed as a resource of the kind of the object, namespaced when the object has a namespace.
+func eventResource(gvr schema.GroupVersionResource, event Event) resource.Resource {
+	if event.Resource != nil {
+		return *event.Resource
+	}
+	kind := event.Object.GetKind()
+	return resource.Resource{
+		GroupVersionKind: gvr.GroupVersion().WithKind(kind),
+		APIResource: metav1.APIResource{
+			Name:       gvr.Resource,
+			Namespaced: event.Object.GetNamespace() != "",
+			Group:      gvr.Group,
+			Version:    gvr.Version,
+			Kind:       kind,
+			Verbs:      metav1.Verbs{"get", "list", "watch"},
+		},
+	}
+}
+
+// Keys returns the WatchKeys of the replayed watches in the order they first appear in the events.
+func (p *Player) Keys() []cache.WatchKey {
+	return append([]cache.WatchKey{}, p.keys...)
+}
+
+// Lister returns the ResourceLister of the replayed watch with the key.
+func (p *Player) Lister(key cache.WatchKey) (cache.ResourceLister, bool) {
+	watch, ok := p.watches[key]
+	if !ok {
+		return nil, false
+	}
+	return watch.lister, true
+}
+
+// Listers returns the ResourceListers of the replayed watches in the order of Keys.
+func (p *Player) Listers() []cache.ResourceLister {
+	listers := make([]cache.ResourceLister, len(p.keys))
+	for i, key := range p.keys {
+		listers[i] = p.watches[key].lister
+	}
+	return listers
+}
+
+// Play delivers the events in order, waiting between events for the time that passed between them
+// divided by the speed of the Player. Play returns when every event is delivered or the context is done.
+func (p *Player) Play(ctx context.Context) error {
+	var previous time.Time
+	for i, event := range p.events {
+		if i > 0 && p.speed > 0 {
+			if err := sleep(ctx, time.Duration(float64(event.Time.Sub(previous))/p.speed)); err != nil {
+				return err
+			}
+		}
+		previous = event.Time
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.deliver(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Player) deliver(event Event) error {
+	key, err := event.WatchKey()
+	if err != nil {
+		return err
+	}
+	watch, ok := p.watches[key]
+	if !ok {
+		return fmt.Errorf("no replay watch for %s", key)
+	}
+
+	obj := event.Object.DeepCopy()
+	switch event.Type {
+	case Added:
+		return watch.informer.Add(obj)
+	case Updated:
+		return watch.informer.Update(obj)
+	case Deleted:
+		return watch.informer.Delete(obj)
+	}
+	return fmt.Errorf("unknown event type %q", event.Type)
+}
+
+// Stop stops the replayed watches and removes them from the ResourceWatches registry.
+func (p *Player) Stop() {
+	for _, key := range p.keys {
+		lister := p.watches[key].lister
+		lister.Stop()
+		if v, ok := cache.ResourceWatches.Load(key); ok && v == lister {
+			cache.ResourceWatches.Delete(key)
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package replay
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// Recorder writes the watch events it receives to an io.Writer as JSON lines, read them back with ReadEvents.
+// Register the Recorder with cache.AddEventHandler, the objects already cached are recorded as adds:
+//
+//	recorder := replay.NewRecorder(f, replay.WithResources(podsGVR))
+//	remove := cache.AddEventHandler(recorder)
+//	defer remove()
+type Recorder struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	err   error
+	count int
+
+	filter func(cache.WatchKey) bool
+	now    func() time.Time
+}
+
+var _ cache.EventHandler = (*Recorder)(nil)
+
+// NewRecorder creates a Recorder writing to w. Writes are not buffered, so each event is written before
+// the EventHandler returns.
+func NewRecorder(w io.Writer, options ...RecorderOption) *Recorder {
+	r := &Recorder{
+		enc:    json.NewEncoder(w),
+		filter: func(cache.WatchKey) bool { return true },
+		now:    time.Now,
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Recorder) OnAdd(key cache.WatchKey, obj runtime.Object) {
+	r.record(Added, key, obj)
+}
+
+func (r *Recorder) OnUpdate(key cache.WatchKey, _, newObj runtime.Object) {
+	r.record(Updated, key, newObj)
+}
+
+func (r *Recorder) OnDelete(key cache.WatchKey, obj runtime.Object) {
+	r.record(Deleted, key, obj)
+}
+
+func (r *Recorder) record(eventType string, key cache.WatchKey, obj runtime.Object) {
+	if !r.filter(key) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	u, err := cache.ToUnstructured(obj)
+	if err != nil {
+		r.err = err
+		return
+	}
+	event := Event{Type: eventType, Key: key.String(), Time: r.now().UTC(), Resource: watchedResource(key), Object: u}
+	if err := r.enc.Encode(event); err != nil {
+		r.err = err
+		return
+	}
+	r.count++
+}
+
+// Err returns the first error encountered writing an event, no events are recorded after an error.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Count returns the number of events recorded.
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// watchedResource returns the resource of the watch with the key, or nil when it is not registered.
+func watchedResource(key cache.WatchKey) *resource.Resource {
+	v, ok := cache.ResourceWatches.Load(key)
+	if !ok {
+		return nil
+	}
+	detail, ok := v.(*cache.WatchDetail)
+	if !ok {
+		return nil
+	}
+	res := detail.Resource
+	return &res
+}
@@ -0,0 +1,177 @@
+package replay_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/replay"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+var (
+	podsGVR        = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	podsKey        = cache.WatchKey{GroupVersionResource: podsGVR, Namespace: "default"}
+)
+
+func newObject(apiVersion, kind, namespace, name, resourceVersion string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetResourceVersion(resourceVersion)
+	return u
+}
+
+func recordedEvents(start time.Time) []replay.Event {
+	pods := podsKey.String()
+	deployments := cache.WatchKey{GroupVersionResource: deploymentsGVR}.String()
+	return []replay.Event{
+		{Type: replay.Added, Key: pods, Time: start, Object: newObject("v1", "Pod", "default", "nginx", "1")},
+		{Type: replay.Added, Key: deployments, Time: start.Add(10 * time.Millisecond), Object: newObject("apps/v1", "Deployment", "test", "nginx", "1")},
+		{Type: replay.Updated, Key: pods, Time: start.Add(20 * time.Millisecond), Object: newObject("v1", "Pod", "default", "nginx", "2")},
+		{Type: replay.Added, Key: pods, Time: start.Add(30 * time.Millisecond), Object: newObject("v1", "Pod", "default", "redis", "3")},
+		{Type: replay.Deleted, Key: pods, Time: start.Add(40 * time.Millisecond), Object: newObject("v1", "Pod", "default", "nginx", "2")},
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	buf := &bytes.Buffer{}
+	recorder := replay.NewRecorder(buf, replay.WithResources(podsGVR))
+	remove := cache.AddEventHandler(recorder)
+	defer remove()
+
+	player, err := replay.NewPlayer(context.TODO(), recordedEvents(time.Now()), replay.WithSpeed(0))
+	assert.Nil(t, err)
+	assert.Nil(t, player.Play(context.TODO()))
+	// stopping the player deletes the replayed objects, the recorder is removed first
+	remove()
+	player.Stop()
+
+	assert.Nil(t, recorder.Err())
+	assert.Equal(t, 4, recorder.Count())
+
+	events, err := replay.ReadEvents(buf)
+	assert.Nil(t, err)
+	summary := []string{}
+	for _, event := range events {
+		key, err := event.WatchKey()
+		assert.Nil(t, err)
+		assert.Equal(t, podsKey, key)
+		summary = append(summary, event.Type+":"+event.Object.GetName()+"@"+event.Object.GetResourceVersion())
+	}
+	assert.Equal(t, []string{"add:nginx@1", "update:nginx@2", "add:redis@3", "delete:nginx@2"}, summary)
+	assert.NotNil(t, events[0].Resource)
+	assert.Equal(t, "Pod", events[0].Resource.GroupVersionKind.Kind)
+	assert.True(t, events[0].Resource.APIResource.Namespaced)
+
+	// the recording replays to the same state
+	player, err = replay.NewPlayer(context.TODO(), events, replay.WithSpeed(0))
+	assert.Nil(t, err)
+	defer player.Stop()
+	assert.Nil(t, player.Play(context.TODO()))
+
+	lister, ok := player.Lister(podsKey)
+	assert.True(t, ok)
+	objs, err := lister.List(labels.Everything())
+	assert.Nil(t, err)
+	assert.Len(t, objs, 1)
+	assert.Equal(t, "redis", objs[0].(*unstructured.Unstructured).GetName())
+}
+
+func TestPlayerDrain(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	player, err := replay.NewPlayer(context.TODO(), recordedEvents(time.Now()), replay.WithSpeed(2))
+	assert.Nil(t, err)
+	defer player.Stop()
+	assert.Len(t, player.Keys(), 2)
+	assert.Len(t, player.Listers(), 2)
+
+	// the replayed watches are found like any other watch
+	lister, err := cache.WatchForKind("", schema.GroupKind{Kind: "Pod"}, "default")
+	assert.Nil(t, err)
+
+	ch := make(chan interface{}, 10)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	lister.Drain(ch, stopCh)
+
+	start := time.Now()
+	assert.Nil(t, player.Play(context.TODO()))
+	// 40ms of events at twice the speed
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	names := []string{}
+	for len(names) < 3 {
+		select {
+		case obj := <-ch:
+			u := obj.(*unstructured.Unstructured)
+			names = append(names, u.GetName()+"@"+u.GetResourceVersion())
+		case <-time.After(time.Second):
+			t.Fatalf("timed out draining, got %v", names)
+		}
+	}
+	assert.Equal(t, []string{"nginx@1", "nginx@2", "redis@3"}, names)
+}
+
+func TestPlayerRecordedResource(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	// a namespaced resource watched across namespaces keeps its scope when the first object has none
+	res := resource.Resource{
+		GroupVersionKind: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+		APIResource:      metav1.APIResource{Name: "widgets", Namespaced: true, Kind: "Widget"},
+	}
+	key := cache.WatchKey{GroupVersionResource: res.GroupVersionResource()}
+	events := []replay.Event{
+		{Type: replay.Deleted, Key: key.String(), Time: time.Now(), Resource: &res, Object: newObject("example.com/v1", "Widget", "", "a", "1")},
+	}
+
+	player, err := replay.NewPlayer(context.TODO(), events, replay.WithSpeed(0))
+	assert.Nil(t, err)
+	defer player.Stop()
+
+	v, ok := cache.ResourceWatches.Load(key)
+	assert.True(t, ok)
+	assert.Equal(t, res, v.(*cache.WatchDetail).Resource)
+}
+
+func TestPlayerErrors(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+
+	player, err := replay.NewPlayer(context.TODO(), recordedEvents(time.Now()))
+	assert.Nil(t, err)
+
+	// watches for the same keys already exist
+	_, err = replay.NewPlayer(context.TODO(), recordedEvents(time.Now()))
+	assert.NotNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.ErrorIs(t, player.Play(ctx), context.Canceled)
+
+	player.Stop()
+	player, err = replay.NewPlayer(context.TODO(), recordedEvents(time.Now()))
+	assert.Nil(t, err)
+	player.Stop()
+
+	_, err = replay.ReadEvents(strings.NewReader(`{"type":"add","key":"//v1/pods/default","object":{"kind":"Pod","apiVersion":"v1"}}
+{"type":"create","key":"//v1/pods/default","object":{"kind":"Pod","apiVersion":"v1"}}
+`))
+	assert.EqualError(t, err, `line 2: unknown event type "create"`)
+}
@@ -13,6 +13,20 @@ func WithLogger(logger *zap.Logger) ResourceAccessOption {
 	}
 }
 
+// WithConcurrency sets the number of SelfSubjectAccessReviews NewResourceAccess will have in flight.
+func WithConcurrency(n int) ResourceAccessOption {
+	return func(r *resourceAccess) {
+		r.concurrency = n
+	}
+}
+
+// WithThrottle rate limits SelfSubjectAccessReviews and retries requests rejected with 429 TooManyRequests.
+func WithThrottle(t *Throttle) ResourceAccessOption {
+	return func(r *resourceAccess) {
+		r.throttle = t
+	}
+}
+
 func WithMinimumRBAC(verbs metav1.Verbs) ResourceAccessOption {
 	return func(r *resourceAccess) {
 		r.minimumVerbs = verbs
@@ -0,0 +1,174 @@
+package resource_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	rtesting "github.com/wwitzel3/k8s-resource-client/pkg/resource/testing"
+)
+
+var podResource = resource.Resource{
+	GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+	APIResource: metav1.APIResource{
+		Name:       "pods",
+		Namespaced: true,
+		Kind:       "Pod",
+		Verbs:      metav1.Verbs{"get", "list", "watch"},
+	},
+}
+
+const rbacRules = `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: monitoring
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      example.com/aggregate-to-monitoring: "true"
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: monitoring-apps
+  labels:
+    example.com/aggregate-to-monitoring: "true"
+rules:
+- apiGroups: ["apps"]
+  resources: ["*"]
+  verbs: ["list", "watch"]
+- apiGroups: [""]
+  resources: ["pods/log", "*/status"]
+  verbs: ["get"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: config
+rules:
+- apiGroups: [""]
+  resources: ["configmaps"]
+  resourceNames: ["settings"]
+  verbs: ["*"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: config
+subjects:
+- kind: User
+  name: jane
+roleRef:
+  kind: Role
+  name: config
+  apiGroup: rbac.authorization.k8s.io
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: monitoring
+  namespace: kube-system
+subjects:
+- kind: Group
+  name: sre
+roleRef:
+  kind: ClusterRole
+  name: monitoring
+  apiGroup: rbac.authorization.k8s.io
+`
+
+func attrs(namespace, group, resource, subresource, name, verb string) *v1.ResourceAttributes {
+	return &v1.ResourceAttributes{Namespace: namespace, Group: group, Resource: resource, Subresource: subresource, Name: name, Verb: verb}
+}
+
+func TestRBACFakeFixtures(t *testing.T) {
+	role := rtesting.NewServiceAccountRBACFake("default", "test-user")
+	assert.Nil(t, role.LoadYAML(rtesting.CreateRoleFixture))
+	ra := resource.NewResourceAccess(context.TODO(), role, "default", []resource.Resource{podResource, deploymentResource})
+	assert.True(t, ra.AllowedAll("default", podResource, []string{"list", "watch"}))
+	assert.False(t, ra.Allowed("default", deploymentResource, "list"))
+
+	allowed, err := role.Authorize(attrs("kube-system", "", "pods", "", "", "list"))
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	clusterRole := rtesting.NewServiceAccountRBACFake("default", "test-user")
+	assert.Nil(t, clusterRole.LoadYAML(rtesting.CreateClusterRoleFixture))
+	allowed, _ = clusterRole.Authorize(attrs("kube-system", "", "pods", "", "", "list"))
+	assert.True(t, allowed)
+	allowed, _ = clusterRole.Authorize(attrs("kube-system", "", "pods", "", "", "delete"))
+	assert.False(t, allowed)
+
+	// bindings are for the ServiceAccount only
+	other := rtesting.NewServiceAccountRBACFake("default", "other-user")
+	assert.Nil(t, other.LoadYAML(rtesting.CreateClusterRoleFixture))
+	allowed, _ = other.Authorize(attrs("default", "", "pods", "", "", "list"))
+	assert.False(t, allowed)
+}
+
+func TestRBACFakeRules(t *testing.T) {
+	sre := rtesting.NewRBACFake("bob", "sre")
+	assert.Nil(t, sre.LoadYAML([]byte(rbacRules)))
+
+	tests := []struct {
+		attrs   *v1.ResourceAttributes
+		allowed bool
+	}{
+		// aggregated from monitoring-apps with a resource wildcard
+		{attrs("kube-system", "apps", "deployments", "", "", "list"), true},
+		{attrs("kube-system", "apps", "deployments", "", "", "delete"), false},
+		{attrs("default", "apps", "deployments", "", "", "list"), false},
+		// subresources
+		{attrs("kube-system", "", "pods", "log", "nginx", "get"), true},
+		{attrs("kube-system", "", "pods", "status", "nginx", "get"), true},
+		{attrs("kube-system", "", "pods", "exec", "nginx", "get"), false},
+		{attrs("kube-system", "", "pods", "", "nginx", "get"), false},
+	}
+	for _, test := range tests {
+		allowed, err := sre.Authorize(test.attrs)
+		assert.Nil(t, err)
+		assert.Equal(t, test.allowed, allowed, "%+v", *test.attrs)
+	}
+
+	jane := rtesting.NewRBACFake("jane")
+	assert.Nil(t, jane.LoadYAML([]byte(rbacRules)))
+	review, err := jane.Create(context.TODO(), &v1.SelfSubjectAccessReview{
+		Spec: v1.SelfSubjectAccessReviewSpec{ResourceAttributes: attrs("default", "", "configmaps", "", "settings", "update")},
+	}, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	assert.True(t, review.Status.Allowed)
+	assert.Contains(t, review.Status.Reason, `RoleBinding "default/config"`)
+
+	// resourceNames only match the named objects
+	allowed, _ := jane.Authorize(attrs("default", "", "configmaps", "", "other", "update"))
+	assert.False(t, allowed)
+	allowed, _ = jane.Authorize(attrs("default", "", "configmaps", "", "", "list"))
+	assert.False(t, allowed)
+
+	rules, err := jane.RulesReviews().Create(context.TODO(), &v1.SelfSubjectRulesReview{
+		Spec: v1.SelfSubjectRulesReviewSpec{Namespace: "default"},
+	}, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []v1.ResourceRule{{
+		Verbs:         []string{"*"},
+		APIGroups:     []string{""},
+		Resources:     []string{"configmaps"},
+		ResourceNames: []string{"settings"},
+	}}, rules.Status.ResourceRules)
+
+	rules, err = sre.RulesReviews().Create(context.TODO(), &v1.SelfSubjectRulesReview{
+		Spec: v1.SelfSubjectRulesReviewSpec{Namespace: "kube-system"},
+	}, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, rules.Status.ResourceRules, 2)
+
+	_, err = jane.Create(context.TODO(), &v1.SelfSubjectAccessReview{}, metav1.CreateOptions{})
+	assert.NotNil(t, err)
+	assert.NotNil(t, jane.LoadYAML([]byte("apiVersion: v1\nkind: ServiceAccount\n")))
+}
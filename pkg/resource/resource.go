@@ -4,7 +4,10 @@ package resource
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	authv1 "k8s.io/api/authorization/v1"
@@ -13,6 +16,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/discovery"
 	authClient "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/metrics"
 )
 
 const (
@@ -60,7 +65,9 @@ func ResourceList(_ context.Context, logger *zap.Logger, client discovery.Server
 		resourceList = client.ServerPreferredNamespacedResources
 	}
 
+	start := time.Now()
 	resources, err := resourceList()
+	metrics.ObserveDiscovery("resources", start, err)
 	if err != nil {
 		if resources == nil {
 			return nil, fmt.Errorf("get preferred resources: %w", err)
@@ -112,58 +119,136 @@ type ResourceAccess interface {
 	Allowed(namespace string, resource Resource, verb string) bool
 	AllowedAll(namespace string, resource Resource, verbs []string) bool
 	AllowedAny(namespace string, resource Resource, verbs []string) bool
+	Status(namespace string, resource Resource, verb string) (int, bool)
+	Entries() []AccessEntry
+	Resource(key string) (Resource, bool)
 	String() string
 }
 
+// AccessEntry is the status of a single verb for a resource in a namespace of the access map.
+// Resource is the Key of the Resource and Status is one of Denied, Allowed, Unused or Error.
+type AccessEntry struct {
+	Namespace string `json:"namespace"`
+	Resource  string `json:"resource"`
+	Verb      string `json:"verb"`
+	Status    int    `json:"status"`
+}
+
+// AllowedNamespaces returns the namespaces the verb is allowed on the resource in. For a namespace it is the
+// namespace when allowed. For "" it is "" when allowed across all namespaces, otherwise for a namespaced
+// resource every namespace of the access map it is allowed in. Nil is returned when it is not allowed.
+func AllowedNamespaces(access ResourceAccess, resource Resource, namespace, verb string) []string {
+	if access.Allowed(namespace, resource, verb) {
+		return []string{namespace}
+	}
+	if namespace != "" || !resource.APIResource.Namespaced {
+		return nil
+	}
+
+	var namespaces []string
+	for _, entry := range access.Entries() {
+		if entry.Namespace != "" && entry.Resource == resource.Key() && entry.Verb == verb && entry.Status == Allowed {
+			namespaces = append(namespaces, entry.Namespace)
+		}
+	}
+	return namespaces
+}
+
 var _ ResourceAccess = (*resourceAccess)(nil)
 
 // NewResourceAccess provides a ResourceAccess object with an access map popluated from issuing SelfSubjectAccessReview
-// requests for the list of resources and verbs provided.
+// requests for the list of resources and verbs provided. At most WithConcurrency requests are in flight at once.
 func NewResourceAccess(ctx context.Context, client authClient.SelfSubjectAccessReviewInterface, namespace string, resources []Resource, options ...ResourceAccessOption) *resourceAccess {
 	ra := &resourceAccess{
 		access:       sync.Map{},
 		logger:       zap.NewNop(),
 		minimumVerbs: metav1.Verbs{"list", "watch"},
 		namespace:    namespace,
+		concurrency:  DefaultAccessConcurrency,
 	}
 
 	for _, o := range options {
 		o(ra)
 	}
 
+	if ra.concurrency < 1 {
+		ra.concurrency = 1
+	}
+
+	work := make(chan Resource)
 	group := sync.WaitGroup{}
-	for _, resource := range resources {
+	for i := 0; i < ra.concurrency; i++ {
 		group.Add(1)
 
-		r := resource
 		go func() {
 			defer group.Done()
 
-			for _, verb := range ra.minimumVerbs {
-				select {
-				case <-ctx.Done():
-					return
-				default:
+			for r := range work {
+				for _, verb := range ra.minimumVerbs {
+					if ctx.Err() != nil {
+						break
+					}
+					ra.Update(ctx, client, namespace, r, verb)
 				}
-				ra.Update(ctx, client, namespace, r, verb)
 			}
 		}()
 	}
 
+	for _, resource := range resources {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- resource
+	}
+	close(work)
+
 	group.Wait()
 
 	return ra
 }
 
+// NewResourceAccessFromEntries provides a ResourceAccess object with an access map populated from the entries,
+// such as those returned by Entries, without issuing any SelfSubjectAccessReview requests.
+func NewResourceAccessFromEntries(entries []AccessEntry, options ...ResourceAccessOption) *resourceAccess {
+	ra := &resourceAccess{
+		access:       sync.Map{},
+		logger:       zap.NewNop(),
+		minimumVerbs: metav1.Verbs{"list", "watch"},
+		concurrency:  DefaultAccessConcurrency,
+	}
+
+	for _, o := range options {
+		o(ra)
+	}
+
+	for _, entry := range entries {
+		ra.access.Store(resourceVerbKey(entry.Namespace, entry.Resource, entry.Verb), entry.Status)
+	}
+	return ra
+}
+
 type resourceAccess struct {
 	access       sync.Map
+	resources    sync.Map // key:string, value:Resource reviewed by Update
 	logger       *zap.Logger
 	minimumVerbs metav1.Verbs
 	namespace    string
+	concurrency  int
+	throttle     *Throttle
 }
 
 // Allowed checks if the given verb is allowed for the GVK.
 func (r *resourceAccess) Allowed(namespace string, resource Resource, verb string) bool {
+	s, found := r.Status(namespace, resource, verb)
+	if !found {
+		return false
+	}
+	return statusIntAsBool(s)
+}
+
+// Status returns the status of the verb for the GVK, Denied, Allowed, Unused or Error, and false when the
+// verb has not been reviewed in the namespace.
+func (r *resourceAccess) Status(namespace string, resource Resource, verb string) (int, bool) {
 	key := resourceVerbKey(namespace, resource.Key(), verb)
 
 	v, found := r.access.Load(key)
@@ -171,7 +256,7 @@ func (r *resourceAccess) Allowed(namespace string, resource Resource, verb strin
 		r.logger.Debug("not found",
 			zap.String("key", key),
 		)
-		return false
+		return 0, false
 	}
 
 	s, ok := v.(int)
@@ -179,10 +264,9 @@ func (r *resourceAccess) Allowed(namespace string, resource Resource, verb strin
 		r.logger.Warn("unable to type convert status to int, malformed access map",
 			zap.String("value", fmt.Sprintf("%v", v)),
 		)
-		return false
+		return 0, false
 	}
-
-	return statusIntAsBool(s)
+	return s, true
 }
 
 // AllowedAll checks if all of the given verbs are allowed for the GVK.
@@ -208,6 +292,7 @@ func (r *resourceAccess) AllowedAny(namespace string, resource Resource, verbs [
 func (ra *resourceAccess) Update(ctx context.Context, client authClient.SelfSubjectAccessReviewInterface, namespace string, resource Resource, verb string) {
 	apiVerbs := sets.NewString(resource.APIResource.Verbs...)
 	key := resourceVerbKey(namespace, resource.Key(), verb)
+	ra.resources.Store(resource.Key(), resource)
 
 	if !apiVerbs.Has(verb) {
 		ra.access.Store(key, Unused)
@@ -225,13 +310,22 @@ func (ra *resourceAccess) Update(ctx context.Context, client authClient.SelfSubj
 		},
 	}
 
-	if result, err := client.Create(ctx, sar, metav1.CreateOptions{}); err != nil {
+	var result *authv1.SelfSubjectAccessReview
+	start := time.Now()
+	err := ra.throttle.Do(ctx, func() (err error) {
+		result, err = client.Create(ctx, sar, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil {
+		metrics.ObserveAccessReview("error", start)
 		ra.logger.Error("error SelfSubjectAccessReview", zap.Error(err))
 		ra.access.Store(key, Error)
 	} else {
 		if result.Status.Allowed {
+			metrics.ObserveAccessReview("allowed", start)
 			ra.access.Store(key, Allowed)
 		} else {
+			metrics.ObserveAccessReview("denied", start)
 			ra.logger.Warn("resource failed minimum RBAC requirement",
 				zap.String("reason", result.Status.Reason),
 				zap.String("evaluation_error", result.Status.EvaluationError),
@@ -243,25 +337,72 @@ func (ra *resourceAccess) Update(ctx context.Context, client authClient.SelfSubj
 	}
 }
 
+// Resource returns the Resource with the key that was reviewed by Update. Access maps populated by
+// NewResourceAccessFromEntries only hold the keys of their resources.
+func (r *resourceAccess) Resource(key string) (Resource, bool) {
+	v, ok := r.resources.Load(key)
+	if !ok {
+		return Resource{}, false
+	}
+	res, ok := v.(Resource)
+	return res, ok
+}
+
+// Entries returns the entries of the access map sorted by namespace, resource and verb.
+// Malformed entries are skipped.
+func (r *resourceAccess) Entries() []AccessEntry {
+	entries := []AccessEntry{}
+	r.access.Range(func(key, value interface{}) bool {
+		s, ok := key.(string)
+		if !ok {
+			return true
+		}
+		v, ok := value.(int)
+		if !ok {
+			return true
+		}
+		// namespaces and verbs do not contain dots, the resource key is everything between them
+		first, last := strings.Index(s, "."), strings.LastIndex(s, ".")
+		if first < 0 || first == last {
+			return true
+		}
+		entries = append(entries, AccessEntry{Namespace: s[:first], Resource: s[first+1 : last], Verb: s[last+1:], Status: v})
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Verb < b.Verb
+	})
+	return entries
+}
+
+// String returns the access map one key per line, sorted. Malformed entries are skipped.
 func (r *resourceAccess) String() string {
-	result := ""
+	lines := []string{}
 	printer := func(key, value interface{}) bool {
 		s, ok := key.(string)
 		if !ok {
-			return false
+			return true
 		}
 
 		v, ok := value.(int)
 		if !ok {
-			return false
+			return true
 		}
 
-		result += fmt.Sprintf("%s: %d\n", s, v)
+		lines = append(lines, fmt.Sprintf("%s: %d\n", s, v))
 
 		return true
 	}
 	r.access.Range(printer)
-	return result
+	sort.Strings(lines)
+	return strings.Join(lines, "")
 }
 
 func statusIntAsBool(i int) bool {
@@ -154,6 +154,46 @@ func TestResourceAccessChecksDenied(t *testing.T) {
 	assert.Contains(t, ra.String(), "apps.v1.deployment.patch: 2")
 }
 
+func TestResourceAccessEntries(t *testing.T) {
+	authFake := rtesting.SubjectAccessFake{}
+	authFake.CreateFn = func(fake *rtesting.SubjectAccessFake) (*v1.SelfSubjectAccessReview, error) {
+		return &v1.SelfSubjectAccessReview{Status: v1.SubjectAccessReviewStatus{Allowed: true}}, nil
+	}
+
+	ra := resource.NewResourceAccess(context.TODO(), authFake, "default", []resource.Resource{deploymentResource},
+		resource.WithLogger(zap.NewNop()),
+		resource.WithMinimumRBAC([]string{"watch", "list", "patch"}),
+	)
+	ra.Update(context.TODO(), authFake, "", deploymentResource, "list")
+
+	entries := ra.Entries()
+	assert.Equal(t, []resource.AccessEntry{
+		{Namespace: "", Resource: "apps.v1.deployment", Verb: "list", Status: resource.Allowed},
+		{Namespace: "default", Resource: "apps.v1.deployment", Verb: "list", Status: resource.Allowed},
+		{Namespace: "default", Resource: "apps.v1.deployment", Verb: "patch", Status: resource.Unused},
+		{Namespace: "default", Resource: "apps.v1.deployment", Verb: "watch", Status: resource.Allowed},
+	}, entries)
+
+	loaded := resource.NewResourceAccessFromEntries(entries)
+	assert.True(t, loaded.AllowedAll("default", deploymentResource, []string{"list", "watch"}))
+	assert.True(t, loaded.Allowed("", deploymentResource, "list"))
+	assert.False(t, loaded.Allowed("default", deploymentResource, "patch"))
+	status, ok := loaded.Status("default", deploymentResource, "patch")
+	assert.True(t, ok)
+	assert.Equal(t, resource.Unused, status)
+	_, ok = loaded.Status("default", deploymentResource, "delete")
+	assert.False(t, ok)
+	assert.Equal(t, ra.String(), loaded.String())
+	assert.Equal(t, entries, loaded.Entries())
+
+	// only the access map that reviewed the resource holds it
+	res, ok := ra.Resource(deploymentResource.Key())
+	assert.True(t, ok)
+	assert.Equal(t, deploymentResource, res)
+	_, ok = loaded.Resource(deploymentResource.Key())
+	assert.False(t, ok)
+}
+
 var deploymentResource = resource.Resource{
 	GroupVersionKind: schema.GroupVersionKind{Version: "v1", Group: "apps", Kind: "deployment"},
 	APIResource: metav1.APIResource{
@@ -0,0 +1,452 @@
+package testing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	authv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// CreateRoleFixture is the scripts/create-role.sh scenario, a Role allowing get, list and watch of pods in
+// the default namespace bound to the test-user ServiceAccount.
+//
+//go:embed fixtures/create-role.yaml
+var CreateRoleFixture []byte
+
+// CreateClusterRoleFixture is the scripts/create-cluster-role.sh scenario, a ClusterRole allowing get, list
+// and watch of pods bound to the test-user ServiceAccount in every namespace.
+//
+//go:embed fixtures/create-cluster-role.yaml
+var CreateClusterRoleFixture []byte
+
+var _ authv1.SelfSubjectAccessReviewInterface = (*RBACFake)(nil)
+
+// RBACFake answers SelfSubjectAccessReviews for a user from in-memory Roles, ClusterRoles and their bindings
+// the way the RBAC authorizer does, including wildcards, resourceNames, subresources and aggregated
+// ClusterRoles. Use RulesReviews for the SelfSubjectRulesReviews of the user.
+type RBACFake struct {
+	User   string
+	Groups []string
+
+	mu                  sync.RWMutex
+	roles               map[string]*rbacv1.Role
+	clusterRoles        map[string]*rbacv1.ClusterRole
+	roleBindings        map[string]*rbacv1.RoleBinding
+	clusterRoleBindings map[string]*rbacv1.ClusterRoleBinding
+}
+
+// NewRBACFake creates an RBACFake for the user in the groups, system:authenticated is always included.
+func NewRBACFake(user string, groups ...string) *RBACFake {
+	return &RBACFake{
+		User:                user,
+		Groups:              append([]string{"system:authenticated"}, groups...),
+		roles:               map[string]*rbacv1.Role{},
+		clusterRoles:        map[string]*rbacv1.ClusterRole{},
+		roleBindings:        map[string]*rbacv1.RoleBinding{},
+		clusterRoleBindings: map[string]*rbacv1.ClusterRoleBinding{},
+	}
+}
+
+// NewServiceAccountRBACFake creates an RBACFake for the ServiceAccount with the user name and groups the
+// API server authenticates its tokens as.
+func NewServiceAccountRBACFake(namespace, name string) *RBACFake {
+	return NewRBACFake(serviceAccountUser(namespace, name), "system:serviceaccounts", "system:serviceaccounts:"+namespace)
+}
+
+func serviceAccountUser(namespace, name string) string {
+	return "system:serviceaccount:" + namespace + ":" + name
+}
+
+// Add adds Roles, ClusterRoles, RoleBindings and ClusterRoleBindings, replacing any with the same name.
+func (f *RBACFake) Add(objs ...interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *rbacv1.Role:
+			f.roles[o.Namespace+"/"+o.Name] = o
+		case *rbacv1.ClusterRole:
+			f.clusterRoles[o.Name] = o
+		case *rbacv1.RoleBinding:
+			f.roleBindings[o.Namespace+"/"+o.Name] = o
+		case *rbacv1.ClusterRoleBinding:
+			f.clusterRoleBindings[o.Name] = o
+		default:
+			return fmt.Errorf("unsupported RBAC object %T", obj)
+		}
+	}
+	return nil
+}
+
+// LoadYAML adds the RBAC objects of the multi-document YAML. Namespaced objects without a namespace are
+// put in the default namespace, like kubectl apply does.
+func (f *RBACFake) LoadYAML(data []byte) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		meta := metav1.TypeMeta{}
+		if err := yaml.Unmarshal(doc, &meta); err != nil {
+			return err
+		}
+		if meta.APIVersion != rbacv1.SchemeGroupVersion.String() {
+			return fmt.Errorf("unsupported apiVersion %q for %s", meta.APIVersion, meta.Kind)
+		}
+
+		var obj interface{}
+		switch meta.Kind {
+		case "Role":
+			obj = &rbacv1.Role{}
+		case "ClusterRole":
+			obj = &rbacv1.ClusterRole{}
+		case "RoleBinding":
+			obj = &rbacv1.RoleBinding{}
+		case "ClusterRoleBinding":
+			obj = &rbacv1.ClusterRoleBinding{}
+		default:
+			return fmt.Errorf("unsupported kind %q", meta.Kind)
+		}
+		if err := yaml.Unmarshal(doc, obj); err != nil {
+			return err
+		}
+		switch o := obj.(type) {
+		case *rbacv1.Role:
+			if o.Namespace == "" {
+				o.Namespace = metav1.NamespaceDefault
+			}
+		case *rbacv1.RoleBinding:
+			if o.Namespace == "" {
+				o.Namespace = metav1.NamespaceDefault
+			}
+		}
+		if err := f.Add(obj); err != nil {
+			return err
+		}
+	}
+}
+
+// LoadFile adds the RBAC objects of the multi-document YAML file.
+func (f *RBACFake) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return f.LoadYAML(data)
+}
+
+func (f *RBACFake) Create(ctx context.Context, selfSubjectAccessReview *v1.SelfSubjectAccessReview, opts metav1.CreateOptions) (*v1.SelfSubjectAccessReview, error) {
+	result := selfSubjectAccessReview.DeepCopy()
+	spec := result.Spec
+	switch {
+	case spec.ResourceAttributes != nil:
+		result.Status.Allowed, result.Status.Reason = f.authorize(spec.ResourceAttributes.Namespace, func(rule rbacv1.PolicyRule) bool {
+			return resourceRuleAllows(rule, spec.ResourceAttributes)
+		})
+	case spec.NonResourceAttributes != nil:
+		result.Status.Allowed, result.Status.Reason = f.authorize("", func(rule rbacv1.PolicyRule) bool {
+			return nonResourceRuleAllows(rule, spec.NonResourceAttributes)
+		})
+	default:
+		return nil, fmt.Errorf("exactly one of nonResourceAttributes or resourceAttributes must be specified")
+	}
+	return result, nil
+}
+
+// Authorize returns if the user is allowed the resource attributes, it can be used as the access function of
+// the FakeCluster in pkg/client/testing.
+func (f *RBACFake) Authorize(attrs *v1.ResourceAttributes) (bool, error) {
+	allowed, _ := f.authorize(attrs.Namespace, func(rule rbacv1.PolicyRule) bool {
+		return resourceRuleAllows(rule, attrs)
+	})
+	return allowed, nil
+}
+
+// authorize finds a rule bound to the user in the namespace that allows the request and returns the reason
+// like the RBAC authorizer.
+func (f *RBACFake) authorize(namespace string, allows func(rbacv1.PolicyRule) bool) (bool, string) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, binding := range sortedClusterRoleBindings(f.clusterRoleBindings) {
+		if !f.appliesTo(binding.Subjects, "") {
+			continue
+		}
+		for _, rule := range f.roleRefRules(binding.RoleRef, "") {
+			if allows(rule) {
+				return true, fmt.Sprintf("RBAC: allowed by ClusterRoleBinding %q of ClusterRole %q to %s", binding.Name, binding.RoleRef.Name, f.User)
+			}
+		}
+	}
+
+	if namespace == "" {
+		return false, ""
+	}
+	for _, binding := range sortedRoleBindings(f.roleBindings) {
+		if binding.Namespace != namespace || !f.appliesTo(binding.Subjects, namespace) {
+			continue
+		}
+		for _, rule := range f.roleRefRules(binding.RoleRef, namespace) {
+			if allows(rule) {
+				return true, fmt.Sprintf("RBAC: allowed by RoleBinding %q of %s %q to %s", binding.Namespace+"/"+binding.Name, binding.RoleRef.Kind, binding.RoleRef.Name, f.User)
+			}
+		}
+	}
+	return false, ""
+}
+
+// RulesReviews returns the SelfSubjectRulesReview client of the user.
+func (f *RBACFake) RulesReviews() authv1.SelfSubjectRulesReviewInterface {
+	return &rbacRulesReviewFake{rbac: f}
+}
+
+// Rules returns the rules that apply to the user in the namespace, the cluster wide rules for no namespace.
+func (f *RBACFake) Rules(namespace string) []rbacv1.PolicyRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	rules := []rbacv1.PolicyRule{}
+	for _, binding := range sortedClusterRoleBindings(f.clusterRoleBindings) {
+		if f.appliesTo(binding.Subjects, "") {
+			rules = append(rules, f.roleRefRules(binding.RoleRef, "")...)
+		}
+	}
+	if namespace == "" {
+		return rules
+	}
+	for _, binding := range sortedRoleBindings(f.roleBindings) {
+		if binding.Namespace == namespace && f.appliesTo(binding.Subjects, namespace) {
+			rules = append(rules, f.roleRefRules(binding.RoleRef, namespace)...)
+		}
+	}
+	return rules
+}
+
+func (f *RBACFake) appliesTo(subjects []rbacv1.Subject, namespace string) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if subject.Name == f.User {
+				return true
+			}
+		case rbacv1.GroupKind:
+			for _, group := range f.Groups {
+				if subject.Name == group {
+					return true
+				}
+			}
+		case rbacv1.ServiceAccountKind:
+			saNamespace := namespace
+			if subject.Namespace != "" {
+				saNamespace = subject.Namespace
+			}
+			if saNamespace != "" && serviceAccountUser(saNamespace, subject.Name) == f.User {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// roleRefRules returns the rules of the referenced role, an unknown role has no rules.
+func (f *RBACFake) roleRefRules(ref rbacv1.RoleRef, namespace string) []rbacv1.PolicyRule {
+	switch ref.Kind {
+	case "Role":
+		if role, ok := f.roles[namespace+"/"+ref.Name]; ok {
+			return role.Rules
+		}
+	case "ClusterRole":
+		if role, ok := f.clusterRoles[ref.Name]; ok {
+			return f.clusterRoleRules(role)
+		}
+	}
+	return nil
+}
+
+// clusterRoleRules returns the rules of the ClusterRole, including the rules of the ClusterRoles selected by
+// its aggregation rule like the aggregation controller does.
+func (f *RBACFake) clusterRoleRules(role *rbacv1.ClusterRole) []rbacv1.PolicyRule {
+	if role.AggregationRule == nil {
+		return role.Rules
+	}
+
+	rules := []rbacv1.PolicyRule{}
+	for _, selector := range role.AggregationRule.ClusterRoleSelectors {
+		s, err := metav1.LabelSelectorAsSelector(&selector)
+		if err != nil {
+			continue
+		}
+		for _, other := range sortedClusterRoles(f.clusterRoles) {
+			if other.Name != role.Name && s.Matches(labelSet(other.Labels)) {
+				rules = append(rules, other.Rules...)
+			}
+		}
+	}
+	return rules
+}
+
+// the sorted helpers order the RBAC objects by key so evaluation and Rules are deterministic
+
+func sortedClusterRoles(m map[string]*rbacv1.ClusterRole) []*rbacv1.ClusterRole {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	objs := make([]*rbacv1.ClusterRole, len(keys))
+	for i, key := range keys {
+		objs[i] = m[key]
+	}
+	return objs
+}
+
+func sortedRoleBindings(m map[string]*rbacv1.RoleBinding) []*rbacv1.RoleBinding {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	objs := make([]*rbacv1.RoleBinding, len(keys))
+	for i, key := range keys {
+		objs[i] = m[key]
+	}
+	return objs
+}
+
+func sortedClusterRoleBindings(m map[string]*rbacv1.ClusterRoleBinding) []*rbacv1.ClusterRoleBinding {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	objs := make([]*rbacv1.ClusterRoleBinding, len(keys))
+	for i, key := range keys {
+		objs[i] = m[key]
+	}
+	return objs
+}
+
+type labelSet map[string]string
+
+func (l labelSet) Has(label string) bool {
+	_, ok := l[label]
+	return ok
+}
+
+func (l labelSet) Get(label string) string {
+	return l[label]
+}
+
+func resourceRuleAllows(rule rbacv1.PolicyRule, attrs *v1.ResourceAttributes) bool {
+	combined := attrs.Resource
+	if attrs.Subresource != "" {
+		combined = attrs.Resource + "/" + attrs.Subresource
+	}
+	return matchesRule(rule.Verbs, attrs.Verb) &&
+		matchesRule(rule.APIGroups, attrs.Group) &&
+		resourceMatches(rule.Resources, combined, attrs.Subresource) &&
+		resourceNameMatches(rule.ResourceNames, attrs.Name)
+}
+
+func nonResourceRuleAllows(rule rbacv1.PolicyRule, attrs *v1.NonResourceAttributes) bool {
+	if !matchesRule(rule.Verbs, attrs.Verb) {
+		return false
+	}
+	for _, url := range rule.NonResourceURLs {
+		if url == rbacv1.NonResourceAll || url == attrs.Path {
+			return true
+		}
+		if strings.HasSuffix(url, "*") && strings.HasPrefix(attrs.Path, strings.TrimSuffix(url, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRule(values []string, value string) bool {
+	for _, v := range values {
+		if v == rbacv1.VerbAll || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceMatches(resources []string, combined, subresource string) bool {
+	for _, r := range resources {
+		if r == rbacv1.ResourceAll || r == combined {
+			return true
+		}
+		if subresource != "" && r == "*/"+subresource {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceNameMatches(names []string, name string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+var _ authv1.SelfSubjectRulesReviewInterface = (*rbacRulesReviewFake)(nil)
+
+type rbacRulesReviewFake struct {
+	rbac *RBACFake
+}
+
+func (r *rbacRulesReviewFake) Create(ctx context.Context, selfSubjectRulesReview *v1.SelfSubjectRulesReview, opts metav1.CreateOptions) (*v1.SelfSubjectRulesReview, error) {
+	result := selfSubjectRulesReview.DeepCopy()
+	result.Status = v1.SubjectRulesReviewStatus{
+		ResourceRules:    []v1.ResourceRule{},
+		NonResourceRules: []v1.NonResourceRule{},
+	}
+	for _, rule := range r.rbac.Rules(result.Spec.Namespace) {
+		if len(rule.NonResourceURLs) > 0 {
+			result.Status.NonResourceRules = append(result.Status.NonResourceRules, v1.NonResourceRule{
+				Verbs:           rule.Verbs,
+				NonResourceURLs: rule.NonResourceURLs,
+			})
+			continue
+		}
+		result.Status.ResourceRules = append(result.Status.ResourceRules, v1.ResourceRule{
+			Verbs:         rule.Verbs,
+			APIGroups:     rule.APIGroups,
+			Resources:     rule.Resources,
+			ResourceNames: rule.ResourceNames,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package resource
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+var (
+	// DefaultAccessConcurrency is the number of SelfSubjectAccessReviews NewResourceAccess will have in flight.
+	DefaultAccessConcurrency = 10
+	// DefaultThrottleRetries is the number of times a request rejected with 429 TooManyRequests is retried.
+	DefaultThrottleRetries = 5
+)
+
+// Throttle limits the rate of requests sent to the API server with a token bucket and retries
+// requests that are rejected with 429 TooManyRequests, honoring the Retry-After returned by the server.
+// A nil *Throttle performs requests without any limits or retries.
+type Throttle struct {
+	limiter    flowcontrol.RateLimiter
+	maxRetries int
+	backoff    wait.Backoff
+}
+
+// NewThrottle creates a Throttle allowing qps requests per second with bursts of up to burst requests.
+func NewThrottle(qps float32, burst int) *Throttle {
+	return &Throttle{
+		limiter:    flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		maxRetries: DefaultThrottleRetries,
+		backoff: wait.Backoff{
+			Duration: 500 * time.Millisecond,
+			Factor:   2,
+			Jitter:   0.1,
+			Steps:    DefaultThrottleRetries,
+			Cap:      30 * time.Second,
+		},
+	}
+}
+
+// Do waits for the rate limiter and calls fn, retrying when fn returns a TooManyRequests error.
+func (t *Throttle) Do(ctx context.Context, fn func() error) error {
+	if t == nil {
+		return fn()
+	}
+
+	backoff := t.backoff
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil || !apierrors.IsTooManyRequests(err) || attempt >= t.maxRetries {
+			return err
+		}
+
+		delay := backoff.Step()
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+			delay = time.Duration(seconds) * time.Second
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package resource_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	rtesting "github.com/wwitzel3/k8s-resource-client/pkg/resource/testing"
+)
+
+func TestThrottleNil(t *testing.T) {
+	var throttle *resource.Throttle
+	calls := 0
+	err := throttle.Do(context.TODO(), func() error {
+		calls += 1
+		return apierrors.NewTooManyRequests("slow down", 1)
+	})
+	assert.True(t, apierrors.IsTooManyRequests(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestThrottleRetryAfter(t *testing.T) {
+	throttle := resource.NewThrottle(100, 1)
+	calls := 0
+	start := time.Now()
+	err := throttle.Do(context.TODO(), func() error {
+		calls += 1
+		if calls == 1 {
+			return apierrors.NewTooManyRequests("slow down", 1)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestThrottleNoRetry(t *testing.T) {
+	throttle := resource.NewThrottle(100, 1)
+	calls := 0
+	err := throttle.Do(context.TODO(), func() error {
+		calls += 1
+		return fmt.Errorf("not throttled")
+	})
+	assert.EqualError(t, err, "not throttled")
+	assert.Equal(t, 1, calls)
+}
+
+func TestThrottleCanceled(t *testing.T) {
+	throttle := resource.NewThrottle(100, 1)
+	ctx, cancel := context.WithCancel(context.TODO())
+	err := throttle.Do(ctx, func() error {
+		cancel()
+		return apierrors.NewTooManyRequests("slow down", 10)
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNewResourceAccessConcurrency(t *testing.T) {
+	var inFlight, maxInFlight, calls int32
+	authFake := rtesting.SubjectAccessFake{
+		CreateFn: func(*rtesting.SubjectAccessFake) (*v1.SelfSubjectAccessReview, error) {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(5 * time.Millisecond)
+			return &v1.SelfSubjectAccessReview{Status: v1.SubjectAccessReviewStatus{Allowed: true}}, nil
+		},
+	}
+
+	resources := []resource.Resource{}
+	for i := 0; i < 20; i++ {
+		r := deploymentResource
+		r.GroupVersionKind = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: fmt.Sprintf("Kind%d", i)}
+		resources = append(resources, r)
+	}
+
+	ra := resource.NewResourceAccess(context.TODO(), authFake, "default", resources,
+		resource.WithConcurrency(3),
+		resource.WithThrottle(resource.NewThrottle(1000, 10)),
+		resource.WithMinimumRBAC(metav1.Verbs{"list"}),
+	)
+	assert.Equal(t, int32(20), atomic.LoadInt32(&calls))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(3))
+	assert.True(t, ra.Allowed("default", resources[19], "list"))
+}
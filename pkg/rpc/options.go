@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	"github.com/wwitzel3/k8s-resource-client/pkg/stream"
+)
+
+type ServerOption func(*Server)
+
+// WithCluster serves the watches of the named cluster, the default cluster is "".
+func WithCluster(cluster string) ServerOption {
+	return func(s *Server) {
+		s.cluster = cluster
+	}
+}
+
+// WithAccess enforces the access map instead of cache.Access, CheckAccess does not review missing verbs.
+func WithAccess(access resource.ResourceAccess) ServerOption {
+	return func(s *Server) {
+		s.accessFn = func() resource.ResourceAccess { return access }
+	}
+}
+
+// WithAccessReviews makes CheckAccess review the verbs missing from cache.Access with the client of the
+// Server and add them to cache.Access. Only the namespaces in cache.Namespaces are reviewed. It is off by
+// default, so requests do not send SelfSubjectAccessReviews to the cluster.
+func WithAccessReviews() ServerOption {
+	return func(s *Server) {
+		s.accessReviews = true
+	}
+}
+
+// WithResources serves the resources instead of the discovered resources in cache.Resources.
+func WithResources(resources ...resource.Resource) ServerOption {
+	return func(s *Server) {
+		s.resourcesFn = func() []resource.Resource { return resources }
+	}
+}
+
+// WithHub streams the events of the Hub, it must stream the same cluster and is not closed by Close.
+func WithHub(hub *stream.Hub) ServerOption {
+	return func(s *Server) {
+		s.hub = hub
+	}
+}
+
+// WithMaxLimit caps the number of objects returned by ListObjects, requests without a limit or a larger
+// limit get pages of at most n objects. The default 0 does not cap the page size.
+func WithMaxLimit(n int) ServerOption {
+	return func(s *Server) {
+		s.maxLimit = n
+	}
+}
+
+func WithLogger(logger *zap.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
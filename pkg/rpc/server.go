@@ -0,0 +1,384 @@
+// Package rpc serves the resourceclient.v1 gRPC API from the discovered resources, the access map and the
+// watches of a client, so one process holds the informers and services in any language share them.
+//
+// Object requests are checked against the access map like the server package does: objects are served from
+// the list cache so list access is required, and listing a namespaced resource across all namespaces only
+// returns the objects of the namespaces the access map allows. Watch requires watch access in the namespace
+// of the request and streams the events of a stream.Hub.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	resourceclientv1 "github.com/wwitzel3/k8s-resource-client/api/resourceclient/v1"
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	"github.com/wwitzel3/k8s-resource-client/pkg/stream"
+)
+
+// Server implements resourceclientv1.ResourceClientServer, create it with NewServer and register it with
+// resourceclientv1.RegisterResourceClientServer.
+type Server struct {
+	resourceclientv1.UnimplementedResourceClientServer
+
+	client      *client.Client
+	hub         *stream.Hub
+	ownHub      bool
+	cluster     string
+	maxLimit    int
+	logger      *zap.Logger
+	accessFn    func() resource.ResourceAccess
+	resourcesFn func() []resource.Resource
+	// accessReviews reviews the verbs missing from cache.Access with the client
+	accessReviews bool
+}
+
+var _ resourceclientv1.ResourceClientServer = (*Server)(nil)
+
+// NewServer creates a Server serving cache.Resources, cache.Access and the watches of the default cluster.
+// With WithAccessReviews, CheckAccess reviews the verbs missing from cache.Access with the client. Close the
+// Server to stop streaming watch events.
+func NewServer(c *client.Client, options ...ServerOption) *Server {
+	s := &Server{
+		client:      c,
+		logger:      zap.NewNop(),
+		accessFn:    func() resource.ResourceAccess { return cache.Access },
+		resourcesFn: cache.DiscoveredResources,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	if s.hub == nil {
+		s.hub = stream.NewHub(stream.WithCluster(s.cluster), stream.WithLogger(s.logger))
+		s.ownHub = true
+	}
+	return s
+}
+
+// Close closes the stream.Hub created by NewServer, running Watch calls return once their buffered events
+// are sent.
+func (s *Server) Close() {
+	if s.ownHub {
+		s.hub.Close()
+	}
+}
+
+func (s *Server) ListResources(_ context.Context, req *resourceclientv1.ListResourcesRequest) (*resourceclientv1.ListResourcesResponse, error) {
+	resp := &resourceclientv1.ListResourcesResponse{}
+	for _, res := range s.resourcesFn() {
+		switch {
+		case req.GetScope() == resourceclientv1.Scope_SCOPE_NAMESPACE && !res.APIResource.Namespaced:
+			continue
+		case req.GetScope() == resourceclientv1.Scope_SCOPE_CLUSTER && res.APIResource.Namespaced:
+			continue
+		}
+		resp.Resources = append(resp.Resources, &resourceclientv1.Resource{
+			Group:      res.GroupVersionKind.Group,
+			Version:    res.GroupVersionKind.Version,
+			Kind:       res.GroupVersionKind.Kind,
+			Name:       res.APIResource.Name,
+			Namespaced: res.APIResource.Namespaced,
+			Verbs:      res.APIResource.Verbs,
+		})
+	}
+	sort.Slice(resp.Resources, func(i, j int) bool {
+		a, b := resp.Resources[i], resp.Resources[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Name < b.Name
+	})
+	return resp, nil
+}
+
+func (s *Server) CheckAccess(ctx context.Context, req *resourceclientv1.CheckAccessRequest) (*resourceclientv1.CheckAccessResponse, error) {
+	res, err := s.resource(req.GetResource())
+	if err != nil {
+		return nil, err
+	}
+	access := s.accessFn()
+	if access == nil {
+		return nil, status.Error(codes.Unavailable, "access has not been discovered")
+	}
+
+	statuses := s.statuses(access, res, req.GetNamespace(), req.GetVerbs())
+	missing := []string{}
+	for _, verb := range req.GetVerbs() {
+		if _, ok := statuses[verb]; !ok {
+			missing = append(missing, verb)
+		}
+	}
+	if len(missing) > 0 && s.accessReviews && s.client != nil && access == cache.Access {
+		if !discoveredNamespace(res, req.GetNamespace()) {
+			return nil, status.Errorf(codes.InvalidArgument, "namespace %q has not been discovered for %s", req.GetNamespace(), res.Key())
+		}
+		if err := client.UpdateResourceVerbAccess(ctx, s.client, res, []string{req.GetNamespace()}, missing); err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		statuses = s.statuses(access, res, req.GetNamespace(), req.GetVerbs())
+	}
+
+	resp := &resourceclientv1.CheckAccessResponse{Verbs: map[string]resourceclientv1.AccessStatus{}}
+	for _, verb := range req.GetVerbs() {
+		resp.Verbs[verb] = accessStatus(statuses, verb)
+	}
+	return resp, nil
+}
+
+// discoveredNamespace returns true when the namespace is in cache.Namespaces for a namespaced resource, or
+// NamespaceAll for a cluster resource.
+func discoveredNamespace(res resource.Resource, namespace string) bool {
+	if !res.APIResource.Namespaced {
+		return namespace == metav1.NamespaceAll
+	}
+	for _, ns := range cache.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// statuses returns the access map statuses of the verbs of the resource in the namespace, verbs that
+// have not been reviewed are left out.
+func (s *Server) statuses(access resource.ResourceAccess, res resource.Resource, namespace string, verbs []string) map[string]int {
+	statuses := map[string]int{}
+	for _, verb := range verbs {
+		if status, ok := access.Status(namespace, res, verb); ok {
+			statuses[verb] = status
+		}
+	}
+	return statuses
+}
+
+func accessStatus(statuses map[string]int, verb string) resourceclientv1.AccessStatus {
+	v, ok := statuses[verb]
+	if !ok {
+		return resourceclientv1.AccessStatus_ACCESS_STATUS_UNSPECIFIED
+	}
+	switch v {
+	case resource.Denied:
+		return resourceclientv1.AccessStatus_ACCESS_STATUS_DENIED
+	case resource.Allowed:
+		return resourceclientv1.AccessStatus_ACCESS_STATUS_ALLOWED
+	case resource.Unused:
+		return resourceclientv1.AccessStatus_ACCESS_STATUS_UNUSED
+	default:
+		return resourceclientv1.AccessStatus_ACCESS_STATUS_ERROR
+	}
+}
+
+func (s *Server) ListObjects(_ context.Context, req *resourceclientv1.ListObjectsRequest) (*resourceclientv1.ListObjectsResponse, error) {
+	res, err := s.resource(req.GetResource())
+	if err != nil {
+		return nil, err
+	}
+	if !res.APIResource.Namespaced && req.GetNamespace() != "" {
+		return nil, status.Errorf(codes.NotFound, "%s is not namespaced", res.Key())
+	}
+	lister, err := s.lister(res, req.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := s.listOptions(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result, err := query.List(lister, opts)
+	if result == nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		s.logger.Warn("serving partial list", zap.String("resource", res.Key()), zap.Error(err))
+	}
+
+	resp := &resourceclientv1.ListObjectsResponse{Continue: result.Continue, RemainingItemCount: int64(result.Remaining)}
+	for _, obj := range result.Items {
+		o, err := toObject(obj)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		resp.Items = append(resp.Items, o)
+	}
+	return resp, nil
+}
+
+func (s *Server) listOptions(req *resourceclientv1.ListObjectsRequest) (query.ListOptions, error) {
+	opts := query.ListOptions{
+		SortBy:     req.GetSortBy(),
+		Descending: req.GetDescending(),
+		Limit:      int(req.GetLimit()),
+		Continue:   req.GetContinue(),
+	}
+	if req.GetLabelSelector() != "" {
+		selector, err := labels.Parse(req.GetLabelSelector())
+		if err != nil {
+			return opts, err
+		}
+		opts.Selector = selector
+	}
+	if req.GetQuery() != "" {
+		q, err := query.Compile(req.GetQuery())
+		if err != nil {
+			return opts, err
+		}
+		opts.Query = q
+	}
+	if opts.Limit < 0 {
+		opts.Limit = 0
+	}
+	if s.maxLimit > 0 && (opts.Limit == 0 || opts.Limit > s.maxLimit) {
+		opts.Limit = s.maxLimit
+	}
+	return opts, nil
+}
+
+func (s *Server) GetObject(_ context.Context, req *resourceclientv1.GetObjectRequest) (*resourceclientv1.Object, error) {
+	res, err := s.resource(req.GetResource())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetName() == "" || res.APIResource.Namespaced == (req.GetNamespace() == "") {
+		return nil, status.Errorf(codes.NotFound, "%s %q not found", res.Key(), req.GetName())
+	}
+	lister, err := s.lister(res, req.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := lister.GetNamespaced(req.GetNamespace(), req.GetName())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "%s %q not found", res.Key(), req.GetName())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	o, err := toObject(obj)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return o, nil
+}
+
+func (s *Server) Watch(req *resourceclientv1.WatchRequest, srv resourceclientv1.ResourceClient_WatchServer) error {
+	access := s.accessFn()
+	if access == nil {
+		return status.Error(codes.Unavailable, "access has not been discovered")
+	}
+
+	sub := stream.Subscription{Namespace: req.GetNamespace()}
+	for _, gvr := range req.GetResources() {
+		res, err := s.resource(gvr)
+		if err != nil {
+			return err
+		}
+		if !access.Allowed(req.GetNamespace(), res, "watch") {
+			return status.Errorf(codes.PermissionDenied, "watch %s is not allowed by the access map", res.Key())
+		}
+		sub.Resources = append(sub.Resources, res.GroupVersionResource())
+	}
+	if req.GetLabelSelector() != "" {
+		selector, err := labels.Parse(req.GetLabelSelector())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		sub.Selector = selector
+	}
+
+	var st *stream.Stream
+	var err error
+	if req.Cursor != nil {
+		st, err = s.hub.SubscribeFrom(sub, req.GetCursor())
+	} else {
+		st, err = s.hub.Subscribe(sub)
+	}
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer st.Close()
+
+	for {
+		e, err := st.Next(srv.Context())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		event := &resourceclientv1.WatchEvent{Type: e.Type, Cursor: e.Cursor, Key: e.Key}
+		if e.Object != nil {
+			if event.Object, err = toObject(e.Object); err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+		}
+		if err := srv.Send(event); err != nil {
+			return err
+		}
+	}
+}
+
+// resource returns the served resource of the GroupVersionResource.
+func (s *Server) resource(gvr *resourceclientv1.GroupVersionResource) (resource.Resource, error) {
+	want := schema.GroupVersionResource{Group: gvr.GetGroup(), Version: gvr.GetVersion(), Resource: gvr.GetResource()}
+	for _, res := range s.resourcesFn() {
+		if res.GroupVersionResource() == want {
+			return res, nil
+		}
+	}
+	return resource.Resource{}, status.Errorf(codes.NotFound, "resource %s not found", want)
+}
+
+// lister returns the ResourceLister of the namespaces of the request the access map allows listing in.
+func (s *Server) lister(res resource.Resource, namespace string) (cache.ResourceLister, error) {
+	access := s.accessFn()
+	if access == nil {
+		return nil, status.Error(codes.Unavailable, "access has not been discovered")
+	}
+	namespaces := resource.AllowedNamespaces(access, res, namespace, "list")
+	if len(namespaces) == 0 {
+		return nil, status.Errorf(codes.PermissionDenied, "list %s is not allowed by the access map", res.Key())
+	}
+
+	lister, err := cache.WatchForClusterResource(s.cluster, res, namespaces...)
+	if err != nil {
+		s.logger.Debug("no watch for request", zap.String("resource", res.Key()), zap.Error(err))
+		return nil, status.Errorf(codes.Unavailable, "%s is not watched", res.Key())
+	}
+	return lister, nil
+}
+
+func toObject(obj runtime.Object) (*resourceclientv1.Object, error) {
+	u, err := cache.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return nil, err
+	}
+	return &resourceclientv1.Object{
+		Namespace:       u.GetNamespace(),
+		Name:            u.GetName(),
+		ResourceVersion: u.GetResourceVersion(),
+		Json:            data,
+	}, nil
+}
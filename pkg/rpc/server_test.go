@@ -0,0 +1,234 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	resourceclientv1 "github.com/wwitzel3/k8s-resource-client/api/resourceclient/v1"
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	ctesting "github.com/wwitzel3/k8s-resource-client/pkg/client/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	"github.com/wwitzel3/k8s-resource-client/pkg/rpc"
+)
+
+var (
+	podResource  = ctesting.NewClusterResource("", "v1", "Pod", "pods", true)
+	nodeResource = ctesting.NewClusterResource("", "v1", "Node", "nodes", false)
+	podsGVR      = &resourceclientv1.GroupVersionResource{Version: "v1", Resource: "pods"}
+)
+
+// serve serves the Server over an in-memory connection and returns a client of it.
+func serve(t *testing.T, s *rpc.Server) resourceclientv1.ResourceClientClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	resourceclientv1.RegisterResourceClientServer(server, s)
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.DialContext(context.TODO(), "bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		s.Close()
+		server.Stop()
+	})
+	return resourceclientv1.NewResourceClientClient(conn)
+}
+
+func newServer(t *testing.T, options ...rpc.ServerOption) (resourceclientv1.ResourceClientClient, *wtesting.FakeIndexerInformer) {
+	cache.ResourceWatches = &sync.Map{}
+	informer := wtesting.WatchPods(t, metav1.NamespaceAll, wtesting.NewPod("default", "nginx"), wtesting.NewPod("default", "redis"), wtesting.NewPod("test", "nginx"), wtesting.NewPod("kube-system", "coredns"))
+	access := resource.NewResourceAccessFromEntries([]resource.AccessEntry{
+		{Namespace: "default", Resource: podResource.Key(), Verb: "list", Status: resource.Allowed},
+		{Namespace: "default", Resource: podResource.Key(), Verb: "watch", Status: resource.Allowed},
+		{Namespace: "test", Resource: podResource.Key(), Verb: "list", Status: resource.Allowed},
+		{Namespace: "kube-system", Resource: podResource.Key(), Verb: "list", Status: resource.Denied},
+	})
+	options = append([]rpc.ServerOption{rpc.WithAccess(access), rpc.WithResources(podResource, nodeResource)}, options...)
+	return serve(t, rpc.NewServer(nil, options...)), informer
+}
+
+func names(items []*resourceclientv1.Object) []string {
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Namespace+"/"+item.Name)
+	}
+	return names
+}
+
+func TestServerResources(t *testing.T) {
+	c, _ := newServer(t)
+
+	resp, err := c.ListResources(context.TODO(), &resourceclientv1.ListResourcesRequest{})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Resources, 2)
+	assert.Equal(t, "nodes", resp.Resources[0].Name)
+
+	resp, err = c.ListResources(context.TODO(), &resourceclientv1.ListResourcesRequest{Scope: resourceclientv1.Scope_SCOPE_NAMESPACE})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Resources, 1)
+	assert.Equal(t, "Pod", resp.Resources[0].Kind)
+	assert.True(t, resp.Resources[0].Namespaced)
+
+	access, err := c.CheckAccess(context.TODO(), &resourceclientv1.CheckAccessRequest{Resource: podsGVR, Namespace: "kube-system", Verbs: []string{"list", "get"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]resourceclientv1.AccessStatus{
+		"list": resourceclientv1.AccessStatus_ACCESS_STATUS_DENIED,
+		"get":  resourceclientv1.AccessStatus_ACCESS_STATUS_UNSPECIFIED,
+	}, access.Verbs)
+}
+
+func TestServerCheckAccessReview(t *testing.T) {
+	defer func() {
+		cache.Access = nil
+		cache.Namespaces = []string{}
+	}()
+
+	cluster := ctesting.NewFakeCluster()
+	cluster.Allow(ctesting.AccessRule{Namespaces: []string{"default"}, APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list", "get"}})
+	c, err := cluster.NewClient(context.TODO(), client.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+	cache.Access = resource.NewResourceAccessFromEntries([]resource.AccessEntry{
+		{Namespace: "default", Resource: podResource.Key(), Verb: "list", Status: resource.Allowed},
+	})
+	cache.Namespaces = []string{"default"}
+	req := &resourceclientv1.CheckAccessRequest{Resource: podsGVR, Namespace: "default", Verbs: []string{"list", "get", "delete", "proxy"}}
+
+	// the missing verbs are not reviewed by default
+	rc := serve(t, rpc.NewServer(c, rpc.WithResources(podResource)))
+	resp, err := rc.CheckAccess(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, resourceclientv1.AccessStatus_ACCESS_STATUS_UNSPECIFIED, resp.Verbs["get"])
+	assert.Empty(t, cluster.AccessReviews())
+
+	rc = serve(t, rpc.NewServer(c, rpc.WithResources(podResource), rpc.WithAccessReviews()))
+	resp, err = rc.CheckAccess(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]resourceclientv1.AccessStatus{
+		"list":   resourceclientv1.AccessStatus_ACCESS_STATUS_ALLOWED,
+		"get":    resourceclientv1.AccessStatus_ACCESS_STATUS_ALLOWED,
+		"delete": resourceclientv1.AccessStatus_ACCESS_STATUS_DENIED,
+		"proxy":  resourceclientv1.AccessStatus_ACCESS_STATUS_UNUSED,
+	}, resp.Verbs)
+	// only the missing verbs are reviewed
+	assert.Len(t, cluster.AccessReviews(), 2)
+
+	// namespaces that have not been discovered are not reviewed
+	_, err = rc.CheckAccess(context.TODO(), &resourceclientv1.CheckAccessRequest{Resource: podsGVR, Namespace: "other", Verbs: []string{"get"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = rc.CheckAccess(context.TODO(), &resourceclientv1.CheckAccessRequest{Resource: podsGVR, Verbs: []string{"get"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Len(t, cluster.AccessReviews(), 2)
+}
+
+func TestServerObjects(t *testing.T) {
+	c, _ := newServer(t)
+
+	list, err := c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{Resource: podsGVR, Namespace: "default"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default/nginx", "default/redis"}, names(list.Items))
+
+	// across namespaces only the allowed namespaces are returned
+	list, err = c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{Resource: podsGVR, SortBy: "namespace", Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default/nginx", "default/redis"}, names(list.Items))
+	assert.Equal(t, int64(1), list.RemainingItemCount)
+	list, err = c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{Resource: podsGVR, SortBy: "namespace", Limit: 2, Continue: list.Continue})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test/nginx"}, names(list.Items))
+	assert.Empty(t, list.Continue)
+
+	list, err = c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{Resource: podsGVR, Query: "metadata.name = redis"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default/redis"}, names(list.Items))
+
+	obj, err := c.GetObject(context.TODO(), &resourceclientv1.GetObjectRequest{Resource: podsGVR, Namespace: "test", Name: "nginx"})
+	assert.Nil(t, err)
+	u := &unstructured.Unstructured{}
+	assert.Nil(t, json.Unmarshal(obj.Json, &u.Object))
+	assert.Equal(t, "Pod", u.GetKind())
+	assert.Equal(t, "test", u.GetNamespace())
+}
+
+func TestServerErrors(t *testing.T) {
+	c, _ := newServer(t)
+
+	code := func(_ interface{}, err error) codes.Code {
+		return status.Code(err)
+	}
+
+	assert.Equal(t, codes.PermissionDenied, code(c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{Resource: podsGVR, Namespace: "kube-system"})))
+	assert.Equal(t, codes.PermissionDenied, code(c.GetObject(context.TODO(), &resourceclientv1.GetObjectRequest{Resource: podsGVR, Namespace: "kube-system", Name: "coredns"})))
+	assert.Equal(t, codes.NotFound, code(c.GetObject(context.TODO(), &resourceclientv1.GetObjectRequest{Resource: podsGVR, Namespace: "default", Name: "missing"})))
+	assert.Equal(t, codes.NotFound, code(c.GetObject(context.TODO(), &resourceclientv1.GetObjectRequest{Resource: podsGVR, Name: "nginx"})))
+	assert.Equal(t, codes.NotFound, code(c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{
+		Resource: &resourceclientv1.GroupVersionResource{Version: "v1", Resource: "widgets"},
+	})))
+	assert.Equal(t, codes.InvalidArgument, code(c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{Resource: podsGVR, Namespace: "default", LabelSelector: "=="})))
+	assert.Equal(t, codes.InvalidArgument, code(c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{Resource: podsGVR, Namespace: "default", Continue: "invalid"})))
+
+	// nodes are served and allowed but not watched
+	c, _ = newServer(t, rpc.WithAccess(resource.NewResourceAccessFromEntries([]resource.AccessEntry{
+		{Namespace: "", Resource: nodeResource.Key(), Verb: "list", Status: resource.Allowed},
+	})))
+	assert.Equal(t, codes.Unavailable, code(c.ListObjects(context.TODO(), &resourceclientv1.ListObjectsRequest{
+		Resource: &resourceclientv1.GroupVersionResource{Version: "v1", Resource: "nodes"},
+	})))
+}
+
+func TestServerWatch(t *testing.T) {
+	c, informer := newServer(t)
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	w, err := c.Watch(ctx, &resourceclientv1.WatchRequest{Resources: []*resourceclientv1.GroupVersionResource{podsGVR}, Namespace: "test"})
+	assert.Nil(t, err)
+	_, err = w.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	w, err = c.Watch(ctx, &resourceclientv1.WatchRequest{Resources: []*resourceclientv1.GroupVersionResource{podsGVR}, Namespace: "default"})
+	assert.Nil(t, err)
+	events := []string{}
+	var synced uint64
+	for i := 0; i < 3; i++ {
+		e, err := w.Recv()
+		assert.Nil(t, err)
+		events = append(events, e.Type+":"+e.Object.GetName())
+		synced = e.Cursor
+	}
+	assert.Equal(t, []string{"add:nginx", "add:redis", "synced:"}, events)
+
+	assert.Nil(t, informer.Add(wtesting.NewPod("test", "db")))
+	assert.Nil(t, informer.Add(wtesting.NewPod("default", "db")))
+	e, err := w.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "add", e.Type)
+	assert.Equal(t, "db", e.Object.Name)
+	assert.Equal(t, synced+2, e.Cursor)
+
+	// resuming replays the events of the namespace after the cursor
+	w, err = c.Watch(ctx, &resourceclientv1.WatchRequest{Resources: []*resourceclientv1.GroupVersionResource{podsGVR}, Namespace: "default", Cursor: &synced})
+	assert.Nil(t, err)
+	e, err = w.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "db", e.Object.Name)
+	assert.Equal(t, synced+2, e.Cursor)
+}
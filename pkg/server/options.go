@@ -0,0 +1,44 @@
+package server
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+type HandlerOption func(*Handler)
+
+// WithCluster serves the watches of the named cluster, the default cluster is "".
+func WithCluster(cluster string) HandlerOption {
+	return func(h *Handler) {
+		h.cluster = cluster
+	}
+}
+
+// WithAccess enforces the access map instead of cache.Access.
+func WithAccess(access resource.ResourceAccess) HandlerOption {
+	return func(h *Handler) {
+		h.accessFn = func() resource.ResourceAccess { return access }
+	}
+}
+
+// WithResources serves the resources instead of the discovered resources in cache.Resources.
+func WithResources(resources ...resource.Resource) HandlerOption {
+	return func(h *Handler) {
+		h.resourcesFn = func() []resource.Resource { return resources }
+	}
+}
+
+// WithMaxLimit caps the number of objects returned in a page, requests without a limit or a larger limit
+// get pages of at most n objects. The default 0 does not cap the page size.
+func WithMaxLimit(n int) HandlerOption {
+	return func(h *Handler) {
+		h.maxLimit = n
+	}
+}
+
+func WithLogger(logger *zap.Logger) HandlerOption {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
@@ -0,0 +1,381 @@
+// Package server provides a read-only http.Handler serving the discovered resources, the access map and the
+// objects cached by the watches as JSON or YAML. Objects are served from the paths of the Kubernetes API:
+//
+//	GET /api/v1/{resource}[/{name}]
+//	GET /api/v1/namespaces/{namespace}/{resource}[/{name}]
+//	GET /apis/{group}/{version}/{resource}[/{name}]
+//	GET /apis/{group}/{version}/namespaces/{namespace}/{resource}[/{name}]
+//
+// Collections accept the labelSelector, query, sortBy, descending, limit and continue parameters, see
+// query.ListOptions. Responses are YAML with output=yaml or an Accept header asking for YAML. Errors are
+// returned as a metav1.Status like the API server does.
+//
+// Every object request is checked against the access map, objects are served from the list cache so list
+// access is required. Listing a namespaced resource across all namespaces only returns the objects of the
+// namespaces the access map allows.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/query"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// AccessPath serves the entries of the access map.
+const AccessPath = "/access"
+
+// Handler serves the cache, create it with NewHandler.
+type Handler struct {
+	cluster     string
+	maxLimit    int
+	logger      *zap.Logger
+	accessFn    func() resource.ResourceAccess
+	resourcesFn func() []resource.Resource
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a Handler serving cache.Resources and the watches of the default cluster, enforcing
+// cache.Access. Both are read on every request so later discovery is picked up.
+func NewHandler(options ...HandlerOption) *Handler {
+	h := &Handler{
+		logger:      zap.NewNop(),
+		accessFn:    func() resource.ResourceAccess { return cache.Access },
+		resourcesFn: cache.DiscoveredResources,
+	}
+	for _, opt := range options {
+		opt(h)
+	}
+	return h
+}
+
+// objectRequest is a parsed object path.
+type objectRequest struct {
+	gv        schema.GroupVersion
+	namespace string
+	resource  string
+	name      string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.writeError(w, r, apierrors.NewMethodNotSupported(schema.GroupResource{}, r.Method))
+		return
+	}
+
+	segments := []string{}
+	for _, s := range strings.Split(r.URL.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	if len(segments) == 0 {
+		h.writeError(w, r, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+
+	var gv schema.GroupVersion
+	var rest []string
+	switch {
+	case r.URL.Path == AccessPath:
+		h.serveAccess(w, r)
+		return
+	case len(segments) == 1 && segments[0] == "api":
+		h.write(w, r, &metav1.APIVersions{TypeMeta: metav1.TypeMeta{Kind: "APIVersions"}, Versions: []string{"v1"}})
+		return
+	case len(segments) == 1 && segments[0] == "apis":
+		h.write(w, r, h.groups())
+		return
+	case len(segments) == 2 && segments[0] == "apis":
+		h.serveGroup(w, r, segments[1])
+		return
+	case segments[0] == "api":
+		gv, rest = schema.GroupVersion{Version: segments[1]}, segments[2:]
+	case segments[0] == "apis":
+		gv, rest = schema.GroupVersion{Group: segments[1], Version: segments[2]}, segments[3:]
+	default:
+		h.writeError(w, r, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+
+	if len(rest) == 0 {
+		h.serveResources(w, r, gv)
+		return
+	}
+
+	req := objectRequest{gv: gv}
+	switch {
+	case len(rest) == 1:
+		req.resource = rest[0]
+	case len(rest) == 2:
+		req.resource, req.name = rest[0], rest[1]
+	case len(rest) == 3 && rest[0] == "namespaces":
+		req.namespace, req.resource = rest[1], rest[2]
+	case len(rest) == 4 && rest[0] == "namespaces":
+		req.namespace, req.resource, req.name = rest[1], rest[2], rest[3]
+	default:
+		h.writeError(w, r, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+	h.serveObjects(w, r, req)
+}
+
+func (h *Handler) serveAccess(w http.ResponseWriter, r *http.Request) {
+	entries := []resource.AccessEntry{}
+	if access := h.accessFn(); access != nil {
+		entries = access.Entries()
+	}
+	h.write(w, r, map[string]interface{}{"items": entries})
+}
+
+// groups returns the named API groups of the served resources.
+func (h *Handler) groups() *metav1.APIGroupList {
+	list := &metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}, Groups: []metav1.APIGroup{}}
+	index := map[string]int{}
+	seen := map[schema.GroupVersion]bool{}
+	for _, res := range h.resourcesFn() {
+		gv := res.GroupVersionKind.GroupVersion()
+		if gv.Group == "" || seen[gv] {
+			continue
+		}
+		seen[gv] = true
+
+		version := metav1.GroupVersionForDiscovery{GroupVersion: gv.String(), Version: gv.Version}
+		i, ok := index[gv.Group]
+		if !ok {
+			index[gv.Group] = len(list.Groups)
+			list.Groups = append(list.Groups, metav1.APIGroup{Name: gv.Group, PreferredVersion: version})
+			i = index[gv.Group]
+		}
+		list.Groups[i].Versions = append(list.Groups[i].Versions, version)
+	}
+	sort.Slice(list.Groups, func(i, j int) bool { return list.Groups[i].Name < list.Groups[j].Name })
+	return list
+}
+
+func (h *Handler) serveGroup(w http.ResponseWriter, r *http.Request, name string) {
+	for _, group := range h.groups().Groups {
+		if group.Name == name {
+			group.TypeMeta = metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"}
+			h.write(w, r, &group)
+			return
+		}
+	}
+	h.writeError(w, r, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+}
+
+func (h *Handler) serveResources(w http.ResponseWriter, r *http.Request, gv schema.GroupVersion) {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: gv.String(),
+		APIResources: []metav1.APIResource{},
+	}
+	for _, res := range h.resourcesFn() {
+		if res.GroupVersionKind.GroupVersion() == gv {
+			list.APIResources = append(list.APIResources, res.APIResource)
+		}
+	}
+	if len(list.APIResources) == 0 {
+		h.writeError(w, r, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+	sort.Slice(list.APIResources, func(i, j int) bool { return list.APIResources[i].Name < list.APIResources[j].Name })
+	h.write(w, r, list)
+}
+
+func (h *Handler) serveObjects(w http.ResponseWriter, r *http.Request, req objectRequest) {
+	gr := schema.GroupResource{Group: req.gv.Group, Resource: req.resource}
+	res, ok := h.resource(req.gv.WithResource(req.resource))
+	if !ok || (!res.APIResource.Namespaced && req.namespace != "") || (res.APIResource.Namespaced && req.name != "" && req.namespace == "") {
+		h.writeError(w, r, apierrors.NewNotFound(gr, req.name))
+		return
+	}
+
+	namespaces, err := h.allowedNamespaces(res, req.namespace)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	lister, err := cache.WatchForClusterResource(h.cluster, res, namespaces...)
+	if err != nil {
+		h.logger.Debug("no watch for request", zap.String("resource", res.Key()), zap.Error(err))
+		h.writeError(w, r, apierrors.NewServiceUnavailable(fmt.Sprintf("%s is not watched", gr)))
+		return
+	}
+
+	if req.name != "" {
+		obj, err := lister.GetNamespaced(req.namespace, req.name)
+		if err != nil {
+			if _, ok := err.(apierrors.APIStatus); !ok {
+				err = apierrors.NewNotFound(gr, req.name)
+			}
+			h.writeError(w, r, err)
+			return
+		}
+		u, err := cache.ToUnstructured(obj)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		h.write(w, r, u)
+		return
+	}
+
+	opts, err := h.listOptions(r)
+	if err != nil {
+		h.writeError(w, r, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+	result, err := query.List(lister, opts)
+	if result == nil {
+		h.writeError(w, r, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Warn("serving partial list", zap.String("resource", res.Key()), zap.Error(err))
+	}
+
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetAPIVersion(req.gv.String())
+	list.SetKind(res.GroupVersionKind.Kind + "List")
+	list.SetContinue(result.Continue)
+	if result.Remaining > 0 {
+		remaining := int64(result.Remaining)
+		list.SetRemainingItemCount(&remaining)
+	}
+	for _, obj := range result.Items {
+		u, err := cache.ToUnstructured(obj)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		list.Items = append(list.Items, *u)
+	}
+	h.write(w, r, list)
+}
+
+func (h *Handler) resource(gvr schema.GroupVersionResource) (resource.Resource, bool) {
+	for _, res := range h.resourcesFn() {
+		if res.GroupVersionResource() == gvr {
+			return res, true
+		}
+	}
+	return resource.Resource{}, false
+}
+
+// allowedNamespaces returns the namespaces of the request the access map allows listing the resource in.
+func (h *Handler) allowedNamespaces(res resource.Resource, namespace string) ([]string, error) {
+	gr := res.GroupVersionResource().GroupResource()
+	access := h.accessFn()
+	if access == nil {
+		return nil, apierrors.NewForbidden(gr, "", fmt.Errorf("access has not been discovered"))
+	}
+	if namespaces := resource.AllowedNamespaces(access, res, namespace, "list"); len(namespaces) > 0 {
+		return namespaces, nil
+	}
+	if namespace == "" {
+		return nil, apierrors.NewForbidden(gr, "", fmt.Errorf("list is not allowed by the access map"))
+	}
+	return nil, apierrors.NewForbidden(gr, "", fmt.Errorf("list is not allowed by the access map in namespace %s", namespace))
+}
+
+func (h *Handler) listOptions(r *http.Request) (query.ListOptions, error) {
+	params := r.URL.Query()
+	opts := query.ListOptions{
+		SortBy:   params.Get("sortBy"),
+		Continue: params.Get("continue"),
+	}
+
+	if s := params.Get("labelSelector"); s != "" {
+		selector, err := labels.Parse(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Selector = selector
+	}
+	if s := params.Get("query"); s != "" {
+		q, err := query.Compile(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Query = q
+	}
+	if s := params.Get("descending"); s != "" {
+		descending, err := strconv.ParseBool(s)
+		if err != nil {
+			return opts, fmt.Errorf("invalid descending %q", s)
+		}
+		opts.Descending = descending
+	}
+	if s := params.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return opts, fmt.Errorf("invalid limit %q", s)
+		}
+		opts.Limit = limit
+	}
+	if h.maxLimit > 0 && (opts.Limit == 0 || opts.Limit > h.maxLimit) {
+		opts.Limit = h.maxLimit
+	}
+	return opts, nil
+}
+
+func wantsYAML(r *http.Request) bool {
+	if output := r.URL.Query().Get("output"); output != "" {
+		return output == "yaml"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "yaml")
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, v interface{}) {
+	h.writeStatus(w, r, http.StatusOK, v)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	statusErr, ok := err.(apierrors.APIStatus)
+	if !ok {
+		statusErr = apierrors.NewInternalError(err)
+	}
+	status := statusErr.Status()
+	status.TypeMeta = metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}
+	h.writeStatus(w, r, int(status.Code), &status)
+}
+
+func (h *Handler) writeStatus(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+	data, err := json.Marshal(v)
+	contentType := "application/json"
+	if err == nil && wantsYAML(r) {
+		data, err = yaml.JSONToYAML(data)
+		contentType = "application/yaml"
+	}
+	if err != nil {
+		h.logger.Error("encoding response", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(data); err != nil {
+		h.logger.Debug("writing response", zap.Error(err))
+	}
+}
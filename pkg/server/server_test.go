@@ -0,0 +1,214 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	"github.com/wwitzel3/k8s-resource-client/pkg/server"
+)
+
+var (
+	podResource = resource.Resource{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		APIResource:      metav1.APIResource{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: metav1.Verbs{"list", "watch"}},
+	}
+	namespaceResource = resource.Resource{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		APIResource:      metav1.APIResource{Name: "namespaces", Kind: "Namespace", Verbs: metav1.Verbs{"list", "watch"}},
+	}
+	deploymentResource = resource.Resource{
+		GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		APIResource:      metav1.APIResource{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: metav1.Verbs{"list", "watch"}},
+	}
+)
+
+func newObject(apiVersion, kind, namespace, name string, labels map[string]string) runtime.Object {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(labels)
+	return u
+}
+
+func newHandler(t *testing.T) *server.Handler {
+	cache.ResourceWatches = &sync.Map{}
+
+	_, err := cache.LoadWatch(cache.WatchKey{GroupVersionResource: podResource.GroupVersionResource()}, podResource, []runtime.Object{
+		newObject("v1", "Pod", "default", "nginx", map[string]string{"app": "nginx"}),
+		newObject("v1", "Pod", "default", "redis", map[string]string{"app": "redis"}),
+		newObject("v1", "Pod", "test", "nginx", map[string]string{"app": "nginx"}),
+		newObject("v1", "Pod", "kube-system", "coredns", nil),
+	})
+	assert.Nil(t, err)
+	_, err = cache.LoadWatch(cache.WatchKey{GroupVersionResource: namespaceResource.GroupVersionResource()}, namespaceResource, []runtime.Object{
+		newObject("v1", "Namespace", "", "default", nil),
+		newObject("v1", "Namespace", "", "test", nil),
+	})
+	assert.Nil(t, err)
+
+	access := resource.NewResourceAccessFromEntries([]resource.AccessEntry{
+		{Namespace: "default", Resource: podResource.Key(), Verb: "list", Status: resource.Allowed},
+		{Namespace: "test", Resource: podResource.Key(), Verb: "list", Status: resource.Allowed},
+		{Namespace: "kube-system", Resource: podResource.Key(), Verb: "list", Status: resource.Denied},
+		{Namespace: "", Resource: namespaceResource.Key(), Verb: "list", Status: resource.Allowed},
+	})
+	return server.NewHandler(
+		server.WithAccess(access),
+		server.WithResources(podResource, namespaceResource, deploymentResource),
+	)
+}
+
+func get(h http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func names(t *testing.T, w *httptest.ResponseRecorder) []string {
+	list := &unstructured.UnstructuredList{}
+	assert.Nil(t, list.UnmarshalJSON(w.Body.Bytes()))
+	names := []string{}
+	for _, item := range list.Items {
+		names = append(names, item.GetNamespace()+"/"+item.GetName())
+	}
+	return names
+}
+
+func TestHandlerObjects(t *testing.T) {
+	h := newHandler(t)
+
+	w := get(h, "/api/v1/namespaces/default/pods")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"default/nginx", "default/redis"}, names(t, w))
+
+	// across namespaces only the allowed namespaces are returned
+	w = get(h, "/api/v1/pods?sortBy=namespace")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"default/nginx", "default/redis", "test/nginx"}, names(t, w))
+
+	w = get(h, "/api/v1/pods?labelSelector=app%3Dnginx")
+	assert.Equal(t, []string{"default/nginx", "test/nginx"}, names(t, w))
+	w = get(h, "/api/v1/pods?query=metadata.labels.app+%3D+redis")
+	assert.Equal(t, []string{"default/redis"}, names(t, w))
+
+	w = get(h, "/api/v1/namespaces/default/pods/redis")
+	assert.Equal(t, http.StatusOK, w.Code)
+	obj := &unstructured.Unstructured{}
+	assert.Nil(t, obj.UnmarshalJSON(w.Body.Bytes()))
+	assert.Equal(t, "redis", obj.GetName())
+
+	w = get(h, "/api/v1/namespaces/test")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = get(h, "/api/v1/namespaces/default/pods/nginx?output=yaml")
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "apiVersion: v1\nkind: Pod\n"))
+	w = get(h, "/api/v1/namespaces", "Accept", "application/yaml")
+	content := map[string]interface{}{}
+	assert.Nil(t, yaml.Unmarshal(w.Body.Bytes(), &content))
+	assert.Equal(t, "NamespaceList", content["kind"])
+}
+
+func TestHandlerPagination(t *testing.T) {
+	h := newHandler(t)
+
+	all := []string{}
+	path := "/api/v1/pods?limit=2"
+	for i := 0; i < 3; i++ {
+		w := get(h, path)
+		assert.Equal(t, http.StatusOK, w.Code)
+		all = append(all, names(t, w)...)
+
+		list := &unstructured.UnstructuredList{}
+		assert.Nil(t, list.UnmarshalJSON(w.Body.Bytes()))
+		if list.GetContinue() == "" {
+			break
+		}
+		assert.Equal(t, int64(1), *list.GetRemainingItemCount())
+		path = "/api/v1/pods?limit=2&continue=" + list.GetContinue()
+	}
+	assert.Equal(t, []string{"default/nginx", "test/nginx", "default/redis"}, all)
+
+	h = server.NewHandler(server.WithMaxLimit(1), server.WithAccess(resource.NewResourceAccessFromEntries([]resource.AccessEntry{
+		{Namespace: "default", Resource: podResource.Key(), Verb: "list", Status: resource.Allowed},
+	})), server.WithResources(podResource))
+	assert.Len(t, names(t, get(h, "/api/v1/namespaces/default/pods")), 1)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := newHandler(t)
+
+	status := func(w *httptest.ResponseRecorder) metav1.Status {
+		s := metav1.Status{}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &s))
+		return s
+	}
+
+	w := get(h, "/api/v1/namespaces/kube-system/pods")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, metav1.StatusReasonForbidden, status(w).Reason)
+
+	w = get(h, "/api/v1/namespaces/default/pods/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = get(h, "/api/v1/namespaces/default/widgets")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = get(h, "/api/v1/namespaces/default/namespaces")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = get(h, "/api/v1/namespaces/default/pods?labelSelector=%3D%3D")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = get(h, "/api/v1/namespaces/default/pods?continue=invalid")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// deployments are discovered and allowed but not watched
+	h = server.NewHandler(server.WithResources(deploymentResource), server.WithAccess(resource.NewResourceAccessFromEntries([]resource.AccessEntry{
+		{Namespace: "default", Resource: deploymentResource.Key(), Verb: "list", Status: resource.Allowed},
+	})))
+	w = get(h, "/apis/apps/v1/namespaces/default/deployments")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	r := httptest.NewRequest(http.MethodDelete, "/api/v1/namespaces/default/pods/nginx", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandlerDiscovery(t *testing.T) {
+	h := newHandler(t)
+
+	groups := metav1.APIGroupList{}
+	w := get(h, "/apis")
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &groups))
+	assert.Len(t, groups.Groups, 1)
+	assert.Equal(t, "apps/v1", groups.Groups[0].PreferredVersion.GroupVersion)
+	assert.Equal(t, http.StatusOK, get(h, "/apis/apps").Code)
+
+	resources := metav1.APIResourceList{}
+	w = get(h, "/api/v1")
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resources))
+	assert.Len(t, resources.APIResources, 2)
+	assert.Equal(t, http.StatusNotFound, get(h, "/apis/batch/v1").Code)
+
+	access := map[string][]resource.AccessEntry{}
+	w = get(h, server.AccessPath)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &access))
+	assert.Len(t, access["items"], 4)
+}
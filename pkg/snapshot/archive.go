@@ -0,0 +1,332 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+const (
+	// JSONLinesExt is the file extension Save and Open use for JSON lines archives.
+	JSONLinesExt = ".jsonl"
+
+	snapshotFile = "snapshot.yaml"
+	watchesDir   = "watches"
+)
+
+// Record types of a JSON lines archive.
+const (
+	recordSnapshot = "snapshot"
+	recordResource = "resource"
+	recordAccess   = "access"
+	recordWatch    = "watch"
+	recordObject   = "object"
+)
+
+// record is a single line of a JSON lines archive. Object records belong to the watch with the same Key,
+// which must come first.
+type record struct {
+	Type       string                     `json:"type"`
+	CreatedAt  *time.Time                 `json:"createdAt,omitempty"`
+	Namespaces []string                   `json:"namespaces,omitempty"`
+	Namespaced *bool                      `json:"namespaced,omitempty"`
+	Resource   *resource.Resource         `json:"resource,omitempty"`
+	Access     *resource.AccessEntry      `json:"access,omitempty"`
+	Key        string                     `json:"key,omitempty"`
+	Object     *unstructured.Unstructured `json:"object,omitempty"`
+}
+
+// Save writes the Snapshot to path. A path ending in JSONLinesExt is written as a single JSON lines
+// file, any other path as a directory of YAML files.
+func Save(path string, s *Snapshot) error {
+	if strings.HasSuffix(path, JSONLinesExt) {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := WriteJSONLines(f, s); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return WriteDir(path, s)
+}
+
+// Open reads a Snapshot written by Save, a directory is read with ReadDir and a file with ReadJSONLines.
+func Open(path string) (*Snapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadDir(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := ReadJSONLines(f)
+	if invalid, ok := err.(*errors.InvalidSnapshot); ok {
+		invalid.Location = path + ":" + invalid.Location
+	}
+	return s, err
+}
+
+// WriteJSONLines writes the Snapshot as JSON lines, one record for the snapshot, each resource, access
+// entry, watch and object. Large caches are written without holding the whole archive in memory.
+func WriteJSONLines(w io.Writer, s *Snapshot) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	createdAt := s.CreatedAt
+	if err := enc.Encode(record{Type: recordSnapshot, CreatedAt: &createdAt, Namespaces: s.Namespaces}); err != nil {
+		return err
+	}
+	for _, namespaced := range []bool{true, false} {
+		resources := s.Resources.Cluster
+		if namespaced {
+			resources = s.Resources.Namespaced
+		}
+		for i := range resources {
+			scope := namespaced
+			if err := enc.Encode(record{Type: recordResource, Namespaced: &scope, Resource: &resources[i]}); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range s.Access {
+		if err := enc.Encode(record{Type: recordAccess, Access: &s.Access[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range s.Watches {
+		watch := &s.Watches[i]
+		if err := enc.Encode(record{Type: recordWatch, Key: watch.Key, Resource: &watch.Resource}); err != nil {
+			return err
+		}
+		for _, obj := range watch.Objects {
+			if err := enc.Encode(record{Type: recordObject, Key: watch.Key, Object: obj}); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadJSONLines reads a Snapshot written by WriteJSONLines. An *errors.InvalidSnapshot with the line
+// number is returned for malformed records.
+func ReadJSONLines(r io.Reader) (*Snapshot, error) {
+	s := &Snapshot{}
+	watches := map[string]int{}
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			if rerr := readRecord(s, watches, data); rerr != nil {
+				return nil, &errors.InvalidSnapshot{Location: fmt.Sprint(line), Err: rerr}
+			}
+		}
+		if err == io.EOF {
+			return s, nil
+		}
+	}
+}
+
+func readRecord(s *Snapshot, watches map[string]int, data []byte) error {
+	rec := record{}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+
+	switch rec.Type {
+	case recordSnapshot:
+		if rec.CreatedAt != nil {
+			s.CreatedAt = *rec.CreatedAt
+		}
+		s.Namespaces = rec.Namespaces
+	case recordResource:
+		if rec.Resource == nil || rec.Namespaced == nil {
+			return fmt.Errorf("resource record missing resource or namespaced")
+		}
+		if *rec.Namespaced {
+			s.Resources.Namespaced = append(s.Resources.Namespaced, *rec.Resource)
+		} else {
+			s.Resources.Cluster = append(s.Resources.Cluster, *rec.Resource)
+		}
+	case recordAccess:
+		if rec.Access == nil {
+			return fmt.Errorf("access record missing access")
+		}
+		s.Access = append(s.Access, *rec.Access)
+	case recordWatch:
+		if rec.Resource == nil {
+			return fmt.Errorf("watch record missing resource")
+		}
+		if _, ok := watches[rec.Key]; ok {
+			return fmt.Errorf("duplicate watch %q", rec.Key)
+		}
+		watches[rec.Key] = len(s.Watches)
+		s.Watches = append(s.Watches, Watch{Key: rec.Key, Resource: *rec.Resource, Objects: []*unstructured.Unstructured{}})
+	case recordObject:
+		i, ok := watches[rec.Key]
+		if !ok {
+			return fmt.Errorf("object record for unknown watch %q", rec.Key)
+		}
+		if rec.Object == nil {
+			return fmt.Errorf("object record missing object")
+		}
+		s.Watches[i].Objects = append(s.Watches[i].Objects, rec.Object)
+	default:
+		return fmt.Errorf("unknown record type %q", rec.Type)
+	}
+	return nil
+}
+
+// WriteDir writes the Snapshot as a directory of YAML files, snapshot.yaml holds the discovered resources
+// and access map and each watch is a multi-document file in the watches directory whose first document
+// is the watch and the rest are its objects. The directory must not exist or be empty.
+func WriteDir(dir string, s *Snapshot) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("snapshot directory %s is not empty", dir)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, watchesDir), 0o755); err != nil {
+		return err
+	}
+
+	header := *s
+	header.Watches = nil
+	data, err := yaml.Marshal(header)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotFile), data, 0o644); err != nil {
+		return err
+	}
+
+	for i, watch := range s.Watches {
+		buf := &bytes.Buffer{}
+		docs := []interface{}{Watch{Key: watch.Key, Resource: watch.Resource}}
+		for _, obj := range watch.Objects {
+			docs = append(docs, obj.Object)
+		}
+		for j, doc := range docs {
+			data, err := yaml.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			if j > 0 {
+				buf.WriteString("---\n")
+			}
+			buf.Write(data)
+		}
+
+		name := fmt.Sprintf("%03d-%s.yaml", i, watch.Resource.GroupVersionResource().GroupResource())
+		if err := os.WriteFile(filepath.Join(dir, watchesDir, name), buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadDir reads a Snapshot written by WriteDir. An *errors.InvalidSnapshot with the file name is
+// returned for malformed files.
+func ReadDir(dir string) (*Snapshot, error) {
+	path := filepath.Join(dir, snapshotFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, &errors.InvalidSnapshot{Location: path, Err: err}
+	}
+	s.Watches = nil
+
+	files, err := filepath.Glob(filepath.Join(dir, watchesDir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		watch, err := readWatchFile(file)
+		if err != nil {
+			return nil, &errors.InvalidSnapshot{Location: file, Err: err}
+		}
+		s.Watches = append(s.Watches, *watch)
+	}
+	return s, nil
+}
+
+func readWatchFile(file string) (*Watch, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var watch *Watch
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		if watch == nil {
+			watch = &Watch{}
+			if err := yaml.Unmarshal(doc, watch); err != nil {
+				return nil, err
+			}
+			watch.Objects = []*unstructured.Unstructured{}
+			continue
+		}
+
+		data, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, err
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		watch.Objects = append(watch.Objects, obj)
+	}
+
+	if watch == nil {
+		return nil, fmt.Errorf("missing watch document")
+	}
+	return watch, nil
+}
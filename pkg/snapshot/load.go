@@ -0,0 +1,269 @@
+package snapshot
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	authv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	typedAuthv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// RESTConfig is the placeholder rest.Config of a client created by Load, no requests are sent to its Host.
+var RESTConfig = &rest.Config{Host: "snapshot.invalid", QPS: 400, Burst: 800}
+
+var namespacesResource = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// Loaded is a read-only client serving a Snapshot and the discovery restored from the Snapshot.
+type Loaded struct {
+	*client.Client
+	// Cluster is the cluster name the watches of the Snapshot were loaded under.
+	Cluster    string
+	Namespaces []string
+	Resources  *cache.ResourceCache
+	Access     resource.ResourceAccess
+}
+
+// Load loads the Snapshot in to the cache under the cluster and returns a read-only client serving it. Each
+// watch is added to the ResourceWatches registry with cache.LoadWatch, the watches captured from the default
+// cluster "" under the cluster and the watches captured from another cluster under cluster/<name>. The
+// client watches the cluster, so the client Watch functions and cache.WatchForClusterResource return
+// ResourceListers serving the snapshot objects. Use a cluster name that no running Watcher uses, loading
+// under the key of a running watch returns an *errors.WatchExists.
+//
+// The discovered resources, namespaces and access map are restored in to the Loaded client, cache.Resources,
+// cache.Namespaces and cache.Access are not changed. The client discovery, SelfSubjectAccessReview and
+// dynamic clients answer from the snapshot, mutations are rejected with a MethodNotSupported error. Options
+// are applied after the snapshot options, use them to set a Logger.
+func Load(ctx context.Context, cluster string, s *Snapshot, options ...client.ClientOption) (*Loaded, error) {
+	access := resource.NewResourceAccessFromEntries(s.Access)
+	resources := append(append([]resource.Resource{}, s.Resources.Namespaced...), s.Resources.Cluster...)
+
+	dynamicClient, err := newDynamicClient(ctx, s, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, watch := range s.Watches {
+		key, err := watch.WatchKey()
+		if err != nil {
+			return nil, err
+		}
+		key.Cluster = LoadedCluster(cluster, key.Cluster)
+		objs := make([]runtime.Object, len(watch.Objects))
+		for i, obj := range watch.Objects {
+			objs[i] = obj
+		}
+		if _, err := cache.LoadWatch(key, watch.Resource, objs); err != nil {
+			return nil, err
+		}
+	}
+
+	discovered := cache.NewResourceCache()
+	discovered.Add("namespace", s.Resources.Namespaced...)
+	discovered.Add("cluster", s.Resources.Cluster...)
+
+	opts := []client.ClientOption{
+		client.WithRESTConfig(RESTConfig),
+		client.WithClientsetFn(func(context.Context, *rest.Config) (kubernetes.Interface, error) {
+			return &kubernetes.Clientset{}, nil
+		}),
+		client.WithDynamicClientFn(func(context.Context, *rest.Config) (dynamic.Interface, error) {
+			return dynamicClient, nil
+		}),
+		client.WithServerResourcesFn(func(context.Context, kubernetes.Interface) (discovery.ServerResourcesInterface, error) {
+			return &serverResources{resources: resources}, nil
+		}),
+		client.WithSubjectAccessFn(func(context.Context, kubernetes.Interface) (typedAuthv1.SelfSubjectAccessReviewInterface, error) {
+			return &subjectAccess{access: access, resources: resources}, nil
+		}),
+		client.WithWatcherFn(func(ctx context.Context, logger *zap.Logger, d dynamic.Interface) (*cache.Watcher, error) {
+			return cache.NewWatcher(ctx,
+				cache.WithLogger(logger),
+				cache.WithDynamicClient(d),
+				cache.WithCluster(cluster),
+			)
+		}),
+	}
+	c, err := client.NewClient(ctx, append(opts, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &Loaded{
+		Client:     c,
+		Cluster:    cluster,
+		Namespaces: append([]string{}, s.Namespaces...),
+		Resources:  discovered,
+		Access:     access,
+	}, nil
+}
+
+// LoadedCluster returns the cluster name Load uses for the watches captured from the captured cluster.
+func LoadedCluster(cluster, captured string) string {
+	if captured == "" {
+		return cluster
+	}
+	return cluster + "/" + captured
+}
+
+// newDynamicClient creates a dynamic client serving the namespaces and objects of the snapshot that
+// rejects every mutation.
+func newDynamicClient(ctx context.Context, s *Snapshot, resources []resource.Resource) (dynamic.Interface, error) {
+	listKinds := map[schema.GroupVersionResource]string{namespacesResource: "NamespaceList"}
+	for _, r := range resources {
+		listKinds[r.GroupVersionResource()] = r.GroupVersionKind.Kind + "List"
+	}
+	for _, watch := range s.Watches {
+		listKinds[watch.Resource.GroupVersionResource()] = watch.Resource.GroupVersionKind.Kind + "List"
+	}
+
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	create := func(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+		_, err := dc.Resource(gvr).Namespace(obj.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// the object is cached by more than one watch
+			return nil
+		}
+		return err
+	}
+
+	for _, ns := range s.Namespaces {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("Namespace")
+		obj.SetName(ns)
+		if err := create(namespacesResource, obj); err != nil {
+			return nil, err
+		}
+	}
+	for _, watch := range s.Watches {
+		for _, obj := range watch.Objects {
+			if err := create(watch.Resource.GroupVersionResource(), obj.DeepCopy()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dc.PrependReactor("*", "*", func(action ktesting.Action) (bool, runtime.Object, error) {
+		switch action.GetVerb() {
+		case "get", "list", "watch":
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewMethodNotSupported(action.GetResource().GroupResource(), action.GetVerb())
+	})
+	return dc, nil
+}
+
+// serverResources serves the discovered resources of the snapshot.
+type serverResources struct {
+	resources []resource.Resource
+}
+
+var _ discovery.ServerResourcesInterface = (*serverResources)(nil)
+
+func (s *serverResources) lists(namespacedOnly bool) []*metav1.APIResourceList {
+	byGroupVersion := map[string]*metav1.APIResourceList{}
+	lists := []*metav1.APIResourceList{}
+	for _, r := range s.resources {
+		if namespacedOnly && !r.APIResource.Namespaced {
+			continue
+		}
+		gv := r.GroupVersionKind.GroupVersion().String()
+		list, ok := byGroupVersion[gv]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: gv}
+			byGroupVersion[gv] = list
+			lists = append(lists, list)
+		}
+		list.APIResources = append(list.APIResources, r.APIResource)
+	}
+	return lists
+}
+
+func (s *serverResources) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for _, list := range s.lists(false) {
+		if list.GroupVersion == groupVersion {
+			return list, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{}, groupVersion)
+}
+
+func (s *serverResources) ServerResources() ([]*metav1.APIResourceList, error) {
+	return s.lists(false), nil
+}
+
+func (s *serverResources) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	lists := s.lists(false)
+	byName := map[string]*metav1.APIGroup{}
+	groups := []*metav1.APIGroup{}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		group, ok := byName[gv.Group]
+		if !ok {
+			group = &metav1.APIGroup{Name: gv.Group}
+			byName[gv.Group] = group
+			groups = append(groups, group)
+		}
+		version := metav1.GroupVersionForDiscovery{GroupVersion: list.GroupVersion, Version: gv.Version}
+		group.Versions = append(group.Versions, version)
+		if group.PreferredVersion.Version == "" {
+			group.PreferredVersion = version
+		}
+	}
+	return groups, lists, nil
+}
+
+func (s *serverResources) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return s.lists(false), nil
+}
+
+func (s *serverResources) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	return s.lists(true), nil
+}
+
+// subjectAccess answers SelfSubjectAccessReviews from the access map of the snapshot. Reviews for
+// resources or verbs that are not in the access map are denied.
+type subjectAccess struct {
+	access    resource.ResourceAccess
+	resources []resource.Resource
+}
+
+var _ typedAuthv1.SelfSubjectAccessReviewInterface = (*subjectAccess)(nil)
+
+func (s *subjectAccess) Create(_ context.Context, review *authv1.SelfSubjectAccessReview, _ metav1.CreateOptions) (*authv1.SelfSubjectAccessReview, error) {
+	result := review.DeepCopy()
+	attrs := review.Spec.ResourceAttributes
+	if attrs == nil {
+		result.Status.Reason = "snapshot only records resource access"
+		return result, nil
+	}
+
+	for _, r := range s.resources {
+		if r.GroupVersionKind.Group == attrs.Group && r.APIResource.Name == attrs.Resource {
+			result.Status.Allowed = s.access.Allowed(attrs.Namespace, r, attrs.Verb)
+			break
+		}
+	}
+	if !result.Status.Allowed {
+		result.Status.Reason = "not allowed in snapshot"
+	}
+	return result, nil
+}
@@ -0,0 +1,22 @@
+package snapshot
+
+type captureOptions struct {
+	cluster     *string
+	runningOnly bool
+}
+
+type CaptureOption func(*captureOptions)
+
+// WithCluster only captures the watches of the named cluster, the default cluster is "".
+func WithCluster(cluster string) CaptureOption {
+	return func(o *captureOptions) {
+		o.cluster = &cluster
+	}
+}
+
+// WithRunningOnly skips watches that have been stopped.
+func WithRunningOnly() CaptureOption {
+	return func(o *captureOptions) {
+		o.runningOnly = true
+	}
+}
@@ -0,0 +1,110 @@
+// Package snapshot captures the watch cache, discovered resources and access map to a portable archive
+// and loads an archive in to a read-only client.
+package snapshot
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// Snapshot is the state of the cache at a point in time.
+type Snapshot struct {
+	CreatedAt  time.Time              `json:"createdAt"`
+	Namespaces []string               `json:"namespaces,omitempty"`
+	Resources  Resources              `json:"resources"`
+	Access     []resource.AccessEntry `json:"access,omitempty"`
+	Watches    []Watch                `json:"watches,omitempty"`
+}
+
+// Resources are the discovered resources, cache.Resources "namespace" and "cluster".
+type Resources struct {
+	Namespaced []resource.Resource `json:"namespaced,omitempty"`
+	Cluster    []resource.Resource `json:"cluster,omitempty"`
+}
+
+// Watch is a watch from the ResourceWatches registry and the objects in its cache.
+// Key is the WatchKey encoded with WatchKey.String.
+type Watch struct {
+	Key      string                       `json:"key"`
+	Resource resource.Resource            `json:"resource"`
+	Objects  []*unstructured.Unstructured `json:"objects,omitempty"`
+}
+
+// WatchKey parses the Key of the Watch.
+func (w Watch) WatchKey() (cache.WatchKey, error) {
+	return cache.ParseWatchKey(w.Key)
+}
+
+// Capture creates a Snapshot of every watch in the ResourceWatches registry, the discovered resources,
+// namespaces and access map. Objects are copied so the Snapshot is safe to modify.
+func Capture(options ...CaptureOption) (*Snapshot, error) {
+	opts := &captureOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	s := &Snapshot{
+		CreatedAt:  time.Now().UTC(),
+		Namespaces: append([]string{}, cache.Namespaces...),
+		Resources: Resources{
+			Namespaced: cache.Resources.Get("namespace"),
+			Cluster:    cache.Resources.Get("cluster"),
+		},
+	}
+	if cache.Access != nil {
+		s.Access = cache.Access.Entries()
+	}
+
+	details := []*cache.WatchDetail{}
+	cache.ResourceWatches.Range(func(k, v interface{}) bool {
+		if detail, ok := v.(*cache.WatchDetail); ok {
+			details = append(details, detail)
+		}
+		return true
+	})
+
+	for _, detail := range details {
+		key := detail.WatchKey()
+		if opts.cluster != nil && key.Cluster != *opts.cluster {
+			continue
+		}
+		if opts.runningOnly && detail.IsRunning() == 0 {
+			continue
+		}
+
+		objs, err := detail.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		watch := Watch{Key: key.String(), Resource: detail.Resource, Objects: []*unstructured.Unstructured{}}
+		for _, obj := range objs {
+			u, err := cache.ToUnstructured(obj)
+			if err != nil {
+				return nil, err
+			}
+			watch.Objects = append(watch.Objects, u.DeepCopy())
+		}
+		sortObjects(watch.Objects)
+		s.Watches = append(s.Watches, watch)
+	}
+	sort.Slice(s.Watches, func(i, j int) bool {
+		return s.Watches[i].Key < s.Watches[j].Key
+	})
+
+	return s, nil
+}
+
+func sortObjects(objs []*unstructured.Unstructured) {
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].GetNamespace() != objs[j].GetNamespace() {
+			return objs[i].GetNamespace() < objs[j].GetNamespace()
+		}
+		return objs[i].GetName() < objs[j].GetName()
+	})
+}
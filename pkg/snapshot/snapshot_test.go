@@ -0,0 +1,192 @@
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/client"
+	"github.com/wwitzel3/k8s-resource-client/pkg/errors"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	"github.com/wwitzel3/k8s-resource-client/pkg/snapshot"
+)
+
+var podResource = resource.Resource{
+	GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+	APIResource: metav1.APIResource{
+		Name:       "pods",
+		Namespaced: true,
+		Kind:       "Pod",
+		Verbs:      metav1.Verbs{"get", "list", "watch"},
+	},
+}
+
+var nodeResource = resource.Resource{
+	GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Node"},
+	APIResource: metav1.APIResource{
+		Name:  "nodes",
+		Kind:  "Node",
+		Verbs: metav1.Verbs{"get", "list", "watch"},
+	},
+}
+
+func newObject(kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion("v1")
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(map[string]string{"app": name})
+	_ = unstructured.SetNestedField(u.Object, int64(2), "spec", "replicas")
+	return u
+}
+
+func resetCache() {
+	cache.ResourceWatches = &sync.Map{}
+	cache.Resources = cache.NewResourceCache()
+	cache.Namespaces = []string{}
+	cache.Access = nil
+}
+
+// seedCache fills the cache with watches and discovery the way a running client would.
+func seedCache(t *testing.T) {
+	resetCache()
+
+	podKey := cache.WatchKey{GroupVersionResource: podResource.GroupVersionResource(), Namespace: "default"}
+	_, err := cache.LoadWatch(podKey, podResource, []runtime.Object{
+		newObject("Pod", "default", "redis"),
+		newObject("Pod", "default", "nginx"),
+	})
+	assert.Nil(t, err)
+
+	nodeKey := cache.WatchKey{Cluster: "other", GroupVersionResource: nodeResource.GroupVersionResource()}
+	_, err = cache.LoadWatch(nodeKey, nodeResource, []runtime.Object{newObject("Node", "", "node-1")})
+	assert.Nil(t, err)
+
+	cache.Resources.Add("namespace", podResource)
+	cache.Resources.Add("cluster", nodeResource)
+	cache.Namespaces = []string{"default", "test"}
+	cache.Access = resource.NewResourceAccessFromEntries([]resource.AccessEntry{
+		{Namespace: "default", Resource: podResource.Key(), Verb: "list", Status: resource.Allowed},
+		{Namespace: "default", Resource: podResource.Key(), Verb: "watch", Status: resource.Allowed},
+		{Namespace: "test", Resource: podResource.Key(), Verb: "list", Status: resource.Denied},
+	})
+}
+
+func TestCapture(t *testing.T) {
+	seedCache(t)
+
+	s, err := snapshot.Capture()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default", "test"}, s.Namespaces)
+	assert.Equal(t, []resource.Resource{podResource}, s.Resources.Namespaced)
+	assert.Equal(t, []resource.Resource{nodeResource}, s.Resources.Cluster)
+	assert.Len(t, s.Access, 3)
+	assert.Len(t, s.Watches, 2)
+
+	pods := s.Watches[0]
+	assert.Equal(t, "//v1/pods/default", pods.Key)
+	key, err := pods.WatchKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "default", key.Namespace)
+	assert.Equal(t, "nginx", pods.Objects[0].GetName())
+	assert.Equal(t, "redis", pods.Objects[1].GetName())
+
+	s, err = snapshot.Capture(snapshot.WithCluster("other"))
+	assert.Nil(t, err)
+	assert.Len(t, s.Watches, 1)
+	assert.Equal(t, "node-1", s.Watches[0].Objects[0].GetName())
+}
+
+func TestSaveOpen(t *testing.T) {
+	seedCache(t)
+	s, err := snapshot.Capture()
+	assert.Nil(t, err)
+
+	for _, name := range []string{"archive", "snapshot" + snapshot.JSONLinesExt} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			assert.Nil(t, snapshot.Save(path, s))
+
+			opened, err := snapshot.Open(path)
+			assert.Nil(t, err)
+			assert.True(t, s.CreatedAt.Equal(opened.CreatedAt))
+			opened.CreatedAt = s.CreatedAt
+			assert.Equal(t, s, opened)
+		})
+	}
+
+	// a directory archive is not written over an existing one
+	dir := t.TempDir()
+	assert.Nil(t, snapshot.Save(dir, s))
+	assert.NotNil(t, snapshot.Save(dir, s))
+}
+
+func TestOpenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot"+snapshot.JSONLinesExt)
+	data := `{"type":"snapshot","namespaces":["default"]}
+{"type":"object","key":"//v1/pods/default","object":{"kind":"Pod","apiVersion":"v1"}}
+`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0o644))
+
+	_, err := snapshot.Open(path)
+	invalid := &errors.InvalidSnapshot{}
+	assert.ErrorAs(t, err, &invalid)
+	assert.Equal(t, path+":2", invalid.Location)
+}
+
+func TestLoad(t *testing.T) {
+	seedCache(t)
+	s, err := snapshot.Capture()
+	assert.Nil(t, err)
+
+	c, err := snapshot.Load(context.TODO(), "snapshot", s, client.WithLogger(zap.NewNop()))
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot", c.Cluster)
+	assert.Equal(t, []string{"default", "test"}, c.Namespaces)
+	assert.Equal(t, []resource.Resource{podResource}, c.Resources.Get("namespace"))
+	assert.True(t, c.Access.AllowedAll("default", podResource, []string{"list", "watch"}))
+
+	// the watches and discovery of the cache are left in place
+	assert.Equal(t, []string{"default", "test"}, cache.Namespaces)
+	live, err := cache.WatchForResource(podResource, "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, live.IsRunning())
+
+	listers, err := client.WatchResource(context.TODO(), c.Client, podResource, false, []string{"default"})
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot", listers[0].Keys()[0].Cluster)
+	objs, err := listers[0].List(labels.SelectorFromSet(labels.Set{"app": "nginx"}))
+	assert.Nil(t, err)
+	assert.Len(t, objs, 1)
+	assert.True(t, listers[0].HasSynced())
+
+	lister, err := cache.WatchForClusterResource(snapshot.LoadedCluster("snapshot", "other"), nodeResource)
+	assert.Nil(t, err)
+	_, err = lister.Get("node-1")
+	assert.Nil(t, err)
+
+	// discovery and access checks are answered from the snapshot
+	resetCache()
+	assert.Nil(t, client.AutoDiscoverResources(context.TODO(), c.Client))
+	assert.Equal(t, []resource.Resource{podResource}, cache.Resources.Get("namespace"))
+
+	assert.Nil(t, client.AutoDiscoverNamespaces(context.TODO(), c.Client))
+	assert.Equal(t, []string{"default", "test"}, cache.Namespaces)
+
+	assert.Nil(t, client.AutoDiscoverAccess(context.TODO(), c.Client, "default", podResource))
+	assert.True(t, cache.Access.Allowed("default", podResource, "list"))
+	assert.Nil(t, client.AutoDiscoverAccess(context.TODO(), c.Client, "test", podResource))
+	assert.False(t, cache.Access.Allowed("test", podResource, "list"))
+}
@@ -0,0 +1,229 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+// DefaultHeartbeat is the heartbeat interval of the handlers.
+const DefaultHeartbeat = 30 * time.Second
+
+// ParseSubscription reads the Subscription of a request from its parameters and the cursor to resume from,
+// nil when the request does not resume. The parameters are:
+//
+//	resource       [group/]version/resource, repeat it to subscribe to several resources
+//	namespace      only stream the objects of the namespace
+//	labelSelector  only stream the objects matching the selector
+//	cursor         resume after the cursor, the Last-Event-ID header of an EventSource is used when absent
+func ParseSubscription(r *http.Request) (Subscription, *uint64, error) {
+	params := r.URL.Query()
+	sub := Subscription{Namespace: params.Get("namespace")}
+
+	for _, value := range params["resource"] {
+		parts := strings.Split(value, "/")
+		switch {
+		case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+			sub.Resources = append(sub.Resources, schema.GroupVersionResource{Version: parts[0], Resource: parts[1]})
+		case len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != "":
+			sub.Resources = append(sub.Resources, schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]})
+		default:
+			return sub, nil, fmt.Errorf("invalid resource %q, expected [group/]version/resource", value)
+		}
+	}
+	if len(sub.Resources) == 0 {
+		return sub, nil, fmt.Errorf("missing resource parameter")
+	}
+
+	if s := params.Get("labelSelector"); s != "" {
+		selector, err := labels.Parse(s)
+		if err != nil {
+			return sub, nil, err
+		}
+		sub.Selector = selector
+	}
+
+	s := params.Get("cursor")
+	if s == "" {
+		s = r.Header.Get("Last-Event-ID")
+	}
+	if s == "" {
+		return sub, nil, nil
+	}
+	cursor, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return sub, nil, fmt.Errorf("invalid cursor %q", s)
+	}
+	return sub, &cursor, nil
+}
+
+// subscribeRequest subscribes to the Subscription of the request when the access map allows watching its
+// resources in its namespace, the returned status code is the HTTP status of the error.
+func (h *Hub) subscribeRequest(r *http.Request, opts *handlerOptions) (*Stream, int, error) {
+	sub, cursor, err := ParseSubscription(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := allowed(opts.accessFn(), sub); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	stream, err := h.subscribe(sub, cursor)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return stream, http.StatusOK, nil
+}
+
+// allowed returns an error unless the access map allows watching every resource of the Subscription in its
+// namespace, resources that have not been discovered are not allowed.
+func allowed(access resource.ResourceAccess, sub Subscription) error {
+	if access == nil {
+		return fmt.Errorf("access has not been discovered")
+	}
+	for _, gvr := range sub.Resources {
+		res, ok := discoveredResource(gvr)
+		if !ok {
+			return fmt.Errorf("resource %s has not been discovered", gvr)
+		}
+		if !access.Allowed(sub.Namespace, res, "watch") {
+			return fmt.Errorf("watch %s is not allowed by the access map", res.Key())
+		}
+	}
+	return nil
+}
+
+func discoveredResource(gvr schema.GroupVersionResource) (resource.Resource, bool) {
+	for _, res := range cache.DiscoveredResources() {
+		if res.GroupVersionResource() == gvr {
+			return res, true
+		}
+	}
+	return resource.Resource{}, false
+}
+
+// SSEHandler serves Streams as Server-Sent Events. The event name is the event type, the data is the event
+// as JSON and the id is its cursor so an EventSource resumes where it left off when it reconnects.
+func (h *Hub) SSEHandler(options ...HandlerOption) http.Handler {
+	opts := newHandlerOptions(options)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+		stream, code, err := h.subscribeRequest(r, opts)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		defer stream.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		err = serve(r.Context(), stream, opts.heartbeat, func(e Event) error {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if e.Cursor != 0 {
+				if _, err := fmt.Fprintf(w, "id: %d\n", e.Cursor); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		})
+		if err != nil && r.Context().Err() == nil {
+			h.logger.Debug("sse stream ended", zap.Error(err))
+		}
+	})
+}
+
+// WebSocketHandler serves Streams over a WebSocket, each event is sent as a JSON text message. The
+// subscription is read from the parameters of the WebSocket URL.
+func (h *Hub) WebSocketHandler(options ...HandlerOption) websocket.Handler {
+	opts := newHandlerOptions(options)
+	return func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		stream, _, err := h.subscribeRequest(ws.Request(), opts)
+		if err != nil {
+			_ = websocket.JSON.Send(ws, Event{Type: Error, Message: err.Error()})
+			return
+		}
+		defer stream.Close()
+
+		// the client does not send messages, reading notices when it goes away
+		ctx, cancel := context.WithCancel(ws.Request().Context())
+		defer cancel()
+		go func() {
+			defer cancel()
+			var msg string
+			for websocket.Message.Receive(ws, &msg) == nil {
+			}
+		}()
+
+		err = serve(ctx, stream, opts.heartbeat, func(e Event) error {
+			return websocket.JSON.Send(ws, e)
+		})
+		if err != nil && ctx.Err() == nil {
+			h.logger.Debug("websocket stream ended", zap.Error(err))
+		}
+	}
+}
+
+func newHandlerOptions(options []HandlerOption) *handlerOptions {
+	opts := &handlerOptions{
+		heartbeat: DefaultHeartbeat,
+		accessFn:  func() resource.ResourceAccess { return cache.Access },
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return opts
+}
+
+// serve sends the events of the Stream until the context is done or the Hub is closed, a heartbeat is sent
+// when there were no events for the heartbeat interval.
+func serve(ctx context.Context, stream *Stream, heartbeat time.Duration, send func(Event) error) error {
+	for {
+		next, cancel := ctx, context.CancelFunc(func() {})
+		if heartbeat > 0 {
+			next, cancel = context.WithTimeout(ctx, heartbeat)
+		}
+		e, err := stream.Next(next)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			e = Event{Type: Heartbeat}
+		}
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package stream
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+)
+
+type HubOption func(*Hub)
+
+// WithCluster streams the watches of the named cluster, the default cluster is "".
+func WithCluster(cluster string) HubOption {
+	return func(h *Hub) {
+		h.cluster = cluster
+	}
+}
+
+// WithBufferSize sets how many events are buffered for each Stream before it has to resync.
+func WithBufferSize(n int) HubOption {
+	return func(h *Hub) {
+		h.bufferSize = n
+	}
+}
+
+// WithHistorySize sets how many events are kept for resuming Streams.
+func WithHistorySize(n int) HubOption {
+	return func(h *Hub) {
+		h.historySize = n
+	}
+}
+
+func WithLogger(logger *zap.Logger) HubOption {
+	return func(h *Hub) {
+		h.logger = logger
+	}
+}
+
+type handlerOptions struct {
+	heartbeat time.Duration
+	accessFn  func() resource.ResourceAccess
+}
+
+type HandlerOption func(*handlerOptions)
+
+// WithHeartbeat sets how long a connection can be idle before a heartbeat is sent, 0 disables heartbeats.
+func WithHeartbeat(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) {
+		o.heartbeat = d
+	}
+}
+
+// WithAccess enforces the access map instead of cache.Access.
+func WithAccess(access resource.ResourceAccess) HandlerOption {
+	return func(o *handlerOptions) {
+		o.accessFn = func() resource.ResourceAccess { return access }
+	}
+}
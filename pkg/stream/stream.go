@@ -0,0 +1,383 @@
+// Package stream streams the watch events of the cache to WebSocket and Server-Sent Events clients.
+//
+// A Hub receives the events of every watch through cache.AddEventHandler, numbers them with a cursor and
+// keeps the most recent in a history. An object cached by overlapping watches, such as a namespace watch and a
+// NamespaceAll watch, is published once: an add or update only when the object is newer than the published
+// one and a delete once no watch caches the object. Each Stream subscribes to resources, optionally in a
+// namespace and matching a label selector. A new Stream starts with the current objects followed by a synced
+// event, a resumed Stream replays the events after its cursor from the history instead. Events
+// are buffered per Stream, when a slow consumer fills its buffer the buffered events are dropped and the
+// Stream sends a resync event followed by the listed objects again. The HTTP handlers only subscribe to the
+// discovered resources the access map allows watching in the namespace of the subscription.
+package stream
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+)
+
+// Event types.
+const (
+	Added   = "add"
+	Updated = "update"
+	Deleted = "delete"
+	// Synced follows the initial objects, or the replayed events of a resumed Stream.
+	Synced = "synced"
+	// Resync tells the client to discard its objects, the current objects follow as adds and a synced event.
+	Resync = "resync"
+	// Heartbeat is sent when there were no events for the heartbeat interval.
+	Heartbeat = "heartbeat"
+	// Error is sent before closing a stream that can not be served, Message has the reason.
+	Error = "error"
+)
+
+// Event is a single streamed event. Cursor is the position of the event in the Hub, pass the last Cursor
+// received to resume a Stream. Resync events and listed objects have no Cursor, the synced event following
+// them does.
+type Event struct {
+	Type    string                     `json:"type"`
+	Cursor  uint64                     `json:"cursor"`
+	Key     string                     `json:"key,omitempty"`
+	Object  *unstructured.Unstructured `json:"object,omitempty"`
+	Message string                     `json:"message,omitempty"`
+
+	watchKey cache.WatchKey
+	old      *unstructured.Unstructured
+}
+
+// Subscription selects the events of a Stream.
+type Subscription struct {
+	Resources []schema.GroupVersionResource
+	// Namespace limits the events to a namespace, "" is every namespace.
+	Namespace string
+	// Selector limits the events to objects with matching labels, nil matches everything.
+	Selector labels.Selector
+}
+
+// event returns the event as seen by the subscription. Like a watch with a label selector, an update of an
+// object that starts matching is an add and one that stops matching is a delete.
+func (s Subscription) event(e Event) (Event, bool) {
+	found := false
+	for _, gvr := range s.Resources {
+		if e.watchKey.GroupVersionResource == gvr {
+			found = true
+			break
+		}
+	}
+	if !found || !s.matches(e.Object) {
+		if e.Type == Updated && e.old != nil && found && s.matches(e.old) {
+			e.Type = Deleted
+			return e, true
+		}
+		return e, false
+	}
+	if e.Type == Updated && e.old != nil && !s.matches(e.old) {
+		e.Type = Added
+	}
+	return e, true
+}
+
+func (s Subscription) matches(obj *unstructured.Unstructured) bool {
+	if s.Namespace != "" && obj.GetNamespace() != s.Namespace {
+		return false
+	}
+	return s.Selector == nil || s.Selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// streamedObject is the last published state of an object and the watches caching it.
+type streamedObject struct {
+	object  *unstructured.Unstructured
+	watches map[cache.WatchKey]struct{}
+}
+
+// Hub distributes the watch events of a cluster to Streams, create it with NewHub.
+type Hub struct {
+	mu      sync.Mutex
+	cursor  uint64
+	history []Event
+	streams map[*Stream]struct{}
+	// objects are the published objects of each resource by UID, or by namespace and name without one
+	objects map[schema.GroupVersionResource]map[string]*streamedObject
+	dropped uint64
+	closed  bool
+	remove  func()
+
+	cluster     string
+	bufferSize  int
+	historySize int
+	logger      *zap.Logger
+}
+
+var _ cache.EventHandler = (*Hub)(nil)
+
+// NewHub creates a Hub and registers it with cache.AddEventHandler, Close unregisters it.
+func NewHub(options ...HubOption) *Hub {
+	h := &Hub{
+		streams:     map[*Stream]struct{}{},
+		objects:     map[schema.GroupVersionResource]map[string]*streamedObject{},
+		bufferSize:  DefaultBufferSize,
+		historySize: DefaultHistorySize,
+		logger:      zap.NewNop(),
+	}
+	for _, opt := range options {
+		opt(h)
+	}
+	if h.bufferSize < 1 {
+		h.bufferSize = 1
+	}
+	h.remove = cache.AddEventHandler(h)
+	return h
+}
+
+const (
+	DefaultBufferSize  = 256
+	DefaultHistorySize = 4096
+)
+
+func (h *Hub) OnAdd(key cache.WatchKey, obj runtime.Object) {
+	h.publish(Added, key, obj)
+}
+
+func (h *Hub) OnUpdate(key cache.WatchKey, _, newObj runtime.Object) {
+	h.publish(Updated, key, newObj)
+}
+
+func (h *Hub) OnDelete(key cache.WatchKey, obj runtime.Object) {
+	h.publish(Deleted, key, obj)
+}
+
+func (h *Hub) publish(eventType string, key cache.WatchKey, obj runtime.Object) {
+	// filtered watches duplicate the events of the unfiltered watches
+	if key.Cluster != h.cluster || key.Selector != "" {
+		return
+	}
+	u, err := cache.ToUnstructured(obj)
+	if err != nil {
+		h.logger.Debug("unable to stream object", zap.String("key", key.String()), zap.Error(err))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	e, ok := h.track(eventType, key, u)
+	if !ok {
+		return
+	}
+	h.cursor++
+	e.Cursor = h.cursor
+	if len(h.history) >= h.historySize {
+		h.history = append(h.history[1:], e)
+	} else {
+		h.history = append(h.history, e)
+	}
+
+	for s := range h.streams {
+		se, ok := s.sub.event(e)
+		if !ok {
+			continue
+		}
+		select {
+		case s.events <- se:
+		default:
+			// the consumer is too slow, drop what it has not read and have it resync
+			h.dropped++
+			h.logger.Debug("stream buffer full, resyncing", zap.Uint64("cursor", e.Cursor))
+		drain:
+			for {
+				select {
+				case <-s.events:
+				default:
+					break drain
+				}
+			}
+			s.events <- Event{Type: Resync, Cursor: e.Cursor}
+		}
+	}
+}
+
+// track records the event of the watch in the published objects and returns the event to publish, false
+// when another watch of the object already published it. The caller must hold the lock.
+func (h *Hub) track(eventType string, key cache.WatchKey, u *unstructured.Unstructured) (Event, bool) {
+	e := Event{Type: eventType, Key: key.String(), Object: u, watchKey: key}
+
+	id := string(u.GetUID())
+	if id == "" {
+		id = u.GetNamespace() + "/" + u.GetName()
+	}
+	objects, ok := h.objects[key.GroupVersionResource]
+	if !ok {
+		objects = map[string]*streamedObject{}
+		h.objects[key.GroupVersionResource] = objects
+	}
+	o, ok := objects[id]
+
+	if eventType == Deleted {
+		if !ok {
+			return e, false
+		}
+		delete(o.watches, key)
+		if len(o.watches) > 0 {
+			return e, false
+		}
+		delete(objects, id)
+		return e, true
+	}
+
+	if !ok {
+		objects[id] = &streamedObject{object: u, watches: map[cache.WatchKey]struct{}{key: {}}}
+		e.Type = Added
+		return e, true
+	}
+	o.watches[key] = struct{}{}
+	if !newerResourceVersion(u.GetResourceVersion(), o.object.GetResourceVersion()) {
+		return e, false
+	}
+	e.Type = Updated
+	e.old = o.object
+	o.object = u
+	return e, true
+}
+
+// newerResourceVersion returns true when the resource version is newer than the published one. Resource
+// versions are compared as numbers, as etcd assigns them, and are newer when they differ otherwise. Objects
+// without a resource version are always newer.
+func newerResourceVersion(rv, published string) bool {
+	if rv == "" || published == "" {
+		return true
+	}
+	a, errA := strconv.ParseUint(rv, 10, 64)
+	b, errB := strconv.ParseUint(published, 10, 64)
+	if errA != nil || errB != nil {
+		return rv != published
+	}
+	return a > b
+}
+
+// Dropped returns how many times a Stream buffer was full and the Stream had to resync.
+func (h *Hub) Dropped() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.dropped
+}
+
+// Cursor returns the cursor of the last event.
+func (h *Hub) Cursor() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.cursor
+}
+
+// Close unregisters the Hub, its Streams return io.EOF once their buffered events are read.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	h.objects = map[schema.GroupVersionResource]map[string]*streamedObject{}
+	h.remove()
+	for s := range h.streams {
+		close(s.events)
+	}
+	h.streams = map[*Stream]struct{}{}
+}
+
+// Subscribe creates a Stream starting with the current objects.
+func (h *Hub) Subscribe(sub Subscription) (*Stream, error) {
+	return h.subscribe(sub, nil)
+}
+
+// SubscribeFrom creates a Stream resuming after the cursor. When the events after the cursor are no
+// longer in the history the Stream starts with a resync.
+func (h *Hub) SubscribeFrom(sub Subscription, cursor uint64) (*Stream, error) {
+	return h.subscribe(sub, &cursor)
+}
+
+func (h *Hub) subscribe(sub Subscription, cursor *uint64) (*Stream, error) {
+	if len(sub.Resources) == 0 {
+		return nil, fmt.Errorf("subscription has no resources")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, fmt.Errorf("hub is closed")
+	}
+
+	s := &Stream{hub: h, sub: sub, events: make(chan Event, h.bufferSize)}
+	switch {
+	case cursor == nil:
+		s.snapshot = &snapshot{}
+	case h.replayable(*cursor):
+		for _, e := range h.history {
+			if e.Cursor <= *cursor {
+				continue
+			}
+			if se, ok := sub.event(e); ok {
+				s.pending = append(s.pending, se)
+			}
+		}
+		s.pending = append(s.pending, Event{Type: Synced, Cursor: h.cursor})
+	default:
+		s.snapshot = &snapshot{resync: true}
+	}
+	h.streams[s] = struct{}{}
+	return s, nil
+}
+
+// replayable returns true when every event after the cursor is in the history.
+func (h *Hub) replayable(cursor uint64) bool {
+	if cursor == h.cursor {
+		return true
+	}
+	return cursor < h.cursor && len(h.history) > 0 && h.history[0].Cursor <= cursor+1
+}
+
+func (h *Hub) unsubscribe(s *Stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.streams, s)
+}
+
+// list returns the current objects of the subscription sorted by namespace and name, and the cursor of the
+// last event they reflect. Listing holds the lock, so no event is published while the objects are listed.
+func (h *Hub) list(sub Subscription) ([]Event, uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := []Event{}
+	for _, gvr := range sub.Resources {
+		key := cache.WatchKey{Cluster: h.cluster, GroupVersionResource: gvr, Namespace: sub.Namespace}
+		listed := []*unstructured.Unstructured{}
+		for _, o := range h.objects[gvr] {
+			if sub.matches(o.object) {
+				listed = append(listed, o.object)
+			}
+		}
+		sort.Slice(listed, func(i, j int) bool {
+			if listed[i].GetNamespace() != listed[j].GetNamespace() {
+				return listed[i].GetNamespace() < listed[j].GetNamespace()
+			}
+			return listed[i].GetName() < listed[j].GetName()
+		})
+		for _, u := range listed {
+			events = append(events, Event{Type: Added, Key: key.String(), Object: u})
+		}
+	}
+	return events, h.cursor
+}
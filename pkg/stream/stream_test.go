@@ -0,0 +1,318 @@
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/wwitzel3/k8s-resource-client/pkg/cache"
+	wtesting "github.com/wwitzel3/k8s-resource-client/pkg/cache/testing"
+	"github.com/wwitzel3/k8s-resource-client/pkg/resource"
+	"github.com/wwitzel3/k8s-resource-client/pkg/stream"
+)
+
+var podsGVR = wtesting.PodResource.GroupVersionResource()
+
+func newPod(namespace, name, resourceVersion string, labels map[string]string) *unstructured.Unstructured {
+	u := wtesting.NewPod(namespace, name)
+	u.SetResourceVersion(resourceVersion)
+	u.SetLabels(labels)
+	return u
+}
+
+func next(t *testing.T, s *stream.Stream) stream.Event {
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	e, err := s.Next(ctx)
+	assert.Nil(t, err)
+	return e
+}
+
+func summary(e stream.Event) string {
+	if e.Object == nil {
+		return e.Type
+	}
+	return e.Type + ":" + e.Object.GetNamespace() + "/" + e.Object.GetName()
+}
+
+func TestStream(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	informer := wtesting.WatchPods(t, metav1.NamespaceAll, newPod("default", "nginx", "1", map[string]string{"app": "web"}))
+	hub := stream.NewHub()
+	defer hub.Close()
+
+	s, err := hub.Subscribe(stream.Subscription{
+		Resources: []schema.GroupVersionResource{podsGVR},
+		Namespace: "default",
+		Selector:  labels.SelectorFromSet(labels.Set{"app": "web"}),
+	})
+	assert.Nil(t, err)
+	defer s.Close()
+
+	added := next(t, s)
+	assert.Equal(t, "add:default/nginx", summary(added))
+	assert.Equal(t, uint64(0), added.Cursor)
+	assert.Equal(t, "synced", summary(next(t, s)))
+
+	assert.Nil(t, informer.Add(newPod("test", "redis", "2", map[string]string{"app": "web"})))
+	assert.Nil(t, informer.Add(newPod("default", "db", "3", map[string]string{"app": "db"})))
+	assert.Nil(t, informer.Add(newPod("default", "redis", "4", map[string]string{"app": "web"})))
+	// updates moving objects in and out of the selector are adds and deletes
+	assert.Nil(t, informer.Update(newPod("default", "db", "5", map[string]string{"app": "web"})))
+	assert.Nil(t, informer.Update(newPod("default", "nginx", "6", map[string]string{"app": "proxy"})))
+	assert.Nil(t, informer.Update(newPod("default", "redis", "7", map[string]string{"app": "web", "tier": "cache"})))
+	assert.Nil(t, informer.Delete(newPod("default", "redis", "7", map[string]string{"app": "web", "tier": "cache"})))
+
+	events := []string{}
+	for i := 0; i < 5; i++ {
+		e := next(t, s)
+		assert.NotZero(t, e.Cursor)
+		assert.Equal(t, cache.WatchKey{GroupVersionResource: podsGVR}.String(), e.Key)
+		events = append(events, summary(e))
+	}
+	assert.Equal(t, []string{"add:default/redis", "add:default/db", "delete:default/nginx", "update:default/redis", "delete:default/redis"}, events)
+
+	_, err = hub.Subscribe(stream.Subscription{})
+	assert.NotNil(t, err)
+
+	hub.Close()
+	_, err = s.Next(context.TODO())
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamResume(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	informer := wtesting.WatchPods(t, metav1.NamespaceAll)
+	hub := stream.NewHub(stream.WithHistorySize(2))
+	defer hub.Close()
+	sub := stream.Subscription{Resources: []schema.GroupVersionResource{podsGVR}}
+
+	assert.Nil(t, informer.Add(newPod("default", "nginx", "1", nil)))
+	cursor := hub.Cursor()
+	assert.Nil(t, informer.Add(newPod("default", "redis", "2", nil)))
+
+	s, err := hub.SubscribeFrom(sub, cursor)
+	assert.Nil(t, err)
+	assert.Equal(t, "add:default/redis", summary(next(t, s)))
+	synced := next(t, s)
+	assert.Equal(t, "synced", synced.Type)
+	assert.Equal(t, hub.Cursor(), synced.Cursor)
+	s.Close()
+
+	// the events after the cursor are no longer in the history
+	assert.Nil(t, informer.Add(newPod("default", "db", "3", nil)))
+	assert.Nil(t, informer.Add(newPod("default", "web", "4", nil)))
+	s, err = hub.SubscribeFrom(sub, cursor)
+	assert.Nil(t, err)
+	defer s.Close()
+	events := []string{}
+	for i := 0; i < 6; i++ {
+		events = append(events, summary(next(t, s)))
+	}
+	assert.Equal(t, []string{"resync", "add:default/db", "add:default/nginx", "add:default/redis", "add:default/web", "synced"}, events)
+}
+
+func TestStreamSlowConsumer(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	informer := wtesting.WatchPods(t, metav1.NamespaceAll)
+	hub := stream.NewHub(stream.WithBufferSize(2))
+	defer hub.Close()
+
+	s, err := hub.Subscribe(stream.Subscription{Resources: []schema.GroupVersionResource{podsGVR}})
+	assert.Nil(t, err)
+	defer s.Close()
+	assert.Equal(t, "synced", next(t, s).Type)
+
+	for _, name := range []string{"a", "b", "c"} {
+		assert.Nil(t, informer.Add(newPod("default", name, "1", nil)))
+	}
+	assert.Equal(t, uint64(1), hub.Dropped())
+
+	events := []string{}
+	for i := 0; i < 5; i++ {
+		events = append(events, summary(next(t, s)))
+	}
+	assert.Equal(t, []string{"resync", "add:default/a", "add:default/b", "add:default/c", "synced"}, events)
+}
+
+func TestStreamListCursor(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	informer := wtesting.WatchPods(t, metav1.NamespaceAll)
+	hub := stream.NewHub()
+	defer hub.Close()
+
+	s, err := hub.Subscribe(stream.Subscription{Resources: []schema.GroupVersionResource{podsGVR}})
+	assert.Nil(t, err)
+	defer s.Close()
+
+	// the objects are listed by the first Next, the events published before are part of the list
+	assert.Nil(t, informer.Add(newPod("default", "nginx", "1", nil)))
+	assert.Nil(t, informer.Add(newPod("default", "redis", "2", nil)))
+	assert.Equal(t, "add:default/nginx", summary(next(t, s)))
+	assert.Equal(t, "add:default/redis", summary(next(t, s)))
+	synced := next(t, s)
+	assert.Equal(t, stream.Synced, synced.Type)
+	assert.Equal(t, hub.Cursor(), synced.Cursor)
+
+	assert.Nil(t, informer.Add(newPod("default", "db", "3", nil)))
+	added := next(t, s)
+	assert.Equal(t, "add:default/db", summary(added))
+	assert.Equal(t, synced.Cursor+1, added.Cursor)
+}
+
+func TestStreamOverlappingWatches(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	hub := stream.NewHub()
+	defer hub.Close()
+
+	s, err := hub.Subscribe(stream.Subscription{Resources: []schema.GroupVersionResource{podsGVR}})
+	assert.Nil(t, err)
+	defer s.Close()
+	assert.Equal(t, stream.Synced, next(t, s).Type)
+
+	// both watches deliver the events of the objects in the namespace
+	defaultNS := cache.WatchKey{GroupVersionResource: podsGVR, Namespace: "default"}
+	all := cache.WatchKey{GroupVersionResource: podsGVR}
+	hub.OnAdd(defaultNS, newPod("default", "nginx", "1", nil))
+	hub.OnAdd(all, newPod("default", "nginx", "1", nil))
+	hub.OnUpdate(all, newPod("default", "nginx", "1", nil), newPod("default", "nginx", "2", nil))
+	hub.OnUpdate(defaultNS, newPod("default", "nginx", "1", nil), newPod("default", "nginx", "2", nil))
+	hub.OnAdd(all, newPod("test", "redis", "3", nil))
+
+	// stopping the namespace watch deletes its objects, the NamespaceAll watch still has them
+	hub.OnDelete(defaultNS, newPod("default", "nginx", "2", nil))
+	hub.OnDelete(all, newPod("default", "nginx", "2", nil))
+
+	events := []string{}
+	for i := 0; i < 4; i++ {
+		events = append(events, summary(next(t, s)))
+	}
+	assert.Equal(t, []string{"add:default/nginx", "update:default/nginx", "add:test/redis", "delete:default/nginx"}, events)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// allowPods discovers the pods resource and returns an access map allowing to watch it in the namespaces.
+func allowPods(t *testing.T, namespaces ...string) stream.HandlerOption {
+	cache.Resources = cache.NewResourceCache()
+	cache.Resources.Add("namespace", wtesting.PodResource)
+	t.Cleanup(func() { cache.Resources = cache.NewResourceCache() })
+
+	entries := []resource.AccessEntry{}
+	for _, ns := range namespaces {
+		entries = append(entries, resource.AccessEntry{Namespace: ns, Resource: wtesting.PodResource.Key(), Verb: "watch", Status: resource.Allowed})
+	}
+	return stream.WithAccess(resource.NewResourceAccessFromEntries(entries))
+}
+
+func TestSSEHandler(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	informer := wtesting.WatchPods(t, metav1.NamespaceAll, newPod("default", "nginx", "1", nil))
+	hub := stream.NewHub()
+	defer hub.Close()
+
+	server := httptest.NewServer(hub.SSEHandler(stream.WithHeartbeat(10*time.Millisecond), allowPods(t, "default")))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?resource=pods")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// watching requires watch access in the namespace of the subscription
+	for _, query := range []string{"?resource=v1/pods", "?resource=v1/pods&namespace=test", "?resource=apps/v1/deployments&namespace=default"} {
+		resp, err = http.Get(server.URL + query)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, query)
+		resp.Body.Close()
+	}
+
+	// cache.Access is enforced by default
+	defaultServer := httptest.NewServer(hub.SSEHandler())
+	defer defaultServer.Close()
+	resp, err = http.Get(defaultServer.URL + "?resource=v1/pods&namespace=default")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "?resource=v1/pods&namespace=default")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	read := func() []string {
+		lines := []string{}
+		for {
+			line, err := reader.ReadString('\n')
+			assert.Nil(t, err)
+			if line == "\n" {
+				return lines
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	lines := read()
+	assert.Equal(t, "event: add", lines[0])
+	e := stream.Event{}
+	assert.Nil(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &e))
+	assert.Equal(t, "nginx", e.Object.GetName())
+	assert.Equal(t, []string{"id: 1", "event: synced", `data: {"type":"synced","cursor":1}`}, read())
+
+	assert.Nil(t, informer.Add(newPod("default", "redis", "2", nil)))
+	for {
+		lines = read()
+		if lines[0] != "event: heartbeat" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"id: 2", "event: add"}, lines[:2])
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	cache.ResourceWatches = &sync.Map{}
+	informer := wtesting.WatchPods(t, metav1.NamespaceAll)
+	hub := stream.NewHub()
+	defer hub.Close()
+
+	server := httptest.NewServer(hub.WebSocketHandler(allowPods(t, "")))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	ws, err := websocket.Dial(url+"?resource=v1/pods&cursor=invalid", "", server.URL)
+	assert.Nil(t, err)
+	e := stream.Event{}
+	assert.Nil(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, stream.Error, e.Type)
+	ws.Close()
+
+	ws, err = websocket.Dial(url+"?resource=v1/pods", "", server.URL)
+	assert.Nil(t, err)
+	defer ws.Close()
+	assert.Nil(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, stream.Synced, e.Type)
+
+	assert.Nil(t, informer.Add(newPod("default", "nginx", "1", nil)))
+	e = stream.Event{}
+	assert.Nil(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, "add:default/nginx", summary(e))
+	assert.Equal(t, uint64(1), e.Cursor)
+}
@@ -0,0 +1,73 @@
+package stream
+
+import (
+	"context"
+	"io"
+)
+
+// Stream is a subscription to the events of a Hub, read it with Next and Close it when done.
+type Stream struct {
+	hub      *Hub
+	sub      Subscription
+	events   chan Event
+	pending  []Event
+	snapshot *snapshot
+	// listed is the cursor of the last list of the current objects, the buffered events at or below it are
+	// part of the list
+	listed uint64
+}
+
+// snapshot is a pending list of the current objects, resync when it follows a resync event.
+type snapshot struct {
+	resync bool
+}
+
+// Next returns the next event, waiting for one until the context is done. io.EOF is returned once the Hub
+// is closed. A context that is done does not lose events, Next can be called again.
+func (s *Stream) Next(ctx context.Context) (Event, error) {
+	for {
+		if s.snapshot != nil {
+			s.pending = append(s.pending, s.list(*s.snapshot)...)
+			s.snapshot = nil
+		}
+		if len(s.pending) > 0 {
+			e := s.pending[0]
+			s.pending = s.pending[1:]
+			return e, nil
+		}
+
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				return Event{}, io.EOF
+			}
+			if e.Type == Resync {
+				s.snapshot = &snapshot{resync: true}
+				continue
+			}
+			if e.Cursor <= s.listed {
+				continue
+			}
+			return e, nil
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		}
+	}
+}
+
+func (s *Stream) list(snap snapshot) []Event {
+	events := []Event{}
+	if snap.resync {
+		events = append(events, Event{Type: Resync})
+	}
+	// only synced has a cursor, a Stream resumed before it would miss the rest of the objects
+	listed, cursor := s.hub.list(s.sub)
+	s.listed = cursor
+	events = append(events, listed...)
+	return append(events, Event{Type: Synced, Cursor: cursor})
+}
+
+// Close unsubscribes the Stream from the Hub.
+func (s *Stream) Close() {
+	s.hub.unsubscribe(s)
+}